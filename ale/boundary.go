@@ -0,0 +1,118 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+// Package ale implements the ALE event cycles on top of the filtering engines
+package ale
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RTCTriggerPrefix is the prefix of the real-time clock trigger URIs
+// urn:epcglobal:ale:trigger:rtc:<period>.<offset>[.<timezone>]
+const RTCTriggerPrefix = "urn:epcglobal:ale:trigger:rtc:"
+
+// ECBoundarySpec specifies how an event cycle starts and stops
+type ECBoundarySpec struct {
+	// Duration closes the cycle after the time from the start
+	Duration time.Duration
+	// RepeatPeriod starts the next cycle after the time from the previous start
+	RepeatPeriod time.Duration
+	// StableSetInterval closes the cycle when no new tag is seen for the time
+	StableSetInterval time.Duration
+	// StartTriggers start a cycle when any of them fires
+	StartTriggers []string
+	// StopTriggers close the cycle when any of them fires
+	StopTriggers []string
+}
+
+// Validate returns an error if the ECBoundarySpec is not valid
+func (spec *ECBoundarySpec) Validate() error {
+	if spec.Duration < 0 {
+		return fmt.Errorf("negative duration: %v", spec.Duration)
+	}
+	if spec.RepeatPeriod < 0 {
+		return fmt.Errorf("negative repeatPeriod: %v", spec.RepeatPeriod)
+	}
+	if spec.StableSetInterval < 0 {
+		return fmt.Errorf("negative stableSetInterval: %v", spec.StableSetInterval)
+	}
+	for _, trigger := range append(append([]string{}, spec.StartTriggers...), spec.StopTriggers...) {
+		if _, err := ParseTrigger(trigger); err != nil {
+			return err
+		}
+	}
+	if spec.Duration == 0 && spec.StableSetInterval == 0 && len(spec.StopTriggers) == 0 {
+		return fmt.Errorf("no stop condition: specify duration, stableSetInterval, or stopTrigger")
+	}
+	return nil
+}
+
+// Trigger is an ALE trigger identified by its URI
+// a trigger with zero Period fires only when requested
+type Trigger struct {
+	URI      string
+	Period   time.Duration
+	Offset   time.Duration
+	Location *time.Location
+}
+
+// ParseTrigger parses the trigger URI
+func ParseTrigger(uri string) (*Trigger, error) {
+	if len(uri) == 0 {
+		return nil, fmt.Errorf("empty trigger URI")
+	}
+	t := &Trigger{URI: uri}
+	if !strings.HasPrefix(uri, RTCTriggerPrefix) {
+		return t, nil
+	}
+
+	fields := strings.SplitN(strings.TrimPrefix(uri, RTCTriggerPrefix), ".", 3)
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("invalid rtc trigger: %s", uri)
+	}
+	period, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil || period <= 0 || period > 24*60*60*1000 {
+		return nil, fmt.Errorf("invalid period in rtc trigger: %s", uri)
+	}
+	offset, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || offset < 0 || offset >= period {
+		return nil, fmt.Errorf("invalid offset in rtc trigger: %s", uri)
+	}
+	t.Period = time.Duration(period) * time.Millisecond
+	t.Offset = time.Duration(offset) * time.Millisecond
+	t.Location = time.Local
+	if len(fields) == 3 {
+		tz, err := time.Parse("Z07:00", fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone in rtc trigger: %s", uri)
+		}
+		t.Location = tz.Location()
+	}
+	return t, nil
+}
+
+// Next returns the time the Trigger fires after the now
+// and false if the Trigger is not a real-time clock trigger
+func (t *Trigger) Next(now time.Time) (time.Time, bool) {
+	if t.Period == 0 {
+		return time.Time{}, false
+	}
+	now = now.In(t.Location)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, t.Location)
+	elapsed := now.Sub(midnight) - t.Offset
+	next := midnight.Add(t.Offset + (elapsed/t.Period+1)*t.Period)
+	if elapsed < 0 {
+		next = midnight.Add(t.Offset)
+	}
+	// the period restarts at every midnight
+	if tomorrow := midnight.AddDate(0, 0, 1); !next.Before(tomorrow) {
+		next = tomorrow.Add(t.Offset)
+	}
+	return next, true
+}
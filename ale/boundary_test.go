@@ -0,0 +1,130 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package ale
+
+import (
+	"testing"
+	"time"
+)
+
+func TestECBoundarySpec_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    ECBoundarySpec
+		wantErr bool
+	}{
+		{"duration", ECBoundarySpec{Duration: time.Second}, false},
+		{"stableSetInterval", ECBoundarySpec{StableSetInterval: time.Second}, false},
+		{"stopTrigger", ECBoundarySpec{StopTriggers: []string{"urn:example:stop"}}, false},
+		{"rtc", ECBoundarySpec{Duration: time.Second, StartTriggers: []string{"urn:epcglobal:ale:trigger:rtc:60000.0"}}, false},
+		{"no stop condition", ECBoundarySpec{RepeatPeriod: time.Second}, true},
+		{"negative duration", ECBoundarySpec{Duration: -time.Second}, true},
+		{"negative repeatPeriod", ECBoundarySpec{Duration: time.Second, RepeatPeriod: -time.Second}, true},
+		{"negative stableSetInterval", ECBoundarySpec{StableSetInterval: -time.Second}, true},
+		{"invalid rtc", ECBoundarySpec{Duration: time.Second, StartTriggers: []string{"urn:epcglobal:ale:trigger:rtc:60000"}}, true},
+		{"empty trigger", ECBoundarySpec{StopTriggers: []string{""}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.spec.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("ECBoundarySpec.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseTrigger(t *testing.T) {
+	tests := []struct {
+		name       string
+		uri        string
+		wantPeriod time.Duration
+		wantOffset time.Duration
+		wantErr    bool
+	}{
+		{"external", "urn:example:dock-door:open", 0, 0, false},
+		{"rtc", "urn:epcglobal:ale:trigger:rtc:60000.5000", time.Minute, 5 * time.Second, false},
+		{"rtc with timezone", "urn:epcglobal:ale:trigger:rtc:3600000.0.+09:00", time.Hour, 0, false},
+		{"rtc with Z", "urn:epcglobal:ale:trigger:rtc:3600000.0.Z", time.Hour, 0, false},
+		{"offset exceeds period", "urn:epcglobal:ale:trigger:rtc:1000.1000", 0, 0, true},
+		{"period exceeds a day", "urn:epcglobal:ale:trigger:rtc:86400001.0", 0, 0, true},
+		{"invalid timezone", "urn:epcglobal:ale:trigger:rtc:1000.0.JST", 0, 0, true},
+		{"empty", "", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTrigger(tt.uri)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseTrigger() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if got.Period != tt.wantPeriod || got.Offset != tt.wantOffset {
+				t.Errorf("ParseTrigger() = %v.%v, want %v.%v", got.Period, got.Offset, tt.wantPeriod, tt.wantOffset)
+			}
+		})
+	}
+}
+
+func TestTrigger_Next(t *testing.T) {
+	jst := time.FixedZone("", 9*60*60)
+	tests := []struct {
+		name   string
+		uri    string
+		now    time.Time
+		want   time.Time
+		wantOk bool
+	}{
+		{
+			"every minute at 5s",
+			"urn:epcglobal:ale:trigger:rtc:60000.5000.Z",
+			time.Date(2018, 1, 2, 3, 4, 30, 0, time.UTC),
+			time.Date(2018, 1, 2, 3, 5, 5, 0, time.UTC),
+			true,
+		},
+		{
+			"strictly after",
+			"urn:epcglobal:ale:trigger:rtc:60000.5000.Z",
+			time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC),
+			time.Date(2018, 1, 2, 3, 5, 5, 0, time.UTC),
+			true,
+		},
+		{
+			"before the offset",
+			"urn:epcglobal:ale:trigger:rtc:3600000.1800000.Z",
+			time.Date(2018, 1, 2, 0, 10, 0, 0, time.UTC),
+			time.Date(2018, 1, 2, 0, 30, 0, 0, time.UTC),
+			true,
+		},
+		{
+			"restarts at midnight",
+			"urn:epcglobal:ale:trigger:rtc:25200000.0.+09:00",
+			time.Date(2018, 1, 2, 22, 0, 0, 0, jst),
+			time.Date(2018, 1, 3, 0, 0, 0, 0, jst),
+			true,
+		},
+		{
+			"external",
+			"urn:example:dock-door:open",
+			time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC),
+			time.Time{},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trigger, err := ParseTrigger(tt.uri)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := trigger.Next(tt.now)
+			if ok != tt.wantOk || !got.Equal(tt.want) {
+				t.Errorf("Trigger.Next() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package ale
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ReportSetType selects the tags to report from a cycle
+type ReportSetType string
+
+// ReportSetTypes
+const (
+	// Current lists every tag seen in the cycle
+	Current ReportSetType = "CURRENT"
+	// Additions lists the tags new since the previous cycle
+	Additions ReportSetType = "ADDITIONS"
+	// Deletions lists the tags seen in the previous cycle but not in the cycle
+	Deletions ReportSetType = "DELETIONS"
+)
+
+// ReportSetTypes contains the names of the available ReportSetTypes
+var ReportSetTypes = []string{string(Current), string(Additions), string(Deletions)}
+
+// ParseReportSetTypes parses the comma-separated ReportSetTypes
+func ParseReportSetTypes(s string) ([]ReportSetType, error) {
+	sets := []ReportSetType{}
+	for _, name := range strings.Split(s, ",") {
+		set := ReportSetType(strings.ToUpper(strings.TrimSpace(name)))
+		switch set {
+		case Current, Additions, Deletions:
+			sets = append(sets, set)
+		default:
+			return nil, fmt.Errorf("unknown report set: %q", name)
+		}
+	}
+	return sets, nil
+}
+
+// ECReportSpec specifies the reports for a reportURI
+type ECReportSpec struct {
+	Sets          []ReportSetType
+	ReportIfEmpty bool
+}
+
+// ECReport is a set of the tags for a reportURI
+type ECReport struct {
+	ReportURI string
+	Set       ReportSetType
+	IDs       []string
+}
+
+// ECReportsGenerator makes the ECReports from the closed Cycles,
+// it remembers the previous Cycle for the Additions and Deletions
+type ECReportsGenerator struct {
+	defaultSpec ECReportSpec
+	specs       map[string]ECReportSpec
+	previous    map[string][]string
+}
+
+// NewECReportsGenerator returns a new ECReportsGenerator,
+// the defaultSpec applies to the reportURIs not in the specs
+func NewECReportsGenerator(defaultSpec ECReportSpec, specs map[string]ECReportSpec) *ECReportsGenerator {
+	if specs == nil {
+		specs = map[string]ECReportSpec{}
+	}
+	return &ECReportsGenerator{
+		defaultSpec: defaultSpec,
+		specs:       specs,
+		previous:    map[string][]string{},
+	}
+}
+
+// Generate returns the ECReports for the Cycle in the order of the reportURIs
+func (g *ECReportsGenerator) Generate(c Cycle) []ECReport {
+	reportURIs := []string{}
+	for reportURI := range c.Tags {
+		reportURIs = append(reportURIs, reportURI)
+	}
+	for reportURI := range g.previous {
+		if _, ok := c.Tags[reportURI]; !ok {
+			reportURIs = append(reportURIs, reportURI)
+		}
+	}
+	for reportURI := range g.specs {
+		if _, ok := c.Tags[reportURI]; !ok {
+			if _, ok := g.previous[reportURI]; !ok {
+				reportURIs = append(reportURIs, reportURI)
+			}
+		}
+	}
+	sort.Strings(reportURIs)
+
+	reports := []ECReport{}
+	for _, reportURI := range reportURIs {
+		spec, ok := g.specs[reportURI]
+		if !ok {
+			spec = g.defaultSpec
+		}
+		current := c.Tags[reportURI]
+		previous := g.previous[reportURI]
+		for _, set := range spec.Sets {
+			var ids []string
+			switch set {
+			case Current:
+				ids = append([]string{}, current...)
+			case Additions:
+				ids = difference(current, previous)
+			case Deletions:
+				ids = difference(previous, current)
+			}
+			if len(ids) == 0 && !spec.ReportIfEmpty {
+				continue
+			}
+			reports = append(reports, ECReport{ReportURI: reportURI, Set: set, IDs: ids})
+		}
+	}
+
+	g.previous = map[string][]string{}
+	for reportURI, ids := range c.Tags {
+		if len(ids) != 0 {
+			g.previous[reportURI] = ids
+		}
+	}
+	return reports
+}
+
+// difference returns the ids in a but not in b, in the order of a
+func difference(a []string, b []string) []string {
+	inB := map[string]bool{}
+	for _, id := range b {
+		inB[id] = true
+	}
+	ids := []string{}
+	for _, id := range a {
+		if !inB[id] {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package ale
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseReportSetTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    []ReportSetType
+		wantErr bool
+	}{
+		{"current", "CURRENT", []ReportSetType{Current}, false},
+		{"additions and deletions", "additions, DELETIONS", []ReportSetType{Additions, Deletions}, false},
+		{"unknown", "CURRENT,ALL", nil, true},
+		{"empty", "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseReportSetTypes(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseReportSetTypes() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseReportSetTypes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestECReportsGenerator_Generate(t *testing.T) {
+	door := "http://localhost:8888/door"
+	shelf := "http://localhost:8888/shelf"
+	g := NewECReportsGenerator(
+		ECReportSpec{Sets: []ReportSetType{Current}},
+		map[string]ECReportSpec{door: {Sets: []ReportSetType{Additions, Deletions}}},
+	)
+	tests := []struct {
+		name string
+		tags map[string][]string
+		want []ECReport
+	}{
+		{
+			"first cycle",
+			map[string][]string{door: {"a", "b"}, shelf: {"x"}},
+			[]ECReport{
+				{door, Additions, []string{"a", "b"}},
+				{shelf, Current, []string{"x"}},
+			},
+		},
+		{
+			"b left and c arrived",
+			map[string][]string{door: {"c", "a"}, shelf: {"x"}},
+			[]ECReport{
+				{door, Additions, []string{"c"}},
+				{door, Deletions, []string{"b"}},
+				{shelf, Current, []string{"x"}},
+			},
+		},
+		{
+			"no change",
+			map[string][]string{door: {"a", "c"}},
+			[]ECReport{},
+		},
+		{
+			"all left",
+			map[string][]string{},
+			[]ECReport{
+				{door, Deletions, []string{"a", "c"}},
+			},
+		},
+		{
+			"nothing",
+			map[string][]string{},
+			[]ECReport{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := g.Generate(Cycle{Tags: tt.tags}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ECReportsGenerator.Generate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestECReportsGenerator_Generate_reportIfEmpty(t *testing.T) {
+	door := "http://localhost:8888/door"
+	g := NewECReportsGenerator(
+		ECReportSpec{Sets: []ReportSetType{Current}},
+		map[string]ECReportSpec{door: {Sets: []ReportSetType{Current, Additions}, ReportIfEmpty: true}},
+	)
+	want := []ECReport{
+		{door, Current, []string{}},
+		{door, Additions, []string{}},
+	}
+	if got := g.Generate(Cycle{Tags: map[string][]string{}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("ECReportsGenerator.Generate() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,352 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package ale
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iomz/gosstrak/filtering"
+	"github.com/iomz/gosstrak/tdt"
+)
+
+// ECSpec is an ALE 1.1 ECSpec loaded for the FC
+type ECSpec struct {
+	Name         string
+	BoundarySpec ECBoundarySpec
+	ReportSpecs  []ReportSpec
+}
+
+// ReportSpec is a reportSpec in the ECSpec with the reportURI to deliver to
+type ReportSpec struct {
+	ReportName      string
+	ReportURI       string
+	IncludePatterns []string
+	ExcludePatterns []string
+	URIForm         tdt.URIForm
+	ECReportSpec
+}
+
+// Subscriptions returns the include and the marked exclude patterns for each reportURI
+func (spec *ECSpec) Subscriptions() filtering.Subscriptions {
+	sub := filtering.Subscriptions{}
+	for _, rs := range spec.ReportSpecs {
+		sub[rs.ReportURI] = append(sub[rs.ReportURI], rs.IncludePatterns...)
+		for _, pat := range rs.ExcludePatterns {
+			sub[rs.ReportURI] = append(sub[rs.ReportURI], filtering.ExcludeMark+pat)
+		}
+	}
+	return sub
+}
+
+// ECReportSpecs returns the ECReportSpec for each reportURI
+func (spec *ECSpec) ECReportSpecs() map[string]ECReportSpec {
+	specs := map[string]ECReportSpec{}
+	for _, rs := range spec.ReportSpecs {
+		specs[rs.ReportURI] = rs.ECReportSpec
+	}
+	return specs
+}
+
+// URIForms returns the form of the URIs to report for each reportURI
+func (spec *ECSpec) URIForms() map[string]tdt.URIForm {
+	forms := map[string]tdt.URIForm{}
+	for _, rs := range spec.ReportSpecs {
+		forms[rs.ReportURI] = rs.URIForm
+	}
+	return forms
+}
+
+// LoadECSpecFromXMLFile takes an ECSpec XML file name and the notificationURI,
+// and returns the ECSpec named after the file
+func LoadECSpecFromXMLFile(f string, notificationURI string) (*ECSpec, error) {
+	data, err := os.ReadFile(f)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSuffix(filepath.Base(f), filepath.Ext(f))
+	spec, err := ParseECSpec(data, name, notificationURI)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f, err)
+	}
+	return spec, nil
+}
+
+// ParseECSpec parses the ALE 1.1 ECSpec XML,
+// each reportSpec reports to <notificationURI>#<reportName>,
+// the fragment is not sent over HTTP and only tells the reports apart;
+// all the errors found are joined with the path to the element
+func ParseECSpec(data []byte, name string, notificationURI string) (*ECSpec, error) {
+	if len(notificationURI) == 0 {
+		return nil, fmt.Errorf("empty notificationURI for ECSpec %s", name)
+	}
+	x := xmlECSpec{}
+	if err := xml.Unmarshal(data, &x); err != nil {
+		return nil, err
+	}
+	if x.XMLName.Local != "ECSpec" {
+		return nil, fmt.Errorf("<%s>: not an ECSpec", x.XMLName.Local)
+	}
+
+	var errs []error
+	fail := func(path string, format string, a ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, a...)))
+	}
+	unsupported := func(path string, elements []xmlElement) {
+		for _, e := range elements {
+			fail(path+"/"+e.XMLName.Local, "not supported")
+		}
+	}
+
+	spec := &ECSpec{Name: name}
+	unsupported("ECSpec", x.Unknown)
+	if x.Extension != nil {
+		unsupported("ECSpec/extension", x.Extension.Unknown)
+	}
+
+	// boundarySpec
+	b := x.BoundarySpec
+	path := "ECSpec/boundarySpec"
+	unsupported(path, b.Unknown)
+	var err error
+	if spec.BoundarySpec.Duration, err = b.Duration.duration(); err != nil {
+		fail(path+"/duration", "%v", err)
+	}
+	if spec.BoundarySpec.RepeatPeriod, err = b.RepeatPeriod.duration(); err != nil {
+		fail(path+"/repeatPeriod", "%v", err)
+	}
+	if spec.BoundarySpec.StableSetInterval, err = b.StableSetInterval.duration(); err != nil {
+		fail(path+"/stableSetInterval", "%v", err)
+	}
+	startTriggerList, stopTriggerList := b.StartTriggerList, b.StopTriggerList
+	if b.Extension != nil {
+		startTriggerList = append(startTriggerList, b.Extension.StartTriggerList...)
+		stopTriggerList = append(stopTriggerList, b.Extension.StopTriggerList...)
+	}
+	spec.BoundarySpec.StartTriggers = triggers(b.StartTrigger, startTriggerList)
+	spec.BoundarySpec.StopTriggers = triggers(b.StopTrigger, stopTriggerList)
+	for _, uri := range append(append([]string{}, spec.BoundarySpec.StartTriggers...), spec.BoundarySpec.StopTriggers...) {
+		if _, err := ParseTrigger(uri); err != nil {
+			fail(path, "%v", err)
+		}
+	}
+	if b.Extension != nil {
+		unsupported(path+"/extension", b.Extension.Unknown)
+		if b.Extension.WhenDataAvailable {
+			fail(path+"/extension/whenDataAvailable", "not supported")
+		}
+	}
+	if len(errs) == 0 {
+		if err := spec.BoundarySpec.Validate(); err != nil {
+			fail(path, "%v", err)
+		}
+	}
+
+	// reportSpecs
+	if len(x.ReportSpecs) == 0 {
+		fail("ECSpec/reportSpecs", "no reportSpec")
+	}
+	names := map[string]bool{}
+	for i, r := range x.ReportSpecs {
+		path := fmt.Sprintf("ECSpec/reportSpecs/reportSpec[%d]", i)
+		if len(r.ReportName) != 0 {
+			path = fmt.Sprintf("ECSpec/reportSpecs/reportSpec[@reportName=%q]", r.ReportName)
+		}
+		unsupported(path, r.Unknown)
+		if len(r.ReportName) == 0 {
+			fail(path, "no reportName")
+		} else if names[r.ReportName] {
+			fail(path, "duplicate reportName")
+		}
+		names[r.ReportName] = true
+		if r.ReportOnlyOnChange {
+			fail(path+"/@reportOnlyOnChange", "not supported")
+		}
+		if r.GroupSpec != nil && len(r.GroupSpec.Unknown) != 0 {
+			fail(path+"/groupSpec", "not supported")
+		}
+		if r.Extension != nil {
+			unsupported(path+"/extension", r.Extension.Unknown)
+		}
+
+		rs := ReportSpec{
+			ReportName: r.ReportName,
+			ReportURI:  notificationURI + "#" + r.ReportName,
+		}
+		rs.ReportIfEmpty = r.ReportIfEmpty
+		// a report carries either the pure identities or the tag URIs
+		if r.Output.IncludeTag && !r.Output.IncludeEPC {
+			rs.URIForm = tdt.TagURI
+		}
+		set := ReportSetType(r.ReportSet.Set)
+		switch set {
+		case Current, Additions, Deletions:
+			rs.Sets = []ReportSetType{set}
+		default:
+			fail(path+"/reportSet", "unknown set %q", r.ReportSet.Set)
+		}
+
+		// filterSpec
+		f := r.FilterSpec
+		fpath := path + "/filterSpec"
+		unsupported(fpath, f.Unknown)
+		rs.IncludePatterns = appendTrimmed(rs.IncludePatterns, f.IncludePatterns)
+		rs.ExcludePatterns = appendTrimmed(rs.ExcludePatterns, f.ExcludePatterns)
+		if f.Extension != nil {
+			unsupported(fpath+"/extension", f.Extension.Unknown)
+			for j, filter := range f.Extension.Filters {
+				path := fmt.Sprintf("%s/extension/filterList/filter[%d]", fpath, j)
+				unsupported(path, filter.Unknown)
+				if fieldname := filter.FieldSpec.FieldName; len(fieldname) != 0 && fieldname != "epc" {
+					fail(path+"/fieldspec/fieldname", "%q is not supported, only epc", fieldname)
+					continue
+				}
+				switch filter.IncludeExclude {
+				case "INCLUDE":
+					rs.IncludePatterns = appendTrimmed(rs.IncludePatterns, filter.Pats)
+				case "EXCLUDE":
+					rs.ExcludePatterns = appendTrimmed(rs.ExcludePatterns, filter.Pats)
+				default:
+					fail(path+"/includeExclude", "unknown value %q", filter.IncludeExclude)
+				}
+			}
+		}
+		for _, pat := range append(append([]string{}, rs.IncludePatterns...), rs.ExcludePatterns...) {
+			if _, err := filtering.MakeFilterStringsFromPattern(pat); err != nil {
+				fail(fpath, "invalid pattern %q: %v", pat, err)
+			}
+		}
+		if len(rs.IncludePatterns) == 0 {
+			fail(fpath, "no include pattern, reporting every tag is not supported")
+		}
+		spec.ReportSpecs = append(spec.ReportSpecs, rs)
+	}
+
+	if len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+	return spec, nil
+}
+
+// triggers returns the trigger URIs from the single element and the list
+func triggers(trigger string, list []string) []string {
+	return appendTrimmed(appendTrimmed([]string{}, []string{trigger}), list)
+}
+
+// appendTrimmed appends the non-empty values without the surrounding spaces
+func appendTrimmed(dst []string, values []string) []string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); len(v) != 0 {
+			dst = append(dst, v)
+		}
+	}
+	return dst
+}
+
+// xmlElement catches the elements not known to the FC
+type xmlElement struct {
+	XMLName xml.Name
+}
+
+// xmlECSpec is the ECSpec XML document
+type xmlECSpec struct {
+	XMLName xml.Name
+	// the FC has a single reader, the logicalReaders are ignored
+	LogicalReaders []string        `xml:"logicalReaders>logicalReader"`
+	BoundarySpec   xmlBoundarySpec `xml:"boundarySpec"`
+	ReportSpecs    []xmlReportSpec `xml:"reportSpecs>reportSpec"`
+	Extension      *struct {
+		Unknown []xmlElement `xml:",any"`
+	} `xml:"extension"`
+	Unknown []xmlElement `xml:",any"`
+}
+
+// xmlBoundarySpec is the ECBoundarySpec in the ECSpec XML
+type xmlBoundarySpec struct {
+	StartTrigger      string    `xml:"startTrigger"`
+	StartTriggerList  []string  `xml:"startTriggerList>startTrigger"`
+	RepeatPeriod      xmlECTime `xml:"repeatPeriod"`
+	StopTrigger       string    `xml:"stopTrigger"`
+	StopTriggerList   []string  `xml:"stopTriggerList>stopTrigger"`
+	Duration          xmlECTime `xml:"duration"`
+	StableSetInterval xmlECTime `xml:"stableSetInterval"`
+	Extension         *struct {
+		StartTriggerList  []string     `xml:"startTriggerList>startTrigger"`
+		StopTriggerList   []string     `xml:"stopTriggerList>stopTrigger"`
+		WhenDataAvailable bool         `xml:"whenDataAvailable"`
+		Unknown           []xmlElement `xml:",any"`
+	} `xml:"extension"`
+	Unknown []xmlElement `xml:",any"`
+}
+
+// xmlECTime is an ECTime with the unit
+type xmlECTime struct {
+	Unit  string `xml:"unit,attr"`
+	Value string `xml:",chardata"`
+}
+
+// duration returns the time.Duration of the ECTime, zero if empty
+func (t xmlECTime) duration() (time.Duration, error) {
+	v := strings.TrimSpace(t.Value)
+	if len(v) == 0 {
+		return 0, nil
+	}
+	if t.Unit != "MS" {
+		return 0, fmt.Errorf("unit %q is not supported, only MS", t.Unit)
+	}
+	ms, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || ms < 0 {
+		return 0, fmt.Errorf("invalid value %q", v)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// xmlReportSpec is the ECReportSpec in the ECSpec XML
+type xmlReportSpec struct {
+	ReportName         string `xml:"reportName,attr"`
+	ReportIfEmpty      bool   `xml:"reportIfEmpty,attr"`
+	ReportOnlyOnChange bool   `xml:"reportOnlyOnChange,attr"`
+	ReportSet          struct {
+		Set string `xml:"set,attr"`
+	} `xml:"reportSet"`
+	FilterSpec xmlFilterSpec `xml:"filterSpec"`
+	GroupSpec  *struct {
+		Unknown []xmlElement `xml:",any"`
+	} `xml:"groupSpec"`
+	// only includeEPC and includeTag choose the URIs in the reports, the rest is ignored
+	Output struct {
+		IncludeEPC bool `xml:"includeEPC,attr"`
+		IncludeTag bool `xml:"includeTag,attr"`
+	} `xml:"output"`
+	Extension *struct {
+		Unknown []xmlElement `xml:",any"`
+	} `xml:"extension"`
+	Unknown []xmlElement `xml:",any"`
+}
+
+// xmlFilterSpec is the ECFilterSpec in the ECSpec XML
+type xmlFilterSpec struct {
+	IncludePatterns []string `xml:"includePatterns>includePattern"`
+	ExcludePatterns []string `xml:"excludePatterns>excludePattern"`
+	Extension       *struct {
+		Filters []struct {
+			IncludeExclude string `xml:"includeExclude"`
+			FieldSpec      struct {
+				FieldName string `xml:"fieldname"`
+			} `xml:"fieldspec"`
+			Pats    []string     `xml:"patList>pat"`
+			Unknown []xmlElement `xml:",any"`
+		} `xml:"filterList>filter"`
+		Unknown []xmlElement `xml:",any"`
+	} `xml:"extension"`
+	Unknown []xmlElement `xml:",any"`
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package ale
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iomz/gosstrak/filtering"
+	"github.com/iomz/gosstrak/tdt"
+)
+
+func TestLoadECSpecFromXMLFile(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	f := path.Join(path.Dir(filename), "../testdata/ecspec_sample.xml")
+	notificationURI := "http://localhost:8888/fosstrak"
+
+	got, err := LoadECSpecFromXMLFile(f, notificationURI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ECSpec{
+		Name: "ecspec_sample",
+		BoundarySpec: ECBoundarySpec{
+			Duration:      9500 * time.Millisecond,
+			RepeatPeriod:  10 * time.Second,
+			StartTriggers: []string{"urn:epcglobal:ale:trigger:rtc:60000.0.Z"},
+			StopTriggers:  []string{},
+		},
+		ReportSpecs: []ReportSpec{
+			{
+				ReportName:      "sgtin",
+				ReportURI:       notificationURI + "#sgtin",
+				IncludePatterns: []string{"urn:epc:pat:sgtin-96:3.999203.7757355"},
+				ECReportSpec:    ECReportSpec{Sets: []ReportSetType{Current}, ReportIfEmpty: true},
+			},
+			{
+				ReportName:      "door",
+				ReportURI:       notificationURI + "#door",
+				IncludePatterns: []string{"urn:epc:pat:sscc-96:3.00039579721", "urn:epc:pat:grai-96:3.123456.1"},
+				ExcludePatterns: []string{"urn:epc:pat:grai-96:3.123456.1.1"},
+				ECReportSpec:    ECReportSpec{Sets: []ReportSetType{Additions}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadECSpecFromXMLFile() = %+v, want %+v", got, want)
+	}
+
+	wantSub := filtering.Subscriptions{
+		notificationURI + "#sgtin": []string{"urn:epc:pat:sgtin-96:3.999203.7757355"},
+		notificationURI + "#door":  []string{"urn:epc:pat:sscc-96:3.00039579721", "urn:epc:pat:grai-96:3.123456.1", "!urn:epc:pat:grai-96:3.123456.1.1"},
+	}
+	if sub := got.Subscriptions(); !reflect.DeepEqual(sub, wantSub) {
+		t.Errorf("ECSpec.Subscriptions() = %v, want %v", sub, wantSub)
+	}
+	if specs := got.ECReportSpecs(); specs[notificationURI+"#door"].Sets[0] != Additions {
+		t.Errorf("ECSpec.ECReportSpecs() = %v, want ADDITIONS for door", specs)
+	}
+}
+
+func TestParseECSpec_errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		xml      string
+		wantErrs []string
+	}{
+		{
+			"not an ECSpec",
+			`<ECReports/>`,
+			[]string{"<ECReports>: not an ECSpec"},
+		},
+		{
+			"no stop condition",
+			`<ECSpec><boundarySpec><repeatPeriod unit="MS">1000</repeatPeriod></boundarySpec>
+			<reportSpecs><reportSpec reportName="r"><reportSet set="CURRENT"/>
+			<filterSpec><includePatterns><includePattern>urn:epc:pat:sscc-96:3.00039579721</includePattern></includePatterns></filterSpec>
+			</reportSpec></reportSpecs></ECSpec>`,
+			[]string{"ECSpec/boundarySpec: no stop condition"},
+		},
+		{
+			"unsupported constructs",
+			`<ECSpec><boundarySpec><duration unit="SECOND">1</duration><extension><whenDataAvailable>true</whenDataAvailable></extension></boundarySpec>
+			<reportSpecs>
+			<reportSpec reportName="a" reportOnlyOnChange="true"><reportSet set="ALL"/>
+			<filterSpec><extension><filterList><filter><includeExclude>INCLUDE</includeExclude><fieldspec><fieldname>killPwd</fieldname></fieldspec></filter></filterList></extension></filterSpec>
+			<groupSpec><pattern>urn:epc:pat:sgtin-96:3.X.*.*</pattern></groupSpec>
+			</reportSpec>
+			<reportSpec reportName="a"><reportSet set="CURRENT"/>
+			<filterSpec><includePatterns><includePattern>urn:epc:pat:foo:1.2</includePattern></includePatterns></filterSpec>
+			</reportSpec>
+			</reportSpecs><cycleCount>1</cycleCount></ECSpec>`,
+			[]string{
+				"ECSpec/cycleCount: not supported",
+				`ECSpec/boundarySpec/duration: unit "SECOND" is not supported, only MS`,
+				"ECSpec/boundarySpec/extension/whenDataAvailable: not supported",
+				`ECSpec/reportSpecs/reportSpec[@reportName="a"]/@reportOnlyOnChange: not supported`,
+				`ECSpec/reportSpecs/reportSpec[@reportName="a"]/groupSpec: not supported`,
+				`ECSpec/reportSpecs/reportSpec[@reportName="a"]/reportSet: unknown set "ALL"`,
+				`ECSpec/reportSpecs/reportSpec[@reportName="a"]/filterSpec/extension/filterList/filter[0]/fieldspec/fieldname: "killPwd" is not supported, only epc`,
+				`ECSpec/reportSpecs/reportSpec[@reportName="a"]/filterSpec: no include pattern`,
+				`ECSpec/reportSpecs/reportSpec[@reportName="a"]: duplicate reportName`,
+				`ECSpec/reportSpecs/reportSpec[@reportName="a"]/filterSpec: invalid pattern "urn:epc:pat:foo:1.2"`,
+			},
+		},
+		{
+			"no reportSpec",
+			`<ECSpec><boundarySpec><duration unit="MS">1000</duration></boundarySpec></ECSpec>`,
+			[]string{"ECSpec/reportSpecs: no reportSpec"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseECSpec([]byte(tt.xml), tt.name, "http://localhost:8888/fosstrak")
+			if err == nil {
+				t.Fatalf("ParseECSpec() error = nil, want %v", tt.wantErrs)
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("ParseECSpec() error = %v, want %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseECSpec_output(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   tdt.URIForm
+	}{
+		{"no output", "", tdt.PureIdentityURI},
+		{"includeEPC", `<output includeEPC="true"/>`, tdt.PureIdentityURI},
+		{"includeTag", `<output includeTag="true"/>`, tdt.TagURI},
+		{"includeEPC and includeTag", `<output includeEPC="true" includeTag="true"/>`, tdt.PureIdentityURI},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			xml := `<ECSpec><boundarySpec><duration unit="MS">1000</duration></boundarySpec>
+			<reportSpecs><reportSpec reportName="r"><reportSet set="CURRENT"/>
+			<filterSpec><includePatterns><includePattern>urn:epc:pat:sscc-96:3.00039579721</includePattern></includePatterns></filterSpec>
+			` + tt.output + `</reportSpec></reportSpecs></ECSpec>`
+			spec, err := ParseECSpec([]byte(xml), tt.name, "http://localhost:8888/fosstrak")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := spec.URIForms()["http://localhost:8888/fosstrak#r"]; got != tt.want {
+				t.Errorf("ECSpec.URIForms() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseECSpec_noNotificationURI(t *testing.T) {
+	data, _ := os.ReadFile("../testdata/ecspec_sample.xml")
+	if _, err := ParseECSpec(data, "sample", ""); err == nil {
+		t.Errorf("ParseECSpec() error = nil, want an error without a notificationURI")
+	}
+}
@@ -0,0 +1,295 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package ale
+
+import (
+	"log"
+	"time"
+)
+
+// QueueSize is the number of the closed cycles to hold
+const QueueSize = 128
+
+// InitiationCondition tells why an event cycle started
+type InitiationCondition string
+
+// InitiationConditions
+const (
+	Requested    InitiationCondition = "REQUESTED"
+	RepeatPeriod InitiationCondition = "REPEAT_PERIOD"
+	StartTrigger InitiationCondition = "TRIGGER"
+)
+
+// TerminationCondition tells why an event cycle closed
+type TerminationCondition string
+
+// TerminationConditions
+const (
+	Duration    TerminationCondition = "DURATION"
+	StableSet   TerminationCondition = "STABLE_SET"
+	StopTrigger TerminationCondition = "TRIGGER"
+	Undefine    TerminationCondition = "UNDEFINE"
+)
+
+// Cycle is a closed event cycle
+type Cycle struct {
+	Number               int
+	Start                time.Time
+	End                  time.Time
+	InitiationCondition  InitiationCondition
+	TerminationCondition TerminationCondition
+	// Tags contains the pure identities for each reportURI in the order first seen
+	Tags map[string][]string
+}
+
+// event is a matched tag, a fired trigger, or the stop request
+// sent to the main loop in order
+type event struct {
+	pureIdentity string
+	reportURIs   []string
+	trigger      string
+	stop         bool
+}
+
+// EventCycle accumulates the tags added with their reportURIs
+// and sends a Cycle to the CycleChannel when each cycle closes
+type EventCycle struct {
+	CycleChannel  chan Cycle
+	spec          ECBoundarySpec
+	startTriggers []*Trigger
+	stopTriggers  []*Trigger
+	eventChannel  chan event
+	done          chan struct{}
+}
+
+// NewEventCycle validates the ECBoundarySpec and starts the event cycles
+func NewEventCycle(spec ECBoundarySpec) (*EventCycle, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	ec := &EventCycle{
+		CycleChannel: make(chan Cycle, QueueSize),
+		spec:         spec,
+		eventChannel: make(chan event, QueueSize),
+		done:         make(chan struct{}),
+	}
+	for _, uri := range spec.StartTriggers {
+		t, _ := ParseTrigger(uri)
+		ec.startTriggers = append(ec.startTriggers, t)
+	}
+	for _, uri := range spec.StopTriggers {
+		t, _ := ParseTrigger(uri)
+		ec.stopTriggers = append(ec.stopTriggers, t)
+	}
+
+	go ec.run()
+
+	return ec, nil
+}
+
+// Add adds the pure identity matched for the reportURIs to the current cycle,
+// it returns false if the EventCycle has stopped
+func (ec *EventCycle) Add(pureIdentity string, reportURIs []string) bool {
+	if len(reportURIs) == 0 {
+		return true
+	}
+	return ec.send(event{pureIdentity: pureIdentity, reportURIs: reportURIs})
+}
+
+// Trigger fires the trigger URI,
+// it returns false if the URI is none of the triggers or the EventCycle has stopped
+func (ec *EventCycle) Trigger(uri string) bool {
+	if !hasTrigger(ec.startTriggers, uri) && !hasTrigger(ec.stopTriggers, uri) {
+		return false
+	}
+	return ec.send(event{trigger: uri})
+}
+
+// Stop closes the current cycle with Undefine and the CycleChannel
+func (ec *EventCycle) Stop() {
+	ec.send(event{stop: true})
+}
+
+// send passes the event to the main loop unless it has stopped
+func (ec *EventCycle) send(e event) bool {
+	select {
+	case ec.eventChannel <- e:
+		return true
+	case <-ec.done:
+		return false
+	}
+}
+
+// run is the main loop of the event cycles
+func (ec *EventCycle) run() {
+	var cycle *Cycle
+	var seen map[string]map[string]bool
+	var lastStart time.Time
+	var durationTimer, stableTimer, repeatTimer, startTimer, stopTimer *time.Timer
+	repeatDue := false
+	number := 0
+
+	start := func(cond InitiationCondition) {
+		number++
+		lastStart = time.Now()
+		cycle = &Cycle{
+			Number:              number,
+			Start:               lastStart,
+			InitiationCondition: cond,
+			Tags:                map[string][]string{},
+		}
+		seen = map[string]map[string]bool{}
+		stopTimerIfAny(startTimer)
+		startTimer = nil
+		if ec.spec.Duration > 0 {
+			durationTimer = time.NewTimer(ec.spec.Duration)
+		}
+		if ec.spec.StableSetInterval > 0 {
+			stableTimer = time.NewTimer(ec.spec.StableSetInterval)
+		}
+		if ec.spec.RepeatPeriod > 0 {
+			repeatDue = false
+			stopTimerIfAny(repeatTimer)
+			repeatTimer = time.NewTimer(ec.spec.RepeatPeriod)
+		}
+		stopTimer = nextTimer(ec.stopTriggers, lastStart)
+	}
+
+	// wait decides when to start the next cycle
+	wait := func() {
+		switch {
+		case repeatDue:
+			start(RepeatPeriod)
+		case ec.spec.RepeatPeriod > 0 || len(ec.startTriggers) != 0:
+			startTimer = nextTimer(ec.startTriggers, time.Now())
+		default:
+			start(Requested)
+		}
+	}
+
+	closeCycle := func(cond TerminationCondition) {
+		cycle.End = time.Now()
+		cycle.TerminationCondition = cond
+		ec.CycleChannel <- *cycle
+		cycle = nil
+		for _, t := range []*time.Timer{durationTimer, stableTimer, stopTimer} {
+			stopTimerIfAny(t)
+		}
+		durationTimer, stableTimer, stopTimer = nil, nil, nil
+	}
+
+	if len(ec.startTriggers) != 0 {
+		startTimer = nextTimer(ec.startTriggers, time.Now())
+	} else {
+		start(Requested)
+	}
+
+	for {
+		select {
+		case e := <-ec.eventChannel:
+			switch {
+			case e.stop:
+				if cycle != nil {
+					closeCycle(Undefine)
+				}
+				for _, t := range []*time.Timer{repeatTimer, startTimer} {
+					stopTimerIfAny(t)
+				}
+				close(ec.done)
+				close(ec.CycleChannel)
+				log.Println("[EventCycle] stopped")
+				return
+			case len(e.trigger) != 0:
+				if cycle != nil && hasTrigger(ec.stopTriggers, e.trigger) {
+					closeCycle(StopTrigger)
+					wait()
+				} else if cycle == nil && hasTrigger(ec.startTriggers, e.trigger) {
+					start(StartTrigger)
+				}
+			case cycle != nil:
+				isNew := false
+				for _, reportURI := range e.reportURIs {
+					if _, ok := seen[reportURI]; !ok {
+						seen[reportURI] = map[string]bool{}
+					}
+					if seen[reportURI][e.pureIdentity] {
+						continue
+					}
+					seen[reportURI][e.pureIdentity] = true
+					cycle.Tags[reportURI] = append(cycle.Tags[reportURI], e.pureIdentity)
+					isNew = true
+				}
+				if isNew && stableTimer != nil {
+					stableTimer.Reset(ec.spec.StableSetInterval)
+				}
+			}
+		case <-timerC(durationTimer):
+			durationTimer = nil
+			closeCycle(Duration)
+			wait()
+		case <-timerC(stableTimer):
+			stableTimer = nil
+			closeCycle(StableSet)
+			wait()
+		case <-timerC(stopTimer):
+			stopTimer = nil
+			closeCycle(StopTrigger)
+			wait()
+		case <-timerC(repeatTimer):
+			repeatTimer = nil
+			if cycle != nil {
+				repeatDue = true
+				continue
+			}
+			start(RepeatPeriod)
+		case <-timerC(startTimer):
+			startTimer = nil
+			if cycle == nil {
+				start(StartTrigger)
+			}
+		}
+	}
+}
+
+// hasTrigger returns true if the uri is one of the triggers
+func hasTrigger(triggers []*Trigger, uri string) bool {
+	for _, t := range triggers {
+		if t.URI == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// nextTimer returns a timer for the earliest real-time clock trigger after the now
+// and nil if none of the triggers is a real-time clock trigger
+func nextTimer(triggers []*Trigger, now time.Time) *time.Timer {
+	var earliest time.Time
+	for _, t := range triggers {
+		if next, ok := t.Next(now); ok && (earliest.IsZero() || next.Before(earliest)) {
+			earliest = next
+		}
+	}
+	if earliest.IsZero() {
+		return nil
+	}
+	return time.NewTimer(earliest.Sub(now))
+}
+
+// timerC returns the channel of the timer, nil blocks forever
+func timerC(t *time.Timer) <-chan time.Time {
+	if t == nil {
+		return nil
+	}
+	return t.C
+}
+
+// stopTimerIfAny stops the timer if not nil
+func stopTimerIfAny(t *time.Timer) {
+	if t != nil {
+		t.Stop()
+	}
+}
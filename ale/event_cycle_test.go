@@ -0,0 +1,173 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package ale
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// matches are the reportURIs for the IDs added in the tests
+var matches = map[string][]string{
+	"a": []string{"http://localhost:8888/a"},
+	"b": []string{"http://localhost:8888/a", "http://localhost:8888/b"},
+}
+
+// addTags adds the IDs with the reportURIs in matches to the EventCycle
+func addTags(ec *EventCycle, ids ...string) {
+	for _, id := range ids {
+		ec.Add(id, matches[id])
+	}
+}
+
+func receiveCycle(t *testing.T, ec *EventCycle) Cycle {
+	t.Helper()
+	select {
+	case c := <-ec.CycleChannel:
+		return c
+	case <-time.After(time.Second):
+		t.Fatal("no cycle closed")
+	}
+	return Cycle{}
+}
+
+func TestEventCycle_duration(t *testing.T) {
+	ec, err := NewEventCycle(ECBoundarySpec{Duration: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ec.Stop()
+	addTags(ec, "a", "x", "b", "a")
+
+	c := receiveCycle(t, ec)
+	want := map[string][]string{
+		"http://localhost:8888/a": []string{"a", "b"},
+		"http://localhost:8888/b": []string{"b"},
+	}
+	if !reflect.DeepEqual(c.Tags, want) {
+		t.Errorf("Cycle.Tags = %v, want %v", c.Tags, want)
+	}
+	if c.Number != 1 || c.InitiationCondition != Requested || c.TerminationCondition != Duration {
+		t.Errorf("Cycle = %v %v %v, want 1 %v %v", c.Number, c.InitiationCondition, c.TerminationCondition, Requested, Duration)
+	}
+
+	// the next cycle starts right away without repeatPeriod
+	c = receiveCycle(t, ec)
+	if c.Number != 2 || len(c.Tags) != 0 {
+		t.Errorf("Cycle = %v %v, want 2 with no tags", c.Number, c.Tags)
+	}
+}
+
+func TestEventCycle_stableSet(t *testing.T) {
+	ec, err := NewEventCycle(ECBoundarySpec{StableSetInterval: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ec.Stop()
+	start := time.Now()
+	addTags(ec, "a")
+	time.Sleep(50 * time.Millisecond)
+	addTags(ec, "a")
+	time.Sleep(10 * time.Millisecond)
+	addTags(ec, "b")
+
+	c := receiveCycle(t, ec)
+	if c.TerminationCondition != StableSet {
+		t.Errorf("Cycle.TerminationCondition = %v, want %v", c.TerminationCondition, StableSet)
+	}
+	// only the new tag b extends the cycle
+	if elapsed := c.End.Sub(start); elapsed < 160*time.Millisecond {
+		t.Errorf("Cycle closed after %v, want >= 160ms", elapsed)
+	}
+	if got := c.Tags["http://localhost:8888/a"]; !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Cycle.Tags = %v, want [a b]", got)
+	}
+}
+
+func TestEventCycle_triggers(t *testing.T) {
+	spec := ECBoundarySpec{
+		StartTriggers: []string{"urn:example:start"},
+		StopTriggers:  []string{"urn:example:stop"},
+	}
+	ec, err := NewEventCycle(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ec.Stop()
+
+	// tags before the start trigger are ignored
+	addTags(ec, "a")
+	ec.Trigger("urn:example:stop")
+	ec.Trigger("urn:example:start")
+	addTags(ec, "b")
+	if ec.Trigger("urn:example:unknown") {
+		t.Errorf("EventCycle.Trigger() = true, want false for an unknown trigger")
+	}
+	if !ec.Trigger("urn:example:stop") {
+		t.Errorf("EventCycle.Trigger() = false, want true")
+	}
+
+	c := receiveCycle(t, ec)
+	if c.InitiationCondition != StartTrigger || c.TerminationCondition != StopTrigger {
+		t.Errorf("Cycle = %v %v, want %v %v", c.InitiationCondition, c.TerminationCondition, StartTrigger, StopTrigger)
+	}
+	want := map[string][]string{
+		"http://localhost:8888/a": []string{"b"},
+		"http://localhost:8888/b": []string{"b"},
+	}
+	if !reflect.DeepEqual(c.Tags, want) {
+		t.Errorf("Cycle.Tags = %v, want %v", c.Tags, want)
+	}
+
+	// waits for the next start trigger
+	select {
+	case c := <-ec.CycleChannel:
+		t.Errorf("unexpected Cycle %v", c)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestEventCycle_repeatPeriod(t *testing.T) {
+	spec := ECBoundarySpec{Duration: 20 * time.Millisecond, RepeatPeriod: 100 * time.Millisecond}
+	ec, err := NewEventCycle(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ec.Stop()
+
+	first := receiveCycle(t, ec)
+	second := receiveCycle(t, ec)
+	if second.InitiationCondition != RepeatPeriod {
+		t.Errorf("Cycle.InitiationCondition = %v, want %v", second.InitiationCondition, RepeatPeriod)
+	}
+	if d := second.Start.Sub(first.Start); d < 100*time.Millisecond || d > 150*time.Millisecond {
+		t.Errorf("the second cycle started %v after the first, want 100ms", d)
+	}
+}
+
+func TestEventCycle_Stop(t *testing.T) {
+	ec, err := NewEventCycle(ECBoundarySpec{Duration: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addTags(ec, "a")
+	ec.Stop()
+
+	c := receiveCycle(t, ec)
+	if c.TerminationCondition != Undefine || len(c.Tags) != 1 {
+		t.Errorf("Cycle = %v %v, want %v with a tag", c.TerminationCondition, c.Tags, Undefine)
+	}
+	if _, ok := <-ec.CycleChannel; ok {
+		t.Errorf("CycleChannel is not closed")
+	}
+}
+
+func TestNewEventCycle_invalid(t *testing.T) {
+	if _, err := NewEventCycle(ECBoundarySpec{}); err == nil {
+		t.Errorf("NewEventCycle() error = nil, want an error without a stop condition")
+	}
+}
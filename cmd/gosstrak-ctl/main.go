@@ -0,0 +1,192 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/iomz/gosstrak/filtering"
+	"github.com/moby/spdystream"
+)
+
+// Environmental variables
+var (
+	// Current Version
+	version = "0.3.0"
+
+	// app
+	app = kingpin.
+		New("gosstrak-ctl", "A command-line client for the gosstrak-fc management endpoint.")
+
+	// common flag
+	managementAddr = app.
+			Flag("managementAddr", "Psuedo ALE management endpoint").
+			Default("127.0.0.1:2784").
+			String()
+	outputJSON = app.
+			Flag("json", "Print the responses in JSON.").
+			Short('j').
+			Default("false").
+			Bool()
+	timeout = app.
+		Flag("timeout", "Timeout for a request.").
+		Default("10s").
+		Duration()
+
+	// sub command
+	cmdSub          = app.Command("sub", "Manage subscriptions.")
+	cmdSubAdd       = cmdSub.Command("add", "Add a subscription.")
+	subAddReportURI = cmdSubAdd.Arg("reportURI", "A URI to report events.").Required().String()
+	subAddPattern   = cmdSubAdd.Arg("pattern", "urn:epc:pat:<type>:<field1>.<field2>...").Required().String()
+	cmdSubRm        = cmdSub.Command("rm", "Delete a subscription.")
+	subRmReportURI  = cmdSubRm.Arg("reportURI", "A URI to report events.").Required().String()
+	subRmPattern    = cmdSubRm.Arg("pattern", "urn:epc:pat:<type>:<field1>.<field2>...").Required().String()
+	cmdSubLs        = cmdSub.Command("ls", "List subscriptions.")
+
+	// engine command
+	cmdEngine        = app.Command("engine", "Inspect engines.")
+	cmdEngineStatus  = cmdEngine.Command("status", "Show the selected engine and the states of the engines.")
+	cmdEngineDump    = cmdEngine.Command("dump", "Dump an engine.")
+	engineDumpEngine = cmdEngineDump.Arg("engine", "The name of the engine, defaults to the selected one.").String()
+
+	// ec command
+	cmdEC        = app.Command("ec", "Control event cycles.")
+	cmdECTrigger = cmdEC.Command("trigger", "Fire a start or stop trigger of the event cycles.")
+	ecTriggerURI = cmdECTrigger.Arg("trigger", "The trigger URI, e.g., urn:example:stop").Required().String()
+)
+
+// request sends the ManagementRequest to the management endpoint
+// and returns the ManagementResponse
+func request(req filtering.ManagementRequest) (*filtering.ManagementResponse, error) {
+	c, err := net.DialTimeout("tcp", *managementAddr, *timeout)
+	if err != nil {
+		return nil, err
+	}
+	c.SetDeadline(time.Now().Add(*timeout))
+
+	spdyConn, err := spdystream.NewConnection(c, false)
+	if err != nil {
+		return nil, err
+	}
+	defer spdyConn.Close()
+	go spdyConn.Serve(spdystream.NoOpStreamHandler)
+
+	stream, err := spdyConn.CreateStream(http.Header{}, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	if err = stream.WaitTimeout(*timeout); err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	if err = json.NewEncoder(stream).Encode(req); err != nil {
+		return nil, err
+	}
+	res := &filtering.ManagementResponse{}
+	if err = json.NewDecoder(stream).Decode(res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// printResponse writes the ManagementResponse in JSON or human-readable text
+func printResponse(w io.Writer, res *filtering.ManagementResponse, asJSON bool) {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(res)
+		return
+	}
+	if res.Type == filtering.Error {
+		fmt.Fprintf(w, "error: %s\n", res.Error)
+		return
+	}
+	switch res.Command {
+	case filtering.AddSubscriptionCommand, filtering.DeleteSubscriptionCommand, filtering.FireTriggerCommand:
+		fmt.Fprintln(w, "ok")
+	case filtering.ListSubscriptionsCommand:
+		for _, reportURI := range res.Subscriptions.Keys() {
+			for _, pattern := range res.Subscriptions[reportURI] {
+				fmt.Fprintf(w, "%s\t%s\n", reportURI, pattern)
+			}
+		}
+	case filtering.GetSelectedEngineCommand:
+		fmt.Fprintf(w, "selected: %s\n", res.EngineName)
+	case filtering.GetEngineStatesCommand:
+		names := []string{}
+		for name := range res.EngineStates {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(w, "%s\t%s\n", name, res.EngineStates[name])
+		}
+	case filtering.DumpEngineCommand:
+		fmt.Fprintf(w, "%s\n%s", res.EngineName, res.EngineDump)
+	}
+}
+
+func main() {
+	app.Version(version)
+	parse := kingpin.MustParse(app.Parse(os.Args[1:]))
+
+	var reqs []filtering.ManagementRequest
+	switch parse {
+	case cmdSubAdd.FullCommand():
+		reqs = append(reqs, filtering.ManagementRequest{
+			Command:   filtering.AddSubscriptionCommand,
+			ReportURI: *subAddReportURI,
+			Pattern:   *subAddPattern,
+		})
+	case cmdSubRm.FullCommand():
+		reqs = append(reqs, filtering.ManagementRequest{
+			Command:   filtering.DeleteSubscriptionCommand,
+			ReportURI: *subRmReportURI,
+			Pattern:   *subRmPattern,
+		})
+	case cmdSubLs.FullCommand():
+		reqs = append(reqs, filtering.ManagementRequest{Command: filtering.ListSubscriptionsCommand})
+	case cmdEngineStatus.FullCommand():
+		reqs = append(reqs,
+			filtering.ManagementRequest{Command: filtering.GetSelectedEngineCommand},
+			filtering.ManagementRequest{Command: filtering.GetEngineStatesCommand},
+		)
+	case cmdEngineDump.FullCommand():
+		reqs = append(reqs, filtering.ManagementRequest{
+			Command:    filtering.DumpEngineCommand,
+			EngineName: *engineDumpEngine,
+		})
+	case cmdECTrigger.FullCommand():
+		reqs = append(reqs, filtering.ManagementRequest{
+			Command: filtering.FireTriggerCommand,
+			Trigger: *ecTriggerURI,
+		})
+	}
+
+	failed := false
+	for _, req := range reqs {
+		res, err := request(req)
+		if err != nil {
+			app.Fatalf("%v", err)
+		}
+		printResponse(os.Stdout, res, *outputJSON)
+		if res.Type == filtering.Error {
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
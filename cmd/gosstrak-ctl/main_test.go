@@ -0,0 +1,73 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/iomz/gosstrak/filtering"
+)
+
+func Test_printResponse(t *testing.T) {
+	type args struct {
+		res    *filtering.ManagementResponse
+		asJSON bool
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			"ack",
+			args{&filtering.ManagementResponse{Type: filtering.Ack, Command: filtering.AddSubscriptionCommand}, false},
+			"ok\n",
+		},
+		{
+			"error",
+			args{&filtering.ManagementResponse{Type: filtering.Error, Command: filtering.DeleteSubscriptionCommand, Error: "not subscribed"}, false},
+			"error: not subscribed\n",
+		},
+		{
+			"subscriptions",
+			args{&filtering.ManagementResponse{
+				Type:    filtering.Ack,
+				Command: filtering.ListSubscriptionsCommand,
+				Subscriptions: filtering.Subscriptions{
+					"http://localhost:8888/sscc":  []string{"urn:epc:pat:sscc-96:3.00039579721"},
+					"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.999203", "urn:epc:pat:sgtin-96:3.999204"},
+				},
+			}, false},
+			"http://localhost:8888/sgtin\turn:epc:pat:sgtin-96:3.999203\n" +
+				"http://localhost:8888/sgtin\turn:epc:pat:sgtin-96:3.999204\n" +
+				"http://localhost:8888/sscc\turn:epc:pat:sscc-96:3.00039579721\n",
+		},
+		{
+			"engine states",
+			args{&filtering.ManagementResponse{
+				Type:         filtering.Ack,
+				Command:      filtering.GetEngineStatesCommand,
+				EngineStates: map[string]string{"SplayTree": "rebuilding", "List": "ready"},
+			}, false},
+			"List\tready\nSplayTree\trebuilding\n",
+		},
+		{
+			"json",
+			args{&filtering.ManagementResponse{Type: filtering.Ack, Command: filtering.GetSelectedEngineCommand, EngineName: "List"}, true},
+			"{\n  \"Type\": \"Ack\",\n  \"Command\": \"GetSelectedEngine\",\n  \"EngineName\": \"List\"\n}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			printResponse(w, tt.args.res, tt.args.asJSON)
+			if got := w.String(); got != tt.want {
+				t.Errorf("printResponse() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"log"
+
+	"github.com/iomz/gosstrak/ale"
+	"github.com/iomz/gosstrak/reporting"
+)
+
+// startEventCycle starts the event cycles with the ECBoundarySpec
+// and sends the ECReports of each closed cycle to the Reporter
+func startEventCycle(name string, spec ale.ECBoundarySpec, generator *ale.ECReportsGenerator, reporter *reporting.Reporter) (*ale.EventCycle, error) {
+	ec, err := ale.NewEventCycle(spec)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for {
+			c, ok := <-ec.CycleChannel
+			if !ok {
+				break
+			}
+			log.Printf("[EventCycle] %s cycle %v closed by %s", name, c.Number, c.TerminationCondition)
+			for _, r := range generator.Generate(c) {
+				reporter.ReportChannel <- reporting.Report{ReportURI: r.ReportURI, Time: c.End, Set: string(r.Set), IDs: r.IDs, Invalid: invalidIDs(r.IDs)}
+			}
+		}
+		log.Fatalf("event cycle %s exited in gosstrak-fc", name)
+	}()
+	return ec, nil
+}
@@ -17,8 +17,11 @@ import (
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/iomz/go-llrp"
+	"github.com/iomz/gosstrak/ale"
 	"github.com/iomz/gosstrak/filtering"
 	"github.com/iomz/gosstrak/monitoring"
+	"github.com/iomz/gosstrak/reporting"
+	"github.com/iomz/gosstrak/tdt"
 	"github.com/moby/spdystream"
 )
 
@@ -34,6 +37,16 @@ const (
 	QueueSize  = 128
 )
 
+// Validation policies for the IDs with a wrong check digit
+const (
+	// DropInvalid discards the reads
+	DropInvalid = "drop"
+	// FlagInvalid reports the IDs and lists them in the Invalid of the reports
+	FlagInvalid = "flag"
+	// PassInvalid reports the IDs as they are
+	PassInvalid = "pass"
+)
+
 // Environmental variables
 var (
 	// Current Version
@@ -54,6 +67,19 @@ var (
 			Short('f').
 			Default("ecspec.csv").
 			String()
+	ecspecXMLs = app.
+			Flag("ecspecxml", "An ALE 1.1 ECSpec XML file and the notificationURI for it, e.g., door.xml=http://localhost:8888/door (repeatable).").
+			PlaceHolder("FILE=URI").
+			StringMap()
+
+	tdtDir = app.
+		Flag("tdtDir", "A directory of GS1 TDT definition XML files for the schemes to translate and filter in addition to the bundled ones.").
+		PlaceHolder("DIR").
+		String()
+	checkDigitPolicy = app.
+				Flag("checkDigitPolicy", "What to do with the IDs with a wrong check digit, e.g., the ISO 6346 container codes in ISO 17363: drop, flag or pass.").
+				Default(FlagInvalid).
+				Enum(DropInvalid, FlagInvalid, PassInvalid)
 
 	// LLRP related values
 	llrpInitialMessageID = app.
@@ -73,6 +99,74 @@ var (
 			Default("127.0.0.1:2784").
 			String()
 
+	// event cycle related values
+	ecDuration = app.
+			Flag("ecDuration", "Close an event cycle after the duration, reports per RO_ACCESS_REPORT without any stop condition.").
+			Default("0s").
+			Duration()
+	ecRepeatPeriod = app.
+			Flag("ecRepeatPeriod", "Start the next event cycle after the period from the previous start.").
+			Default("0s").
+			Duration()
+	ecStableSetInterval = app.
+				Flag("ecStableSetInterval", "Close an event cycle when no new tag is seen for the interval.").
+				Default("0s").
+				Duration()
+	ecStartTriggers = app.
+			Flag("ecStartTrigger", "A trigger URI to start an event cycle, e.g., urn:epcglobal:ale:trigger:rtc:60000.0 (repeatable).").
+			Strings()
+	ecStopTriggers = app.
+			Flag("ecStopTrigger", "A trigger URI to close an event cycle (repeatable).").
+			Strings()
+	ecReportSet = app.
+			Flag("ecReportSet", "Comma-separated report sets for each reportURI: CURRENT, ADDITIONS, and/or DELETIONS.").
+			Default(string(ale.Current)).
+			String()
+	ecReportSetFor = app.
+			Flag("ecReportSetFor", "The report sets for a reportURI, e.g., http://localhost:8888/door=ADDITIONS,DELETIONS (repeatable).").
+			StringMap()
+	ecReportIfEmpty = app.
+			Flag("ecReportIfEmpty", "Report a set even if it is empty.").
+			Default("false").
+			Bool()
+
+	// report related values
+	reportFormat = app.
+			Flag("reportFormat", "The payload format of the reports.").
+			Default(string(reporting.JSON)).
+			Enum(reporting.PayloadFormats...)
+	reportURIForm = app.
+			Flag("reportURIForm", "The syntax of the EPCs in the reports: pure-identity, tag with the filter value, gs1-key, element-string or digital-link.").
+			Default(tdt.PureIdentityURI.String()).
+			Enum(tdt.PureIdentityURI.String(), tdt.TagURI.String(), tdt.GS1Key.String(), tdt.ElementString.String(), tdt.DigitalLinkURI.String())
+	reportURIFormFor = app.
+				Flag("reportURIFormFor", "The syntax of the EPCs for a reportURI, e.g., http://localhost:8888/door=digital-link (repeatable).").
+				StringMap()
+	reportTimeout = app.
+			Flag("reportTimeout", "Timeout for delivering a report to a reportURI.").
+			Default("5s").
+			Duration()
+	reportConcurrency = app.
+				Flag("reportConcurrency", "The number of concurrent deliveries for each reportURI without the outbox.").
+				Default("1").
+				Int()
+	reportOutbox = app.
+			Flag("reportOutbox", "Keep the reports on disk until delivered.").
+			Default("true").
+			Bool()
+	reportRetries = app.
+			Flag("reportRetries", "The number of deliveries before moving a report to the dead-letter file.").
+			Default("10").
+			Int()
+	reportBackoff = app.
+			Flag("reportBackoff", "The initial wait before retrying a failed delivery, doubled for each failure.").
+			Default("1s").
+			Duration()
+	reportMaxBackoff = app.
+				Flag("reportMaxBackoff", "The maximum wait before retrying a failed delivery.").
+				Default("5m").
+				Duration()
+
 	// stat related values
 	enableStat = app.
 			Flag("enableStat", "Enable statistical monitoring.").
@@ -106,6 +200,20 @@ var (
 	currentMessageID = uint32(*llrpInitialMessageID)
 )
 
+// invalidIDs returns the IDs with a wrong check digit if the policy flags them
+func invalidIDs(ids []string) []string {
+	if *checkDigitPolicy != FlagInvalid {
+		return nil
+	}
+	var invalid []string
+	for _, id := range ids {
+		if tdt.ValidateCheckDigit(id) != nil {
+			invalid = append(invalid, id)
+		}
+	}
+	return invalid
+}
+
 func getPackagePath() string {
 	// Determine the package dir
 	_, filename, _, ok := runtime.Caller(0)
@@ -115,7 +223,7 @@ func getPackagePath() string {
 	return path.Dir(filename)
 }
 
-func run() {
+func run(dataCacheDir string) {
 	log.Println("initializing gosstrak-fc for master mode...")
 
 	// setup StatManager
@@ -125,9 +233,29 @@ func run() {
 		sm = monitoring.NewStatManager("master", *influxAddr, *influxUser, *influxPass, *influxDB)
 	}
 
+	// load GS1 TDT definitions before the subscriptions use them
+	if len(*tdtDir) != 0 {
+		log.Printf("loading GS1 TDT definitions from %s", *tdtDir)
+		if err := tdt.RegisterEPCTagDataTranslation(*tdtDir); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	// load existing subscriptions from file
 	log.Println("loading subscriptions from file")
 	sub := filtering.LoadSubscriptionsFromCSVFile(*ecspecFile)
+	ecspecs := []*ale.ECSpec{}
+	for f, notificationURI := range *ecspecXMLs {
+		log.Printf("loading ECSpec from %s", f)
+		spec, err := ale.LoadECSpecFromXMLFile(f, notificationURI)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for reportURI, patterns := range spec.Subscriptions() {
+			sub[reportURI] = append(sub[reportURI], patterns...)
+		}
+		ecspecs = append(ecspecs, spec)
+	}
 
 	// receive the engine instance status
 	log.Println("setting up a management channel")
@@ -176,6 +304,73 @@ func run() {
 		time.Sleep(time.Second)
 	}
 
+	// deliver the reports to the reportURIs
+	log.Println("setting up a reporter")
+	var reporter *reporting.Reporter
+	if *reportOutbox {
+		var err error
+		reporter, err = reporting.NewReporterWithOutbox(
+			reporting.PayloadFormat(*reportFormat),
+			*reportTimeout,
+			path.Join(dataCacheDir, "outbox"),
+			reporting.RetryPolicy{
+				MaxAttempts:    *reportRetries,
+				InitialBackoff: *reportBackoff,
+				MaxBackoff:     *reportMaxBackoff,
+			},
+		)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		reporter = reporting.NewReporter(reporting.PayloadFormat(*reportFormat), *reportTimeout, *reportConcurrency)
+	}
+
+	// accumulate the IDs in event cycles for each ECSpec
+	ecspecCycles := map[string]*ale.EventCycle{}
+	cycles := []*ale.EventCycle{}
+	for _, spec := range ecspecs {
+		generator := ale.NewECReportsGenerator(ale.ECReportSpec{}, spec.ECReportSpecs())
+		ec, err := startEventCycle(spec.Name, spec.BoundarySpec, generator, reporter)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, rs := range spec.ReportSpecs {
+			ecspecCycles[rs.ReportURI] = ec
+		}
+		cycles = append(cycles, ec)
+	}
+
+	// accumulate the other IDs in event cycles if any stop condition is given
+	var eventCycle *ale.EventCycle
+	if *ecDuration != 0 || *ecStableSetInterval != 0 || len(*ecStopTriggers) != 0 {
+		log.Println("setting up event cycles")
+		sets, err := ale.ParseReportSetTypes(*ecReportSet)
+		if err != nil {
+			log.Fatal(err)
+		}
+		specs := map[string]ale.ECReportSpec{}
+		for reportURI, s := range *ecReportSetFor {
+			sets, err := ale.ParseReportSetTypes(s)
+			if err != nil {
+				log.Fatal(err)
+			}
+			specs[reportURI] = ale.ECReportSpec{Sets: sets, ReportIfEmpty: *ecReportIfEmpty}
+		}
+		generator := ale.NewECReportsGenerator(ale.ECReportSpec{Sets: sets, ReportIfEmpty: *ecReportIfEmpty}, specs)
+		eventCycle, err = startEventCycle("default", ale.ECBoundarySpec{
+			Duration:          *ecDuration,
+			RepeatPeriod:      *ecRepeatPeriod,
+			StableSetInterval: *ecStableSetInterval,
+			StartTriggers:     *ecStartTriggers,
+			StopTriggers:      *ecStopTriggers,
+		}, generator, reporter)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cycles = append(cycles, eventCycle)
+	}
+
 	// receive management access
 	log.Println("setting up an management interface")
 	go func() {
@@ -194,26 +389,28 @@ func run() {
 				log.Print(err)
 				continue
 			}
-			go spdyConn.Serve(spdystream.MirrorStreamHandler)
-
-			// receiver, err := t.WaitReceiveChannel()
-			// if err != nil {
-			// 	log.Print(err)
-			// 	continue
-			// }
-
-			// mm := &filtering.ManagementMessage{}
-			// err = receiver.Receive(mm)
-			// if err != nil {
-			// 	log.Print(err)
-			// 	continue
-			// }
-			// log.Print(mm)
-			// mc <- *mm
+			go spdyConn.Serve(newManagementStreamHandler(engineFactory, cycles))
 		}
 		log.Fatalln("managementListener closed in gosstrak-fc")
 	}()
 
+	// choose the form of the EPC URIs for each reportURI, the flags override the ECSpecs
+	defaultURIForm, _ := tdt.ParseURIForm(*reportURIForm)
+	uriForms := map[string]tdt.URIForm{}
+	for _, spec := range ecspecs {
+		for reportURI, form := range spec.URIForms() {
+			uriForms[reportURI] = form
+		}
+	}
+	for reportURI, name := range *reportURIFormFor {
+		form, err := tdt.ParseURIForm(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		uriForms[reportURI] = form
+	}
+	tdtCore := tdt.NewCore()
+
 	// receive incoming IDs and translate them in PureIdentity
 	log.Println("setting up an incoming ReadEvent channel")
 	var rq = make(chan []*llrp.ReadEvent)
@@ -230,16 +427,53 @@ func run() {
 				if err != nil { // no much or something went wrong
 					continue
 				}
+				if err := tdt.ValidateCheckDigit(pureIdentity); err != nil && *checkDigitPolicy == DropInvalid {
+					if *verbose {
+						log.Printf("dropping %v: %v", pureIdentity, err)
+					}
+					continue
+				}
+				// translate the ReadEvent again only for the other forms
+				uris := map[tdt.URIForm]string{tdt.PureIdentityURI: pureIdentity}
+				uriFor := func(dest string) string {
+					form, ok := uriForms[dest]
+					if !ok {
+						form = defaultURIForm
+					}
+					if _, ok := uris[form]; !ok {
+						uri, err := tdtCore.TranslateURI(re.PC, re.ID, form)
+						if err != nil {
+							uri = pureIdentity
+						}
+						uris[form] = uri
+					}
+					return uris[form]
+				}
+				rest := map[string][]string{}
 				for _, dest := range reportURIs {
-					if _, ok := reports[dest]; !ok {
-						reports[dest] = []string{}
+					uri := uriFor(dest)
+					if ec, ok := ecspecCycles[dest]; ok {
+						ec.Add(uri, []string{dest})
+					} else {
+						rest[uri] = append(rest[uri], dest)
+					}
+				}
+				if eventCycle != nil {
+					for uri, dests := range rest {
+						eventCycle.Add(uri, dests)
+					}
+					continue
+				}
+				for uri, dests := range rest {
+					for _, dest := range dests {
+						reports[dest] = append(reports[dest], uri)
 					}
-					reports[dest] = append(reports[dest], pureIdentity)
 				}
 			}
 			// do report
-			for _, dest := range reports {
-				_ = dest
+			now := time.Now()
+			for dest, ids := range reports {
+				reporter.ReportChannel <- reporting.Report{ReportURI: dest, Time: now, IDs: ids, Invalid: invalidIDs(ids)}
 			}
 		}
 		log.Fatalln("ReadEvent listener exited in gosstrak-fc")
@@ -319,6 +553,6 @@ func main() {
 
 	switch parse {
 	case cmdStart.FullCommand():
-		run()
+		run(dataCacheDir)
 	}
 }
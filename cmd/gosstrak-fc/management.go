@@ -0,0 +1,69 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/iomz/gosstrak/ale"
+	"github.com/iomz/gosstrak/filtering"
+	"github.com/moby/spdystream"
+)
+
+// newManagementStreamHandler returns a spdystream.StreamHandler which
+// reads a ManagementRequest from each stream and replies a ManagementResponse,
+// the triggers are fired in the event cycles and the rest is handled by the EngineFactory
+func newManagementStreamHandler(ef *filtering.EngineFactory, cycles []*ale.EventCycle) spdystream.StreamHandler {
+	return func(stream *spdystream.Stream) {
+		if err := stream.SendReply(http.Header{}, false); err != nil {
+			log.Print(err)
+			return
+		}
+		go func() {
+			defer stream.Close()
+			var res filtering.ManagementResponse
+			req := filtering.ManagementRequest{}
+			if err := json.NewDecoder(stream).Decode(&req); err != nil {
+				res = filtering.ManagementResponse{
+					Type:  filtering.Error,
+					Error: "malformed request: " + err.Error(),
+				}
+			} else {
+				log.Printf("[Management] %v >>> %s", stream.RemoteAddr(), req.Command)
+				if req.Command == filtering.FireTriggerCommand {
+					res = fireTrigger(cycles, req)
+				} else {
+					res = ef.HandleManagementRequest(req)
+				}
+			}
+			if err := json.NewEncoder(stream).Encode(res); err != nil {
+				log.Print(err)
+			}
+		}()
+	}
+}
+
+// fireTrigger fires the trigger in all the event cycles having it
+func fireTrigger(cycles []*ale.EventCycle, req filtering.ManagementRequest) filtering.ManagementResponse {
+	res := filtering.ManagementResponse{
+		Type:    filtering.Ack,
+		Command: req.Command,
+	}
+	fired := false
+	for _, ec := range cycles {
+		if ec.Trigger(req.Trigger) {
+			fired = true
+		}
+	}
+	if !fired {
+		res.Type = filtering.Error
+		res.Error = fmt.Sprintf("no event cycle has the trigger: %q", req.Trigger)
+	}
+	return res
+}
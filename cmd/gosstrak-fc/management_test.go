@@ -0,0 +1,112 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/iomz/gosstrak/ale"
+	"github.com/iomz/gosstrak/filtering"
+	"github.com/moby/spdystream"
+)
+
+func Test_newManagementStreamHandler(t *testing.T) {
+	ef := filtering.NewEngineFactory(filtering.Subscriptions{
+		"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.999203.7757355"},
+	}, 1, make(chan filtering.ManagementMessage))
+	ec, err := ale.NewEventCycle(ale.ECBoundarySpec{StopTriggers: []string{"urn:example:stop"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ec.Stop()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		spdyConn, err := spdystream.NewConnection(c, true)
+		if err != nil {
+			return
+		}
+		spdyConn.Serve(newManagementStreamHandler(ef, []*ale.EventCycle{ec}))
+	}()
+
+	c, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	spdyConn, err := spdystream.NewConnection(c, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go spdyConn.Serve(spdystream.NoOpStreamHandler)
+	defer spdyConn.Close()
+
+	tests := []struct {
+		name string
+		req  filtering.ManagementRequest
+		want filtering.ManagementResponseType
+	}{
+		{"add", filtering.ManagementRequest{Command: filtering.AddSubscriptionCommand, ReportURI: "http://localhost:8888/sscc", Pattern: "urn:epc:pat:sscc-96:3.00039579721"}, filtering.Ack},
+		{"add again", filtering.ManagementRequest{Command: filtering.AddSubscriptionCommand, ReportURI: "http://localhost:8888/sscc", Pattern: "urn:epc:pat:sscc-96:3.00039579721"}, filtering.Error},
+		{"list", filtering.ManagementRequest{Command: filtering.ListSubscriptionsCommand}, filtering.Ack},
+		{"fire a trigger", filtering.ManagementRequest{Command: filtering.FireTriggerCommand, Trigger: "urn:example:stop"}, filtering.Ack},
+		{"fire an unknown trigger", filtering.ManagementRequest{Command: filtering.FireTriggerCommand, Trigger: "urn:example:start"}, filtering.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := spdyConn.CreateStream(http.Header{}, nil, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = stream.Wait(); err != nil {
+				t.Fatal(err)
+			}
+			if err = json.NewEncoder(stream).Encode(tt.req); err != nil {
+				t.Fatal(err)
+			}
+			res := filtering.ManagementResponse{}
+			if err = json.NewDecoder(stream).Decode(&res); err != nil {
+				t.Fatal(err)
+			}
+			stream.Close()
+			if res.Type != tt.want || res.Command != tt.req.Command {
+				t.Errorf("newManagementStreamHandler() = %v, want %v to %v", res, tt.want, tt.req.Command)
+			}
+		})
+	}
+}
+
+func Test_fireTrigger_closesCycle(t *testing.T) {
+	ec, err := ale.NewEventCycle(ale.ECBoundarySpec{StopTriggers: []string{"urn:example:stop"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ec.Stop()
+	ec.Add("urn:epc:id:sgtin:0614141.812345.6789", []string{"http://localhost:8888/sgtin"})
+	res := fireTrigger([]*ale.EventCycle{ec}, filtering.ManagementRequest{Command: filtering.FireTriggerCommand, Trigger: "urn:example:stop"})
+	if res.Type != filtering.Ack {
+		t.Fatalf("fireTrigger() = %v, want %v", res, filtering.Ack)
+	}
+	select {
+	case c := <-ec.CycleChannel:
+		if c.TerminationCondition != ale.StopTrigger || len(c.Tags) != 1 {
+			t.Errorf("Cycle = %v %v, want %v with a tag", c.TerminationCondition, c.Tags, ale.StopTrigger)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("no cycle closed by the trigger")
+	}
+}
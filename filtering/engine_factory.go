@@ -7,6 +7,7 @@ package filtering
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"reflect"
 	"sync"
@@ -21,16 +22,18 @@ type EngineFactory struct {
 	mainChannel          chan ManagementMessage
 	generatorChannels    []chan ManagementMessage
 	currentSubscriptions Subscriptions
+	subscriptionMutex    sync.Mutex
 	productionSystem     map[string]*EngineGenerator
 	deploymentPriority   map[string]uint8
 	enginePerformance    sync.Map
 	currentEngineName    string
+	currentEngineMutex   sync.RWMutex
 	statInterval         int
 }
 
 // IsActive returns false if no engine is available
 func (ef *EngineFactory) IsActive() bool {
-	if len(ef.currentEngineName) == 0 {
+	if len(ef.currentEngine()) == 0 {
 		return false
 	}
 	return true
@@ -38,12 +41,32 @@ func (ef *EngineFactory) IsActive() bool {
 
 // Search is a wrapper for Search() with the current EngineGenerator
 func (ef *EngineFactory) Search(re llrp.ReadEvent) (string, []string, error) {
+	current := ef.currentEngine()
 	for name, eg := range ef.productionSystem {
-		if name != ef.currentEngineName && eg.FSM.Is("ready") {
+		if name != current && eg.FSM.Is("ready") {
 			_, _, _ = eg.Search(re)
 		}
 	}
-	return ef.productionSystem[ef.currentEngineName].Search(re)
+	return ef.productionSystem[current].Search(re)
+}
+
+// currentEngine returns the name of the selected engine, empty if none
+func (ef *EngineFactory) currentEngine() string {
+	ef.currentEngineMutex.RLock()
+	defer ef.currentEngineMutex.RUnlock()
+	return ef.currentEngineName
+}
+
+// selectEngine replaces the selected engine with the named one
+// and notifies the main channel of it
+func (ef *EngineFactory) selectEngine(name string) {
+	ef.currentEngineMutex.Lock()
+	ef.currentEngineName = name
+	ef.currentEngineMutex.Unlock()
+	ef.mainChannel <- ManagementMessage{
+		Type:       SelectedEngine,
+		EngineName: name,
+	}
 }
 
 // NewEngineFactory returns the pointer to a new EngineFactory instance
@@ -104,14 +127,12 @@ func (ef *EngineFactory) Run() {
 					}
 					return true
 				})
-				if ef.currentEngineName != ename && len(ename) != 0 {
-					log.Printf("[EngineFactory] %s replaces the currentEngine %s due to performance", ename, ef.currentEngineName)
-					ef.currentEngineName = ename
-				}
-				ef.mainChannel <- ManagementMessage{
-					Type:       SelectedEngine,
-					EngineName: ef.currentEngineName,
+				current := ef.currentEngine()
+				if current != ename && len(ename) != 0 {
+					log.Printf("[EngineFactory] %s replaces the currentEngine %s due to performance", ename, current)
+					current = ename
 				}
+				ef.selectEngine(current)
 			}
 		}
 	}()
@@ -137,53 +158,23 @@ func (ef *EngineFactory) Run() {
 			}
 			switch msg.Type {
 			case AddSubscription:
-				/*
-					if _, ok := ef.currentByteSubscriptions[msg.FilterString]; !ok {
-						ef.currentByteSubscriptions[msg.FilterString] = &PartialSubscription{
-							Offset:    0,
-							ReportURI: msg.ReportURI,
-						}
-						for _, eg := range ef.productionSystem {
-							err := eg.FSM.Event("update", &msg)
-							if err != nil {
-								log.Println(err)
-							}
-						}
-					}
-				*/
+				ef.addSubscription(msg.ReportURI, msg.Pattern)
 			case DeleteSubscription:
-				/*
-					if _, ok := ef.currentByteSubscriptions[msg.FilterString]; ok {
-						delete(ef.currentByteSubscriptions, msg.FilterString)
-						for _, eg := range ef.productionSystem {
-							err := eg.FSM.Event("update", &msg)
-							if err != nil {
-								log.Println(err)
-							}
-						}
-					}
-				*/
+				ef.deleteSubscription(msg.ReportURI, msg.Pattern)
 			case OnEngineGenerated:
-				log.Printf("[EngineFactory] received OnEngineGenerated from %s", msg.EngineGeneratorInstance.Engine.Name())
-				if len(ef.currentEngineName) == 0 {
+				log.Printf("[EngineFactory] received OnEngineGenerated from %s", msg.EngineGeneratorInstance.Name)
+				current := ef.currentEngine()
+				if len(current) == 0 {
 					log.Printf("[EngineFactory] set %s as an initial engine", msg.EngineGeneratorInstance.Name)
-					ef.currentEngineName = msg.EngineGeneratorInstance.Name
-					ef.mainChannel <- ManagementMessage{
-						Type:       SelectedEngine,
-						EngineName: ef.currentEngineName,
-					}
+					ef.selectEngine(msg.EngineGeneratorInstance.Name)
 					continue
 				}
-				if ef.deploymentPriority[ef.currentEngineName] < ef.deploymentPriority[msg.EngineGeneratorInstance.Name] {
-					log.Printf("[EngineFactory] %s replaces the currentEngine %s", msg.EngineGeneratorInstance.Name, ef.currentEngineName)
-					ef.currentEngineName = msg.EngineGeneratorInstance.Name
-					ef.mainChannel <- ManagementMessage{
-						Type:       SelectedEngine,
-						EngineName: ef.currentEngineName,
-					}
+				if ef.deploymentPriority[current] < ef.deploymentPriority[msg.EngineGeneratorInstance.Name] {
+					log.Printf("[EngineFactory] %s replaces the currentEngine %s", msg.EngineGeneratorInstance.Name, current)
+					ef.selectEngine(msg.EngineGeneratorInstance.Name)
 					continue
 				}
-				log.Printf("[EngineFactory] %s didn't replace the currentEngine %s", msg.EngineGeneratorInstance.Name, ef.currentEngineName)
+				log.Printf("[EngineFactory] %s didn't replace the currentEngine %s", msg.EngineGeneratorInstance.Name, current)
 			case TrafficStatus:
 				ef.mainChannel <- msg // bypass the status message from generators to main
 			case EngineStatus:
@@ -196,8 +187,98 @@ func (ef *EngineFactory) Run() {
 
 	// initialize the engines
 	log.Println("[EngineFactory] initializing engines")
+	ef.subscriptionMutex.Lock()
+	defer ef.subscriptionMutex.Unlock()
+	for _, eg := range ef.productionSystem {
+		// pass the cloned subscriptions
+		eg.FSM.Event(context.Background(), "init", ef.currentSubscriptions.Clone())
+	}
+}
+
+// HandleManagementRequest processes the ManagementRequest
+// and returns the ManagementResponse
+func (ef *EngineFactory) HandleManagementRequest(req ManagementRequest) ManagementResponse {
+	res := ManagementResponse{
+		Type:    Ack,
+		Command: req.Command,
+	}
+	var err error
+	switch req.Command {
+	case AddSubscriptionCommand:
+		err = ef.addSubscription(req.ReportURI, req.Pattern)
+	case DeleteSubscriptionCommand:
+		err = ef.deleteSubscription(req.ReportURI, req.Pattern)
+	case ListSubscriptionsCommand:
+		ef.subscriptionMutex.Lock()
+		res.Subscriptions = ef.currentSubscriptions.Clone()
+		ef.subscriptionMutex.Unlock()
+	case GetSelectedEngineCommand:
+		if !ef.IsActive() {
+			err = fmt.Errorf("no engine is available yet")
+		}
+		res.EngineName = ef.currentEngine()
+	case GetEngineStatesCommand:
+		res.EngineStates = map[string]string{}
+		for name, eg := range ef.productionSystem {
+			res.EngineStates[name] = eg.FSM.Current()
+		}
+	case DumpEngineCommand:
+		// dump the selected engine unless specified
+		res.EngineName = req.EngineName
+		if len(res.EngineName) == 0 {
+			res.EngineName = ef.currentEngine()
+		}
+		eg, ok := ef.productionSystem[res.EngineName]
+		if !ok {
+			err = fmt.Errorf("unknown engine: %q", res.EngineName)
+			break
+		}
+		res.EngineDump, err = eg.Dump()
+	default:
+		err = fmt.Errorf("unknown command: %q", req.Command)
+	}
+	if err != nil {
+		res.Type = Error
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// addSubscription adds the pattern to the reportURI and updates the engines
+func (ef *EngineFactory) addSubscription(reportURI string, pattern string) error {
+	if len(reportURI) == 0 {
+		return fmt.Errorf("empty reportURI")
+	}
+	if _, err := MakeFilterStringsFromPattern(pattern); err != nil {
+		return err
+	}
+	ef.subscriptionMutex.Lock()
+	defer ef.subscriptionMutex.Unlock()
+	if !ef.currentSubscriptions.add(reportURI, pattern) {
+		return fmt.Errorf("%s is already subscribed by %s", pattern, reportURI)
+	}
+	log.Printf("[EngineFactory] add subscription %s -> %s", pattern, reportURI)
+	ef.updateEngines()
+	return nil
+}
+
+// deleteSubscription deletes the pattern from the reportURI and updates the engines
+func (ef *EngineFactory) deleteSubscription(reportURI string, pattern string) error {
+	ef.subscriptionMutex.Lock()
+	defer ef.subscriptionMutex.Unlock()
+	if !ef.currentSubscriptions.remove(reportURI, pattern) {
+		return fmt.Errorf("%s is not subscribed by %s", pattern, reportURI)
+	}
+	log.Printf("[EngineFactory] delete subscription %s -> %s", pattern, reportURI)
+	ef.updateEngines()
+	return nil
+}
+
+// updateEngines lets all the EngineGenerators rebuild their engines
+// with the current subscriptions, subscriptionMutex must be held
+func (ef *EngineFactory) updateEngines() {
 	for _, eg := range ef.productionSystem {
 		// pass the cloned subscriptions
-		eg.FSM.Event(context.Background(), "init", ef.currentSubscriptions)
+		eg.Update(ef.currentSubscriptions.Clone())
 	}
 }
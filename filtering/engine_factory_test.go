@@ -0,0 +1,144 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package filtering
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEngineFactory_HandleManagementRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     ManagementRequest
+		want    ManagementResponseType
+		wantSub Subscriptions
+	}{
+		{
+			"add a subscription",
+			ManagementRequest{Command: AddSubscriptionCommand, ReportURI: "http://localhost:8888/sscc", Pattern: "urn:epc:pat:sscc-96:3.00039579721"},
+			Ack,
+			Subscriptions{
+				"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.999203.7757355"},
+				"http://localhost:8888/sscc":  []string{"urn:epc:pat:sscc-96:3.00039579721"},
+			},
+		},
+		{
+			"add an existing subscription",
+			ManagementRequest{Command: AddSubscriptionCommand, ReportURI: "http://localhost:8888/sgtin", Pattern: "urn:epc:pat:sgtin-96:3.999203.7757355"},
+			Error,
+			Subscriptions{"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.999203.7757355"}},
+		},
+		{
+			"add an invalid pattern",
+			ManagementRequest{Command: AddSubscriptionCommand, ReportURI: "http://localhost:8888/foo", Pattern: "urn:epc:pat:foo-96:3.999203"},
+			Error,
+			Subscriptions{"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.999203.7757355"}},
+		},
+		{
+			"delete a subscription",
+			ManagementRequest{Command: DeleteSubscriptionCommand, ReportURI: "http://localhost:8888/sgtin", Pattern: "urn:epc:pat:sgtin-96:3.999203.7757355"},
+			Ack,
+			Subscriptions{},
+		},
+		{
+			"delete an unknown subscription",
+			ManagementRequest{Command: DeleteSubscriptionCommand, ReportURI: "http://localhost:8888/sscc", Pattern: "urn:epc:pat:sscc-96:3.00039579721"},
+			Error,
+			Subscriptions{"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.999203.7757355"}},
+		},
+		{
+			"list subscriptions",
+			ManagementRequest{Command: ListSubscriptionsCommand},
+			Ack,
+			Subscriptions{"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.999203.7757355"}},
+		},
+		{
+			"get the selected engine before any deployment",
+			ManagementRequest{Command: GetSelectedEngineCommand},
+			Error,
+			Subscriptions{"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.999203.7757355"}},
+		},
+		{
+			"dump an engine before any generation",
+			ManagementRequest{Command: DumpEngineCommand, EngineName: "List"},
+			Error,
+			Subscriptions{"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.999203.7757355"}},
+		},
+		{
+			"dump an unknown engine",
+			ManagementRequest{Command: DumpEngineCommand, EngineName: "Foo"},
+			Error,
+			Subscriptions{"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.999203.7757355"}},
+		},
+		{
+			"unknown command",
+			ManagementRequest{Command: "Foo"},
+			Error,
+			Subscriptions{"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.999203.7757355"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc := make(chan ManagementMessage)
+			ef := NewEngineFactory(Subscriptions{
+				"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.999203.7757355"},
+			}, 1, mc)
+			got := ef.HandleManagementRequest(tt.req)
+			if got.Type != tt.want {
+				t.Errorf("EngineFactory.HandleManagementRequest() = %v, want %v", got, tt.want)
+			}
+			if got.Command != tt.req.Command {
+				t.Errorf("EngineFactory.HandleManagementRequest() replied to %v, want %v", got.Command, tt.req.Command)
+			}
+			if !reflect.DeepEqual(ef.currentSubscriptions, tt.wantSub) {
+				t.Errorf("EngineFactory.HandleManagementRequest() results %v, want %v", ef.currentSubscriptions, tt.wantSub)
+			}
+		})
+	}
+}
+
+func TestEngineFactory_HandleManagementRequest_GetEngineStates(t *testing.T) {
+	mc := make(chan ManagementMessage)
+	ef := NewEngineFactory(Subscriptions{}, 1, mc)
+	got := ef.HandleManagementRequest(ManagementRequest{Command: GetEngineStatesCommand})
+	if got.Type != Ack {
+		t.Fatalf("EngineFactory.HandleManagementRequest() = %v, want %v", got.Type, Ack)
+	}
+	for name := range AvailableEngines {
+		if state, ok := got.EngineStates[name]; !ok || state != "unavailable" {
+			t.Errorf("EngineFactory.HandleManagementRequest() EngineStates[%s] = %q, want %q", name, state, "unavailable")
+		}
+	}
+}
+
+func TestEngineFactory_HandleManagementRequest_selectEngine(t *testing.T) {
+	mc := make(chan ManagementMessage)
+	ef := NewEngineFactory(Subscriptions{}, 1, mc)
+	done := make(chan struct{})
+	go func() {
+		for range mc {
+		}
+		close(done)
+	}()
+	// the engine selection races with the requests unless guarded
+	go func() {
+		for i := 0; i < 100; i++ {
+			for name := range AvailableEngines {
+				ef.selectEngine(name)
+			}
+		}
+		close(mc)
+	}()
+	for i := 0; i < 100; i++ {
+		got := ef.HandleManagementRequest(ManagementRequest{Command: GetSelectedEngineCommand})
+		if _, ok := AvailableEngines[got.EngineName]; got.Type == Ack && !ok {
+			t.Errorf("EngineFactory.HandleManagementRequest() EngineName = %q, want one of the engines", got.EngineName)
+		}
+		ef.HandleManagementRequest(ManagementRequest{Command: DumpEngineCommand})
+	}
+	<-done
+}
@@ -7,8 +7,10 @@ package filtering
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"math"
+	"sync"
 	"time"
 
 	//"reflect"
@@ -29,6 +31,8 @@ type EngineGenerator struct {
 	EventCount          int64
 	MatchedCount        int64
 	statInterval        int
+	engineMutex         sync.RWMutex
+	nextSubscriptions   Subscriptions
 }
 
 // NewEngineGenerator returns the pointer to a new EngineGenerator instance
@@ -107,13 +111,38 @@ func NewEngineGenerator(name string, ec EngineConstructor, statInterval int, mc
 // Search do search in the generated engine
 func (eg *EngineGenerator) Search(re llrp.ReadEvent) (string, []string, error) {
 	defer timeTrack(time.Now(), eg.timePerEventChannel)
+	eg.engineMutex.RLock()
 	pureIdentity, reportURIs, err := eg.Engine.Search(re)
+	eg.engineMutex.RUnlock()
 	if len(reportURIs) != 0 {
 		eg.MatchedCount++
 	}
 	return pureIdentity, reportURIs, err
 }
 
+// Dump returns a string representation of the generated engine
+func (eg *EngineGenerator) Dump() (string, error) {
+	eg.engineMutex.RLock()
+	defer eg.engineMutex.RUnlock()
+	if eg.Engine == nil {
+		return "", fmt.Errorf("%s is not generated yet", eg.Name)
+	}
+	return eg.Engine.Dump(), nil
+}
+
+// Update requests a rebuild of the engine with the given subscriptions,
+// the current engine keeps serving until the rebuilt one gets deployed
+func (eg *EngineGenerator) Update(sub Subscriptions) {
+	eg.engineMutex.Lock()
+	eg.nextSubscriptions = sub
+	eg.engineMutex.Unlock()
+
+	// if the engine is not ready, enterReady() picks up the subscriptions later
+	if eg.FSM.Is("ready") {
+		eg.FSM.Event(context.Background(), "update")
+	}
+}
+
 func (eg *EngineGenerator) enterState(e *fsm.Event) {
 	log.Printf("[EngineGenerator] %s event, %s entering %s", e.Event, eg.Name, e.Dst)
 }
@@ -122,34 +151,31 @@ func (eg *EngineGenerator) enterGenerating(e *fsm.Event) {
 	go func() {
 		//log.Printf("[EngineGenerator] start generating %s engine", eg.Name)
 		sub := e.Args[0].(Subscriptions)
-		eg.Engine = AvailableEngines[eg.Name](sub)
+		engine := AvailableEngines[eg.Name](sub)
+		eg.engineMutex.Lock()
+		eg.Engine = engine
+		eg.engineMutex.Unlock()
 		eg.FSM.Event(context.Background(), "deploy")
 	}()
 }
 
 func (eg *EngineGenerator) enterRebuilding(e *fsm.Event) {
-	msg := e.Args[0].(*ManagementMessage)
-	switch msg.Type {
-	case AddSubscription:
-		/*
-			eg.Engine.AddSubscription(ByteSubscriptions{
-				msg.FilterString: &Info{
-					Offset:          0,
-					ReportURI: msg.ReportURI,
-				},
-			})
-		*/
-	case DeleteSubscription:
-		/*
-			eg.Engine.DeleteSubscription(ByteSubscriptions{
-				msg.FilterString: &Info{
-					Offset:          0,
-					ReportURI: msg.ReportURI,
-				},
-			})
-		*/
-	}
-	eg.FSM.Event(context.Background(), "deploy")
+	eg.engineMutex.Lock()
+	sub := eg.nextSubscriptions
+	eg.nextSubscriptions = nil
+	eg.engineMutex.Unlock()
+
+	go func() {
+		if sub != nil {
+			// build a new engine aside and swap it with the current one
+			// so that Search() never waits for the rebuild
+			engine := AvailableEngines[eg.Name](sub)
+			eg.engineMutex.Lock()
+			eg.Engine = engine
+			eg.engineMutex.Unlock()
+		}
+		eg.FSM.Event(context.Background(), "deploy")
+	}()
 }
 
 func (eg *EngineGenerator) enterReady(e *fsm.Event) {
@@ -158,9 +184,17 @@ func (eg *EngineGenerator) enterReady(e *fsm.Event) {
 		Type:                    OnEngineGenerated,
 		EngineGeneratorInstance: eg,
 	}
+
+	// rebuild again if subscriptions were updated in the meantime
+	eg.engineMutex.RLock()
+	hasUpdate := eg.nextSubscriptions != nil
+	eg.engineMutex.RUnlock()
+	if hasUpdate {
+		eg.FSM.Event(context.Background(), "update")
+	}
 }
 
 func (eg *EngineGenerator) enterPending(e *fsm.Event) {
 	// Wait until the engine finishes the current execution
-	eg.FSM.Event(context.Background(), "rebuild", e.Args[0].(*ManagementMessage))
+	eg.FSM.Event(context.Background(), "rebuild")
 }
@@ -0,0 +1,86 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package filtering
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iomz/go-llrp"
+)
+
+func TestEngineGenerator_Update(t *testing.T) {
+	re := llrp.ReadEvent{
+		PC: []byte{48, 0},
+		ID: []byte{48, 112, 94, 48, 167, 0, 0, 64, 0, 0, 0, 1}, // urn:epc:id:sgtin:12345678.00001.1
+	}
+	tests := []struct {
+		name           string
+		sub            Subscriptions
+		update         Subscriptions
+		wantReportURIs []string
+	}{
+		{
+			"add a subscription",
+			Subscriptions{"http://localhost:8888/sscc": []string{"urn:epc:pat:sscc-96:3.00039579721"}},
+			Subscriptions{
+				"http://localhost:8888/sscc":  []string{"urn:epc:pat:sscc-96:3.00039579721"},
+				"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.12345678"},
+			},
+			[]string{"http://localhost:8888/sgtin"},
+		},
+		{
+			"delete a subscription",
+			Subscriptions{"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.12345678"}},
+			Subscriptions{"http://localhost:8888/sscc": []string{"urn:epc:pat:sscc-96:3.00039579721"}},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		for name, constructor := range AvailableEngines {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				mc := make(chan ManagementMessage)
+				go func() {
+					for range mc {
+					}
+				}()
+				eg := NewEngineGenerator(name, constructor, 1, mc)
+				eg.FSM.Event(context.Background(), "init", tt.sub)
+				waitUntilReady(t, eg)
+
+				eg.Update(tt.update)
+				waitUntilReady(t, eg)
+
+				_, gotReportURIs, _ := eg.Search(re)
+				if len(gotReportURIs) == 0 && len(tt.wantReportURIs) == 0 {
+					return
+				}
+				if !reflect.DeepEqual(gotReportURIs, tt.wantReportURIs) {
+					t.Errorf("EngineGenerator.Search() after Update() = %v, want %v", gotReportURIs, tt.wantReportURIs)
+				}
+			})
+		}
+	}
+}
+
+// waitUntilReady blocks until the engine is deployed without pending updates
+func waitUntilReady(t *testing.T, eg *EngineGenerator) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		if eg.FSM.Is("ready") {
+			eg.engineMutex.RLock()
+			waiting := eg.nextSubscriptions != nil
+			eg.engineMutex.RUnlock()
+			if !waiting {
+				return
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("%s is not ready: %s", eg.Name, eg.FSM.Current())
+}
@@ -9,10 +9,693 @@ import (
 	"bytes"
 	"encoding/gob"
 	"fmt"
+	"math/rand"
 	"os"
+	"reflect"
+	"sort"
 	"testing"
+
+	"github.com/iomz/go-llrp"
+	"github.com/iomz/gosstrak/tdt"
 )
 
+func TestEngines_exclude(t *testing.T) {
+	sub := Subscriptions{
+		"http://localhost:8888/a": []string{
+			"urn:epc:pat:sgtin-96:3.12345678",
+			"!urn:epc:pat:sgtin-96:3.12345678.00001.1",
+		},
+		"http://localhost:8888/b": []string{
+			"urn:epc:pat:sgtin-96:3.12345678.00001",
+		},
+		// a reportURI can start with the ExcludeMark
+		"!http://localhost:8888/c": []string{
+			"urn:epc:pat:sgtin-96:3.12345678",
+		},
+	}
+	tests := []struct {
+		name           string
+		re             llrp.ReadEvent
+		wantReportURIs []string
+		wantErr        bool
+	}{
+		{
+			"excluded from a",
+			llrp.ReadEvent{ID: []byte{48, 112, 94, 48, 167, 0, 0, 64, 0, 0, 0, 1}, PC: []byte{48, 0}},
+			[]string{"!http://localhost:8888/c", "http://localhost:8888/b"},
+			false,
+		},
+		{
+			"not excluded",
+			llrp.ReadEvent{ID: []byte{48, 112, 94, 48, 167, 0, 0, 64, 0, 0, 0, 2}, PC: []byte{48, 0}},
+			[]string{"!http://localhost:8888/c", "http://localhost:8888/a", "http://localhost:8888/b"},
+			false,
+		},
+	}
+	for name, constructor := range AvailableEngines {
+		engine := constructor(sub)
+		data, err := engine.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%s.MarshalBinary() error = %v", name, err)
+		}
+		decoded := constructor(Subscriptions{})
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatalf("%s.UnmarshalBinary() error = %v", name, err)
+		}
+		for _, e := range []Engine{engine, decoded} {
+			for _, tt := range tests {
+				t.Run(name+"/"+tt.name, func(t *testing.T) {
+					_, gotReportURIs, err := e.Search(tt.re)
+					if (err != nil) != tt.wantErr {
+						t.Errorf("%s.Search() error = %v, wantErr %v", name, err, tt.wantErr)
+						return
+					}
+					sort.Strings(gotReportURIs)
+					if !reflect.DeepEqual(gotReportURIs, tt.wantReportURIs) {
+						t.Errorf("%s.Search() gotReportURIs = %v, want %v", name, gotReportURIs, tt.wantReportURIs)
+					}
+				})
+			}
+		}
+	}
+
+	// only excluded
+	for name, constructor := range AvailableEngines {
+		engine := constructor(Subscriptions{"http://localhost:8888/a": sub["http://localhost:8888/a"]})
+		if _, reportURIs, err := engine.Search(tests[0].re); err == nil {
+			t.Errorf("%s.Search() = %v, want an error for the excluded ID", name, reportURIs)
+		}
+	}
+
+	// the exclude pattern added and deleted later
+	exclude := Subscriptions{"http://localhost:8888/b": []string{"!urn:epc:pat:sgtin-96:3.12345678.00001.2"}}
+	for name, constructor := range AvailableEngines {
+		engine := constructor(Subscriptions{"http://localhost:8888/b": sub["http://localhost:8888/b"]})
+		engine.AddSubscription(exclude)
+		if _, reportURIs, err := engine.Search(tests[1].re); err == nil {
+			t.Errorf("%s.Search() = %v, want an error for the excluded ID", name, reportURIs)
+		}
+		engine.DeleteSubscription(exclude)
+		if _, reportURIs, err := engine.Search(tests[1].re); err != nil || !reflect.DeepEqual(reportURIs, []string{"http://localhost:8888/b"}) {
+			t.Errorf("%s.Search() = %v, %v, want [http://localhost:8888/b]", name, reportURIs, err)
+		}
+	}
+}
+
+func TestEngines_sharedPattern(t *testing.T) {
+	sub := Subscriptions{
+		"http://localhost:8888/a": []string{"urn:epc:pat:sgtin-96:3.12345678"},
+		"http://localhost:8888/b": []string{"urn:epc:pat:sgtin-96:3.12345678"},
+		"http://localhost:8888/c": []string{"urn:epc:pat:sgtin-96:3.12345678", "urn:epc:pat:sgtin-96:3.12345678.00001"},
+	}
+	re := llrp.ReadEvent{ID: []byte{48, 112, 94, 48, 167, 0, 0, 64, 0, 0, 0, 1}, PC: []byte{48, 0}}
+	tests := []struct {
+		name           string
+		del            Subscriptions
+		wantReportURIs []string
+		wantErr        bool
+	}{
+		{
+			"all reportURIs",
+			Subscriptions{},
+			[]string{"http://localhost:8888/a", "http://localhost:8888/b", "http://localhost:8888/c"},
+			false,
+		},
+		{
+			"delete one of the shared",
+			Subscriptions{"http://localhost:8888/a": []string{"urn:epc:pat:sgtin-96:3.12345678"}},
+			[]string{"http://localhost:8888/b", "http://localhost:8888/c"},
+			false,
+		},
+		{
+			"delete the rest",
+			Subscriptions{
+				"http://localhost:8888/b": []string{"urn:epc:pat:sgtin-96:3.12345678"},
+				"http://localhost:8888/c": sub["http://localhost:8888/c"],
+			},
+			nil,
+			true,
+		},
+	}
+	for name, constructor := range AvailableEngines {
+		engine := constructor(sub)
+		data, err := engine.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%s.MarshalBinary() error = %v", name, err)
+		}
+		decoded := constructor(Subscriptions{})
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatalf("%s.UnmarshalBinary() error = %v", name, err)
+		}
+		for _, e := range []Engine{engine, decoded} {
+			for _, tt := range tests {
+				t.Run(name+"/"+tt.name, func(t *testing.T) {
+					e.DeleteSubscription(tt.del)
+					_, gotReportURIs, err := e.Search(re)
+					if (err != nil) != tt.wantErr {
+						t.Errorf("%s.Search() error = %v, wantErr %v", name, err, tt.wantErr)
+						return
+					}
+					if tt.wantErr {
+						return
+					}
+					sort.Strings(gotReportURIs)
+					if !reflect.DeepEqual(gotReportURIs, tt.wantReportURIs) {
+						t.Errorf("%s.Search() gotReportURIs = %v, want %v", name, gotReportURIs, tt.wantReportURIs)
+					}
+				})
+			}
+		}
+
+		// add them back one by one
+		engine = constructor(Subscriptions{})
+		for _, reportURI := range sub.Keys() {
+			engine.AddSubscription(Subscriptions{reportURI: sub[reportURI]})
+		}
+		_, gotReportURIs, _ := engine.Search(re)
+		sort.Strings(gotReportURIs)
+		if !reflect.DeepEqual(gotReportURIs, tests[0].wantReportURIs) {
+			t.Errorf("%s.Search() after AddSubscription gotReportURIs = %v, want %v", name, gotReportURIs, tests[0].wantReportURIs)
+		}
+	}
+}
+
+func TestEngines_overlappingPatterns(t *testing.T) {
+	tests := []struct {
+		name           string
+		sub            Subscriptions
+		del            Subscriptions
+		uri            string
+		wantReportURIs []string
+		wantErr        bool
+	}{
+		{
+			"raw and pat",
+			Subscriptions{"http://a": []string{"urn:epc:raw:x30", "urn:epc:pat:sgtin-96:*"}},
+			Subscriptions{"http://a": []string{"urn:epc:pat:sgtin-96:*"}},
+			"urn:epc:tag:sgtin-96:1.0614141.812345.7",
+			[]string{"http://a"},
+			false,
+		},
+		{
+			"value and range",
+			Subscriptions{"http://a": []string{"urn:epc:pat:sscc-96:1.0614141", "urn:epc:pat:sscc-96:[1-3].0614141"}},
+			Subscriptions{"http://a": []string{"urn:epc:pat:sscc-96:[1-3].0614141"}},
+			"urn:epc:tag:sscc-96:1.0614141.1234567890",
+			[]string{"http://a"},
+			false,
+		},
+		{
+			"exclude",
+			Subscriptions{"http://a": []string{"urn:epc:pat:sgtin-96:*", "!urn:epc:raw:x30", "!urn:epc:pat:sgtin-96:*"}},
+			Subscriptions{"http://a": []string{"!urn:epc:pat:sgtin-96:*"}},
+			"urn:epc:tag:sgtin-96:1.0614141.812345.7",
+			nil,
+			true,
+		},
+	}
+	c := tdt.NewCore()
+	for name, constructor := range AvailableEngines {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				pc, id, err := c.Encode(tt.uri, "", "")
+				if err != nil {
+					t.Fatal(err)
+				}
+				engine := constructor(tt.sub)
+				data, err := engine.MarshalBinary()
+				if err != nil {
+					t.Fatalf("%s.MarshalBinary() error = %v", name, err)
+				}
+				decoded := constructor(Subscriptions{})
+				if err := decoded.UnmarshalBinary(data); err != nil {
+					t.Fatalf("%s.UnmarshalBinary() error = %v", name, err)
+				}
+				for _, e := range []Engine{engine, decoded} {
+					// the other pattern still subscribes the same filter
+					e.DeleteSubscription(tt.del)
+					_, gotReportURIs, err := e.Search(llrp.ReadEvent{ID: id, PC: pc})
+					if (err != nil) != tt.wantErr {
+						t.Errorf("%s.Search() error = %v, wantErr %v", name, err, tt.wantErr)
+						continue
+					}
+					if !tt.wantErr && !reflect.DeepEqual(gotReportURIs, tt.wantReportURIs) {
+						t.Errorf("%s.Search() gotReportURIs = %v, want %v", name, gotReportURIs, tt.wantReportURIs)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestEngines_schemes(t *testing.T) {
+	sgtin := Subscriptions{
+		"http://localhost:8888/serial":  []string{"urn:epc:pat:sgtin-198:3.0614141.812345.32a%2Fb"},
+		"http://localhost:8888/company": []string{"urn:epc:pat:sgtin-198:3.0614141"},
+		"http://localhost:8888/upper":   []string{"urn:epc:pat:sgtin-198:3.0614141.812345.32A%2Fb"},
+		"http://localhost:8888/sgtin96": []string{"urn:epc:pat:sgtin-96:3.0614141"},
+	}
+	sgln := Subscriptions{
+		"http://localhost:8888/extension": []string{"urn:epc:pat:sgln-96:3.0614141.12345.400"},
+		"http://localhost:8888/location":  []string{"urn:epc:pat:sgln-96:3.0614141.12345"},
+		"http://localhost:8888/other":     []string{"urn:epc:pat:sgln-96:3.0614141.12346"},
+	}
+	assets := Subscriptions{
+		"http://localhost:8888/tote":  []string{"urn:epc:pat:grai-170:3.0614141.12345.32a%2Fb"},
+		"http://localhost:8888/asset": []string{"urn:epc:pat:giai-202:3.0614141.32a%2Fb"},
+	}
+	gs1 := Subscriptions{
+		"http://localhost:8888/document": []string{"urn:epc:pat:gdti-96:3.0614141.12345"},
+		"http://localhost:8888/service":  []string{"urn:epc:pat:gsrn-96:0.0614141.1234567890"},
+		"http://localhost:8888/coupon":   []string{"urn:epc:pat:sgcn-96:3.4012345.67890.04711"},
+		"http://localhost:8888/part":     []string{"urn:epc:pat:cpi-var:3.0614141.5PQ7%2FZ43"},
+	}
+	gid := Subscriptions{
+		"http://localhost:8888/class":   []string{"urn:epc:pat:gid-96:95100000.12345"},
+		"http://localhost:8888/manager": []string{"urn:epc:pat:gid-96:95100000"},
+		"http://localhost:8888/other":   []string{"urn:epc:pat:gid-96:95100000.12346"},
+	}
+	iso := Subscriptions{
+		"http://localhost:8888/rti":       []string{"urn:epc:pat:iso17364:25B.UN.043325711"},
+		"http://localhost:8888/packaging": []string{"urn:epc:pat:iso17366:25S.OD"},
+		"http://localhost:8888/product":   []string{"urn:epc:pat:iso17367:25S.LA.ACME"},
+		"http://localhost:8888/container": []string{"urn:epc:pat:iso17363:7B.ABC"},
+	}
+	raw := Subscriptions{
+		"http://localhost:8888/unknown":      []string{"urn:epc:raw:xff"},
+		"http://localhost:8888/unprogrammed": []string{"urn:epc:raw:x00"},
+		"http://localhost:8888/sgtin":        []string{"urn:epc:raw:x30", "!urn:epc:pat:sgtin-96:3.1234567.000001"},
+	}
+	tests := []struct {
+		name             string
+		sub              Subscriptions
+		re               llrp.ReadEvent
+		wantPureIdentity string
+		wantReportURIs   []string
+	}{
+		{
+			"SGTIN-198",
+			sgtin,
+			llrp.ReadEvent{ID: []byte{54, 116, 37, 123, 247, 25, 78, 89, 178, 194, 191, 16, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, PC: []byte{104, 0}},
+			"urn:epc:id:sgtin:0614141.812345.32a%2Fb",
+			[]string{"http://localhost:8888/company", "http://localhost:8888/serial"},
+		},
+		{
+			"SGTIN-96 against SGTIN-198",
+			sgtin,
+			llrp.ReadEvent{ID: []byte{48, 116, 37, 123, 247, 25, 78, 64, 0, 0, 26, 133}, PC: []byte{48, 0}},
+			"urn:epc:id:sgtin:0614141.812345.6789",
+			[]string{"http://localhost:8888/sgtin96"},
+		},
+		{
+			"SGLN-96",
+			sgln,
+			llrp.ReadEvent{ID: []byte{50, 116, 37, 123, 244, 96, 114, 0, 0, 0, 1, 144}, PC: []byte{48, 0}},
+			"urn:epc:id:sgln:0614141.12345.400",
+			[]string{"http://localhost:8888/extension", "http://localhost:8888/location"},
+		},
+		{
+			"GRAI-170",
+			assets,
+			llrp.ReadEvent{ID: []byte{55, 116, 37, 123, 244, 12, 14, 89, 178, 194, 191, 16, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, PC: []byte{88, 0}},
+			"urn:epc:id:grai:0614141.12345.32a%2Fb",
+			[]string{"http://localhost:8888/tote"},
+		},
+		{
+			"GIAI-202",
+			assets,
+			llrp.ReadEvent{ID: []byte{56, 116, 37, 123, 245, 155, 44, 43, 241, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, PC: []byte{104, 0}},
+			"urn:epc:id:giai:0614141.32a%2Fb",
+			[]string{"http://localhost:8888/asset"},
+		},
+		{
+			"GDTI-96",
+			gs1,
+			llrp.ReadEvent{ID: []byte{44, 116, 37, 123, 244, 96, 114, 0, 0, 0, 1, 144}, PC: []byte{48, 0}},
+			"urn:epc:id:gdti:0614141.12345.400",
+			[]string{"http://localhost:8888/document"},
+		},
+		{
+			"GSRN-96",
+			gs1,
+			llrp.ReadEvent{ID: []byte{45, 20, 37, 123, 244, 73, 150, 2, 210, 0, 0, 0}, PC: []byte{48, 0}},
+			"urn:epc:id:gsrn:0614141.1234567890",
+			[]string{"http://localhost:8888/service"},
+		},
+		{
+			"SGCN-96",
+			gs1,
+			llrp.ReadEvent{ID: []byte{63, 116, 244, 228, 230, 18, 100, 0, 0, 1, 153, 7}, PC: []byte{48, 0}},
+			"urn:epc:id:sgcn:4012345.67890.04711",
+			[]string{"http://localhost:8888/coupon"},
+		},
+		{
+			"CPI-var",
+			gs1,
+			llrp.ReadEvent{ID: []byte{61, 116, 37, 123, 247, 84, 17, 222, 246, 180, 204, 0, 0, 0, 3, 3, 144, 0}, PC: []byte{72, 0}},
+			"urn:epc:id:cpi:0614141.5PQ7%2FZ43.12345",
+			[]string{"http://localhost:8888/part"},
+		},
+		{
+			"GID-96 in the TDT definitions",
+			gid,
+			llrp.ReadEvent{ID: []byte{53, 90, 177, 198, 0, 3, 3, 144, 0, 0, 1, 144}, PC: []byte{48, 0}},
+			"urn:epc:id:gid:95100000.12345.400",
+			[]string{"http://localhost:8888/class", "http://localhost:8888/manager"},
+		},
+		{
+			"ISO17364",
+			iso,
+			llrp.ReadEvent{ID: []byte{203, 80, 149, 59, 13, 51, 207, 45, 119, 199, 20, 148, 39, 12, 48, 198}, PC: []byte{65, 163}},
+			"urn:epc:id:iso17364:25BUN043325711RTI0001",
+			[]string{"http://localhost:8888/rti"},
+		},
+		{
+			"ISO17366",
+			iso,
+			llrp.ReadEvent{ID: []byte{203, 84, 207, 16, 50, 78, 197, 2, 199, 195, 12, 96}, PC: []byte{49, 165}},
+			"urn:epc:id:iso17366:25SODCIN1PKG001",
+			[]string{"http://localhost:8888/packaging"},
+		},
+		{
+			"ISO17367",
+			iso,
+			llrp.ReadEvent{ID: []byte{203, 84, 204, 4, 16, 205, 21, 51, 177, 203, 61, 53}, PC: []byte{49, 161}},
+			"urn:epc:id:iso17367:25SLAACMESN12345",
+			[]string{"http://localhost:8888/product"},
+		},
+		{
+			// the serial 123456 read as 123457 with the check digit 0, left to the validation policy
+			"ISO17363 with a wrong check digit",
+			iso,
+			llrp.ReadEvent{ID: []byte{220, 32, 66, 13, 92, 114, 207, 77, 119, 194}, PC: []byte{41, 169}},
+			"urn:epc:id:iso17363:7BABCU1234570",
+			[]string{"http://localhost:8888/container"},
+		},
+		{
+			"raw unknown header",
+			raw,
+			llrp.ReadEvent{ID: []byte{255, 1, 2, 3, 0, 0, 0, 0}, PC: []byte{16, 0}},
+			"urn:epc:raw:32.xFF010203",
+			[]string{"http://localhost:8888/unknown"},
+		},
+		{
+			"raw unprogrammed",
+			raw,
+			llrp.ReadEvent{ID: []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, PC: []byte{48, 0}},
+			"urn:epc:raw:96.x000000000000000000000000",
+			[]string{"http://localhost:8888/unprogrammed"},
+		},
+		{
+			"raw SGTIN-96",
+			raw,
+			llrp.ReadEvent{ID: []byte{48, 120, 120, 144, 0, 0, 0, 64, 0, 0, 0, 1}, PC: []byte{48, 0}},
+			"urn:epc:id:sgtin:123456.0000001.1",
+			[]string{"http://localhost:8888/sgtin"},
+		},
+	}
+	for name, constructor := range AvailableEngines {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				testEngineSearch(t, constructor(tt.sub), tt.re, tt.wantPureIdentity, tt.wantReportURIs)
+			})
+		}
+	}
+}
+
+// testEngineSearch checks the pureIdentity and the sorted reportURIs the engine finds for the read
+func testEngineSearch(t *testing.T, e Engine, re llrp.ReadEvent, wantPureIdentity string, wantReportURIs []string) {
+	t.Helper()
+	gotPureIdentity, gotReportURIs, err := e.Search(re)
+	if err != nil {
+		t.Errorf("%s.Search() error = %v", e.Name(), err)
+		return
+	}
+	if gotPureIdentity != wantPureIdentity {
+		t.Errorf("%s.Search() gotPureIdentity = %v, want %v", e.Name(), gotPureIdentity, wantPureIdentity)
+	}
+	sort.Strings(gotReportURIs)
+	if !reflect.DeepEqual(gotReportURIs, wantReportURIs) {
+		t.Errorf("%s.Search() gotReportURIs = %v, want %v", e.Name(), gotReportURIs, wantReportURIs)
+	}
+}
+
+func TestEngines_masked(t *testing.T) {
+	sub := Subscriptions{
+		"http://localhost:8888/range": []string{
+			"urn:epc:pat:sgtin-96:3.0614141.*.[1000-1999]",
+			"!urn:epc:pat:sgtin-96:3.0614141.812345.[1500-1599]",
+		},
+		"http://localhost:8888/anyFilter": []string{"urn:epc:pat:sgtin-96:*.0614141.812345"},
+		"http://localhost:8888/item":      []string{"urn:epc:pat:sgtin-96:3.0614141.812345.*"},
+	}
+	tests := []struct {
+		name           string
+		uri            string
+		wantReportURIs []string
+		wantErr        bool
+	}{
+		{"low end", "urn:epc:tag:sgtin-96:3.0614141.812345.1000", []string{"http://localhost:8888/anyFilter", "http://localhost:8888/item", "http://localhost:8888/range"}, false},
+		{"excluded", "urn:epc:tag:sgtin-96:3.0614141.812345.1550", []string{"http://localhost:8888/anyFilter", "http://localhost:8888/item"}, false},
+		{"other item at high end", "urn:epc:tag:sgtin-96:3.0614141.812346.1999", []string{"http://localhost:8888/range"}, false},
+		{"out of range", "urn:epc:tag:sgtin-96:3.0614141.812345.2000", []string{"http://localhost:8888/anyFilter", "http://localhost:8888/item"}, false},
+		{"no match", "urn:epc:tag:sgtin-96:3.0614141.812346.999", nil, true},
+	}
+	c := tdt.NewCore()
+	reads, wants := make([]llrp.ReadEvent, len(tests)), make([][]string, len(tests))
+	for i, tt := range tests {
+		pc, id, err := c.Encode(tt.uri, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		reads[i], wants[i] = llrp.ReadEvent{ID: id, PC: pc}, tt.wantReportURIs
+	}
+	for name, constructor := range AvailableEngines {
+		engine := constructor(sub)
+		data, err := engine.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%s.MarshalBinary() error = %v", name, err)
+		}
+		decoded := constructor(Subscriptions{})
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatalf("%s.UnmarshalBinary() error = %v", name, err)
+		}
+		for _, e := range []Engine{engine, decoded} {
+			for _, tt := range tests {
+				t.Run(name+"/"+tt.name, func(t *testing.T) {
+					pc, id, err := c.Encode(tt.uri, "", "")
+					if err != nil {
+						t.Fatal(err)
+					}
+					_, gotReportURIs, err := e.Search(llrp.ReadEvent{ID: id, PC: pc})
+					if (err != nil) != tt.wantErr {
+						t.Errorf("%s.Search() error = %v, wantErr %v", name, err, tt.wantErr)
+						return
+					}
+					if tt.wantErr {
+						return
+					}
+					sort.Strings(gotReportURIs)
+					if !reflect.DeepEqual(gotReportURIs, tt.wantReportURIs) {
+						t.Errorf("%s.Search() gotReportURIs = %v, want %v", name, gotReportURIs, tt.wantReportURIs)
+					}
+				})
+			}
+			testEngineRepeatedSearch(t, e, reads, wants)
+		}
+
+		// the masked filters come and go with the subscriptions
+		pc, id, _ := c.Encode("urn:epc:tag:sgtin-96:3.0614141.812346.1999", "", "")
+		engine.DeleteSubscription(Subscriptions{"http://localhost:8888/range": sub["http://localhost:8888/range"]})
+		if _, reportURIs, err := engine.Search(llrp.ReadEvent{ID: id, PC: pc}); err == nil {
+			t.Errorf("%s.Search() = %v after DeleteSubscription, want an error", name, reportURIs)
+		}
+		engine.AddSubscription(Subscriptions{"http://localhost:8888/range": sub["http://localhost:8888/range"]})
+		if _, reportURIs, err := engine.Search(llrp.ReadEvent{ID: id, PC: pc}); err != nil || !reflect.DeepEqual(reportURIs, []string{"http://localhost:8888/range"}) {
+			t.Errorf("%s.Search() = %v, %v after AddSubscription, want [http://localhost:8888/range]", name, reportURIs, err)
+		}
+	}
+}
+
+func TestEngines_filterValue(t *testing.T) {
+	patterns := map[string]string{
+		"sgtin3":     "urn:epc:pat:sgtin-96:3.0614141.812345",
+		"company":    "urn:epc:pat:sgtin-96:3.0614141",
+		"item":       "urn:epc:pat:sgtin-96:3.0614141.812346",
+		"serial":     "urn:epc:pat:sgtin-96:3.0614141.812345.6789",
+		"sgtinAny":   "urn:epc:pat:sgtin-96:*.0614141.812345",
+		"sgtinRange": "urn:epc:pat:sgtin-96:[1-2].0614141.812345",
+		"sscc0":      "urn:epc:pat:sscc-96:0.0614141",
+		"ssccAny":    "urn:epc:pat:sscc-96:*.0614141.*",
+		"sgln3":      "urn:epc:pat:sgln-96:3.0614141.12345",
+		"sglnRange":  "urn:epc:pat:sgln-96:[0-1].0614141.12345",
+	}
+	tests := []struct {
+		uri  string
+		want []string
+	}{
+		{"urn:epc:tag:sgtin-96:0.0614141.812345.6789", []string{"sgtinAny"}},
+		{"urn:epc:tag:sgtin-96:1.0614141.812345.6789", []string{"sgtinAny", "sgtinRange"}},
+		{"urn:epc:tag:sgtin-96:3.0614141.812345.6789", []string{"company", "serial", "sgtin3", "sgtinAny"}},
+		{"urn:epc:tag:sgtin-96:3.0614141.812346.1", []string{"company", "item"}},
+		{"urn:epc:tag:sgtin-96:7.0614141.812345.6789", []string{"sgtinAny"}},
+		{"urn:epc:tag:sscc-96:0.0614141.1234567890", []string{"sscc0", "ssccAny"}},
+		{"urn:epc:tag:sscc-96:3.0614141.1234567890", []string{"ssccAny"}},
+		{"urn:epc:tag:sgln-96:0.0614141.12345.400", []string{"sglnRange"}},
+		{"urn:epc:tag:sgln-96:1.0614141.12345.400", []string{"sglnRange"}},
+		{"urn:epc:tag:sgln-96:3.0614141.12345.400", []string{"sgln3"}},
+	}
+	sub := Subscriptions{}
+	for name, pattern := range patterns {
+		sub[name] = []string{pattern}
+	}
+	c := tdt.NewCore()
+	reads, wants := make([]llrp.ReadEvent, len(tests)), make([][]string, len(tests))
+	for i, tt := range tests {
+		pc, id, err := c.Encode(tt.uri, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		reads[i], wants[i] = llrp.ReadEvent{ID: id, PC: pc}, tt.want
+	}
+	for name, constructor := range AvailableEngines {
+		engine := constructor(sub)
+		data, err := engine.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%s.MarshalBinary() error = %v", name, err)
+		}
+		decoded := constructor(Subscriptions{})
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatalf("%s.UnmarshalBinary() error = %v", name, err)
+		}
+		// the longer patterns added first are nested under the shorter ones added later
+		added := constructor(Subscriptions{})
+		names := sub.Keys()
+		sort.Slice(names, func(i, j int) bool { return len(patterns[names[i]]) > len(patterns[names[j]]) })
+		for _, n := range names {
+			added.AddSubscription(Subscriptions{n: sub[n]})
+		}
+		for _, e := range []Engine{engine, decoded, added} {
+			for _, tt := range tests {
+				t.Run(name+"/"+tt.uri, func(t *testing.T) {
+					pc, id, err := c.Encode(tt.uri, "", "")
+					if err != nil {
+						t.Fatal(err)
+					}
+					_, gotReportURIs, _ := e.Search(llrp.ReadEvent{ID: id, PC: pc})
+					sort.Strings(gotReportURIs)
+					if !reflect.DeepEqual(gotReportURIs, tt.want) {
+						t.Errorf("%s.Search() gotReportURIs = %v, want %v", name, gotReportURIs, tt.want)
+					}
+				})
+			}
+			testEngineRepeatedSearch(t, e, reads, wants)
+		}
+	}
+}
+
+// testEngineRepeatedSearch searches the reads in a random order on the same engine many times
+// and checks the sorted reportURIs, as the engines like SplayTree reorganize themselves on every search
+func testEngineRepeatedSearch(t *testing.T, e Engine, reads []llrp.ReadEvent, wantReportURIs [][]string) {
+	t.Helper()
+	rng := rand.New(rand.NewSource(1))
+	for n := 0; n < 3000; n++ {
+		i := rng.Intn(len(reads))
+		_, gotReportURIs, _ := e.Search(reads[i])
+		if len(gotReportURIs) == 0 && len(wantReportURIs[i]) == 0 {
+			continue
+		}
+		sort.Strings(gotReportURIs)
+		if !reflect.DeepEqual(gotReportURIs, wantReportURIs[i]) {
+			t.Errorf("%s.Search() of the read %d after %d searches gotReportURIs = %v, want %v", e.Name(), i, n, gotReportURIs, wantReportURIs[i])
+			return
+		}
+	}
+}
+
+func TestApplyExclusions(t *testing.T) {
+	tests := []struct {
+		name           string
+		matches        []string
+		excludeMatches []string
+		want           []string
+	}{
+		{"no exclusion", []string{"a", "b"}, nil, []string{"a", "b"}},
+		{"excluded", []string{"a", "b"}, []string{"a"}, []string{"b"}},
+		{"only exclusions", nil, []string{"a"}, []string{}},
+		{"marked reportURI", []string{"!a", "a"}, []string{"a"}, []string{"!a"}},
+		{"aggregation nodes", []string{"", "a"}, nil, []string{"a"}},
+		{"duplicates", []string{"a", "b", "a"}, nil, []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := applyExclusions(tt.matches, tt.excludeMatches); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("applyExclusions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddReportURI(t *testing.T) {
+	tests := []struct {
+		name       string
+		reportURIs []string
+		reportURI  string
+		want       []string
+	}{
+		{"empty", nil, "b", []string{"b"}},
+		{"sorted", []string{"a", "c"}, "b", []string{"a", "b", "c"}},
+		{"another reference", []string{"a", "b"}, "b", []string{"a", "b", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := addReportURI(tt.reportURIs, tt.reportURI); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("addReportURI() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveReportURI(t *testing.T) {
+	tests := []struct {
+		name       string
+		reportURIs []string
+		reportURI  string
+		want       []string
+	}{
+		{"remove", []string{"a", "b", "c"}, "b", []string{"a", "c"}},
+		{"not exists", []string{"a", "c"}, "b", []string{"a", "c"}},
+		{"last one", []string{"a"}, "a", []string{}},
+		{"one reference", []string{"a", "b", "b"}, "b", []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := removeReportURI(tt.reportURIs, tt.reportURI); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("removeReportURI() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDistinctReportURIs(t *testing.T) {
+	tests := []struct {
+		name       string
+		reportURIs []string
+		want       []string
+	}{
+		{"empty", nil, []string{}},
+		{"distinct", []string{"a", "b"}, []string{"a", "b"}},
+		{"references", []string{"a", "a", "b", "b", "b"}, []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := distinctReportURIs(tt.reportURIs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("distinctReportURIs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func benchmarkEngineGenerationFromNSubs(nSubs int, constructor EngineConstructor, b *testing.B) {
 	var engine Engine
 	for i := 0; i < b.N; i++ {
@@ -209,6 +209,8 @@ func TestFilterObject_Match(t *testing.T) {
 		{"000000001111000000000000", fields{"00111100", 8, 6, []byte{252, 243}, []byte{252, 3}, 0, 2}, args{[]byte{0, 240, 0}}, true},
 		{"000000001111111100000000", fields{"0000", 4, 19, []byte{15}, []byte{15}, 2, 1}, args{[]byte{0, 255, 0}}, true},
 		{"001100000111011000011110100011011101010000000000", fields{"1100001111010001101110101", 25, 13, []byte{254, 30, 141, 215}, []byte{248, 0, 0, 3}, 1, 4}, args{[]byte{48, 118, 30, 141, 212, 0}}, true},
+		{"0000x1xx0000", fields{"0000x1xx0000", 12, 0, []byte{15, 15}, []byte{11, 15}, 0, 2}, args{[]byte{4, 0}}, true},
+		{"0000x1xx0000_short", fields{"0000x1xx0000", 12, 0, []byte{15, 15}, []byte{11, 15}, 0, 2}, args{[]byte{4}}, false},
 		//{"", fields{"", 0, []byte{}, []byte{}, 0, 1}, args{[]byte{}}, true},
 	}
 	for _, tt := range tests {
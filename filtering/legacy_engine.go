@@ -3,7 +3,11 @@ package filtering
 import (
 	"bytes"
 	"encoding/gob"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/iomz/go-llrp"
@@ -13,38 +17,43 @@ import (
 // LegacyEngine is a engine based-on text match
 type LegacyEngine struct {
 	filters Subscriptions
-	tdtCore *tdt.Core
+	// excludes keeps the exclude patterns apart from the filters
+	excludes Subscriptions
+	tdtCore  *tdt.Core
 }
 
 // AddSubscription adds a set of subscriptions if not exists yet
 func (le *LegacyEngine) AddSubscription(sub Subscriptions) {
-	for _, f := range sub.Keys() {
-		if reportURIs, ok := le.filters[f]; !ok {
-			le.filters[f] = sub[f]
-		} else {
-			for _, dest := range sub[f] {
-				if stringIndexInSlice(dest, reportURIs) < 0 {
-					le.filters[f] = append(le.filters[f], dest)
-				}
-			}
+	includes, excludes := sub.Split()
+	if le.filters == nil {
+		le.filters = Subscriptions{}
+	}
+	if le.excludes == nil {
+		le.excludes = Subscriptions{}
+	}
+	for reportURI, patterns := range includes {
+		for _, pattern := range patterns {
+			le.filters.add(reportURI, pattern)
+		}
+	}
+	for reportURI, patterns := range excludes {
+		for _, pattern := range patterns {
+			le.excludes.add(reportURI, pattern)
 		}
 	}
 }
 
 // DeleteSubscription deletes a set of subscriptions if already exist
 func (le *LegacyEngine) DeleteSubscription(sub Subscriptions) {
-	for _, f := range sub.Keys() {
-		if reportURIs, ok := le.filters[f]; !ok {
-			continue
-		} else {
-			for _, dest := range sub[f] {
-				if i := stringIndexInSlice(dest, reportURIs); i > -1 {
-					le.filters[f] = append(reportURIs[:i], reportURIs[i+1:]...)
-				}
-			}
-			if len(le.filters[f]) == 0 {
-				delete(le.filters, f)
-			}
+	includes, excludes := sub.Split()
+	for reportURI, patterns := range includes {
+		for _, pattern := range patterns {
+			le.filters.remove(reportURI, pattern)
+		}
+	}
+	for reportURI, patterns := range excludes {
+		for _, pattern := range patterns {
+			le.excludes.remove(reportURI, pattern)
 		}
 	}
 }
@@ -55,6 +64,12 @@ func (le *LegacyEngine) Dump() string {
 	for _, f := range le.filters.Keys() {
 		fmt.Fprintf(writer, "--%s %q\n", f, le.filters[f])
 	}
+	if len(le.excludes) != 0 {
+		fmt.Fprintln(writer, "excludes:")
+		for _, f := range le.excludes.Keys() {
+			fmt.Fprintf(writer, "--%s %q\n", f, le.excludes[f])
+		}
+	}
 	return writer.String()
 }
 
@@ -66,17 +81,9 @@ func (le *LegacyEngine) MarshalBinary() (_ []byte, err error) {
 	// type of Engine
 	enc.Encode("Engine:filtering.LegacyEngine")
 
-	// size of LegacyEngine
-	enc.Encode(len(le.filters.Keys()))
-	for _, f := range le.filters.Keys() {
-		// filter
-		enc.Encode(f)
-		// size of reportURIs
-		enc.Encode(len(le.filters[f]))
-		for _, reportURI := range le.filters[f] {
-			enc.Encode(reportURI)
-		}
-	}
+	// the include patterns, then the exclude patterns
+	encodeLegacyFilters(enc, le.filters)
+	encodeLegacyFilters(enc, le.excludes)
 
 	return buf.Bytes(), err
 }
@@ -88,54 +95,150 @@ func (le *LegacyEngine) Name() string {
 
 // Search returns a pureIdentity of the llrp.ReadEvent if found any subscription without err
 func (le *LegacyEngine) Search(re llrp.ReadEvent) (pureIdentity string, reportURIs []string, err error) {
-	// translate the readevent to a PureIdentity
-	pureIdentity, err = le.tdtCore.Translate(re.PC, re.ID)
+	// decode the readevent to an Identity with the fields
+	// leave the IDs with a wrong check digit to the validation policy of the caller
+	identity, err := le.tdtCore.Decode(re.PC, re.ID)
+	if errors.Is(err, tdt.ErrCheckDigit) {
+		err = nil
+	}
 	if err != nil {
 		return
 	}
+	pureIdentity = identity.PureIdentity
 
-	for reportURI, patterns := range le.filters {
+	reportURIs = applyExclusions(matchPatterns(identity, le.filters), matchPatterns(identity, le.excludes))
+	// sort the reportURIs as the other engines do
+	sort.Strings(reportURIs)
+	if len(reportURIs) == 0 {
+		return pureIdentity, reportURIs, fmt.Errorf("no match found for %v", pureIdentity)
+	}
+	return
+}
+
+// matchPatterns returns the reportURIs with any pattern matching the Identity
+func matchPatterns(identity *tdt.Identity, sub Subscriptions) (reportURIs []string) {
+	for reportURI, patterns := range sub {
 		for _, pattern := range patterns {
-			seq := strings.Split(pattern, ":")
-			if len(seq) != 5 {
+			scheme, filter, fields, ok := parsePatternIdentity(pattern)
+			if !ok {
 				continue
 			}
-			patternType := seq[3]
-			pattern := seq[4]
-
-			switch patternType {
-			case "giai-96":
-				fields := strings.Split(seq[4], ".")
-				// remove filter value in tag uri to match with the received PureIdentity
-				pattern = "giai:" + strings.Join(fields[1:], ".")
-			case "grai-96":
-				fields := strings.Split(seq[4], ".")
-				// remove filter value in tag uri to match with the received PureIdentity
-				pattern = "grai:" + strings.Join(fields[1:], ".")
-			case "sgtin-96":
-				fields := strings.Split(seq[4], ".")
-				// remove filter value in tag uri to match with the received PureIdentity
-				pattern = "sgtin:" + strings.Join(fields[1:], ".")
-			case "sscc-96":
-				fields := strings.Split(seq[4], ".")
-				// remove filter value in tag uri to match with the received PureIdentity
-				pattern = "sscc:" + strings.Join(fields[1:], ".")
-			case "iso17363":
-				pattern = patternType + ":" + strings.Replace(pattern, ".", "", -1)
-			case "iso17365":
-				pattern = patternType + ":" + strings.Replace(pattern, ".", "", -1)
+			if filter != "" && !matchField(strconv.Itoa(identity.Filter), filter) {
+				continue
 			}
-			if strings.HasPrefix(strings.TrimPrefix(pureIdentity, "urn:epc:id:"), pattern) {
+			if matchIdentityFields(identity, scheme, fields) {
 				reportURIs = append(reportURIs, reportURI)
 			}
 		}
 	}
-	if len(reportURIs) == 0 {
-		return pureIdentity, reportURIs, fmt.Errorf("no match found for %v", pureIdentity)
-	}
 	return
 }
 
+// parsePatternIdentity returns the tag URI scheme, the filter value and the pure identity fields of the pattern
+// to match with the received Identity, the filter value is empty if the pattern has none
+func parsePatternIdentity(pattern string) (string, string, []string, bool) {
+	// the raw patterns match the leading hex digits of any ID, translated in a scheme or not
+	if strings.HasPrefix(pattern, "urn:epc:raw:") {
+		if _, err := tdt.NewPrefixFilterRaw([]string{strings.TrimPrefix(pattern, "urn:epc:raw:")}); err != nil {
+			return "", "", nil, false
+		}
+		return "raw", "", []string{strings.ToUpper(strings.TrimPrefix(pattern, "urn:epc:raw:x"))}, true
+	}
+	seq := strings.Split(pattern, ":")
+	if len(seq) != 5 {
+		return "", "", nil, false
+	}
+	patternType := seq[3]
+	fields := strings.Split(seq[4], ".")
+	// the trailing * match anything left
+	for len(fields) > 1 && fields[len(fields)-1] == tdt.Wildcard {
+		fields = fields[:len(fields)-1]
+	}
+
+	switch patternType {
+	case "cpi-96", "cpi-var",
+		"gdti-96", "gdti-174",
+		"giai-96", "giai-202",
+		"grai-96", "grai-170",
+		"gsrn-96",
+		"sgcn-96",
+		"sgln-96", "sgln-195",
+		"sgtin-96", "sgtin-198",
+		"sscc-96":
+		// separate the filter value in tag uri to match with the received PureIdentity
+		return patternType, fields[0], fields[1:], true
+	case "iso17363", "iso17363h",
+		"iso17364", "iso17364h",
+		"iso17365", "iso17365h",
+		"iso17366", "iso17366h",
+		"iso17367", "iso17367h":
+		return patternType, "", []string{strings.Join(fields, "")}, true
+	}
+	// the schemes in the GS1 TDT definitions
+	filter := ""
+	if tdt.HasFilter(patternType) {
+		filter = fields[0]
+		if filter == tdt.Wildcard || strings.HasPrefix(filter, "[") {
+			// the pure identity takes no filter value, any valid one makes the same prefix
+			fields = append([]string{"0"}, fields[1:]...)
+		}
+	}
+	pi, err := tdt.MakePureIdentityPrefix(patternType, fields)
+	if err != nil {
+		return "", "", nil, false
+	}
+	pis := strings.SplitN(pi, ":", 5)
+	if len(pis) != 5 {
+		return "", "", nil, false
+	}
+	return patternType, filter, strings.Split(pis[4], "."), true
+}
+
+// matchIdentityFields checks if the Identity is of the scheme and its fields start with the fields,
+// the scheme tells the bit lengths apart, e.g., sgtin-96 from sgtin-198
+func matchIdentityFields(identity *tdt.Identity, scheme string, fields []string) bool {
+	if scheme == "raw" {
+		return strings.HasPrefix(strings.ToUpper(hex.EncodeToString(identity.ID)), fields[0])
+	}
+	if identity.Scheme != scheme {
+		return false
+	}
+	// the ISO UII is a single string with the fields concatenated
+	if identity.NSI {
+		return strings.HasPrefix(identity.Fields[0], strings.Join(fields, ""))
+	}
+	if len(identity.Fields) == 0 {
+		return false
+	}
+	if n := len(identity.Fields); len(fields) > n {
+		// the rest of the fields are joined to the last one, e.g., an IAR with '.'
+		fields = append(fields[:n-1:n-1], strings.Join(fields[n-1:], "."))
+	}
+	for i, f := range fields {
+		if !matchField(identity.Fields[i], f) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchField checks if the value matches the field of a pattern,
+// * for any value or [lo-hi] for the integers from lo to hi
+func matchField(v string, f string) bool {
+	if f == tdt.Wildcard {
+		return true
+	}
+	if strings.HasPrefix(f, "[") && strings.HasSuffix(f, "]") {
+		lo, hi, err := tdt.ParseRange(f)
+		if err != nil {
+			return false
+		}
+		n, err := strconv.ParseUint(v, 10, 64)
+		return err == nil && lo <= n && n <= hi
+	}
+	return v == f
+}
+
 // UnmarshalBinary overwrites the unmarshaller in gob decoding LegacyEngine
 func (le *LegacyEngine) UnmarshalBinary(data []byte) (err error) {
 	dec := gob.NewDecoder(bytes.NewReader(data))
@@ -146,29 +249,12 @@ func (le *LegacyEngine) UnmarshalBinary(data []byte) (err error) {
 		return fmt.Errorf("Wrong Filtering Engine: %s", typeOfEngine)
 	}
 
-	// Size of LegacyEngine
-	var legacyEngineSize int
-	if err = dec.Decode(&legacyEngineSize); err != nil {
+	// the include patterns, then the exclude patterns
+	if le.filters, err = decodeLegacyFilters(dec); err != nil {
 		return
 	}
-
-	for i := 0; i < legacyEngineSize; i++ {
-		var f string
-		// filter
-		if err = dec.Decode(&f); err != nil {
-			return
-		}
-		var reportURIsSize int
-		if err = dec.Decode(&reportURIsSize); err != nil {
-			return
-		}
-		var reportURIs []string
-		for j := 0; j < legacyEngineSize; j++ {
-			// reportURI
-			var dest string
-			err = dec.Decode(&dest)
-			reportURIs = append(reportURIs, dest)
-		}
+	if le.excludes, err = decodeLegacyFilters(dec); err != nil {
+		return
 	}
 
 	// tdt.Core
@@ -183,7 +269,7 @@ func NewLegacyEngine(sub Subscriptions) Engine {
 	le := &LegacyEngine{}
 
 	// load up the subscriptions
-	le.filters = sub.Clone()
+	le.filters, le.excludes = sub.Split()
 
 	// initialize tdt.Core
 	le.tdtCore = tdt.NewCore()
@@ -193,6 +279,54 @@ func NewLegacyEngine(sub Subscriptions) Engine {
 
 // Internal helper methods -----------------------------------------------------
 
+// encodeLegacyFilters writes the patterns of each reportURI to the gob encoder
+func encodeLegacyFilters(enc *gob.Encoder, sub Subscriptions) {
+	// size of the Subscriptions
+	enc.Encode(len(sub.Keys()))
+	for _, f := range sub.Keys() {
+		// filter
+		enc.Encode(f)
+		// size of reportURIs
+		enc.Encode(len(sub[f]))
+		for _, reportURI := range sub[f] {
+			enc.Encode(reportURI)
+		}
+	}
+}
+
+// decodeLegacyFilters reads the patterns written by encodeLegacyFilters from the gob decoder
+func decodeLegacyFilters(dec *gob.Decoder) (sub Subscriptions, err error) {
+	// size of the Subscriptions
+	var size int
+	if err = dec.Decode(&size); err != nil {
+		return
+	}
+
+	sub = Subscriptions{}
+	for i := 0; i < size; i++ {
+		var f string
+		// filter
+		if err = dec.Decode(&f); err != nil {
+			return
+		}
+		var reportURIsSize int
+		if err = dec.Decode(&reportURIsSize); err != nil {
+			return
+		}
+		var reportURIs []string
+		for j := 0; j < reportURIsSize; j++ {
+			// reportURI
+			var dest string
+			if err = dec.Decode(&dest); err != nil {
+				return
+			}
+			reportURIs = append(reportURIs, dest)
+		}
+		sub[f] = reportURIs
+	}
+	return
+}
+
 // check if string is in a slice
 func stringIndexInSlice(a string, list []string) int {
 	for i, b := range list {
@@ -165,13 +165,70 @@ func TestLegacyEngine_Search(t *testing.T) {
 		wantReportURIs   []string
 		wantErr          bool
 	}{
-	// TODO: Add test cases.
+		{
+			"SGTIN-96_company_prefix",
+			fields{Subscriptions{
+				"http://localhost:8080/a": []string{"urn:epc:pat:sgtin-96:3.1234567"},
+				"http://localhost:8080/b": []string{"urn:epc:pat:sgtin-96:3.123456"},
+				"http://localhost:8080/c": []string{"urn:epc:pat:sscc-96:3.1234567"},
+			}, tdt.NewCore()},
+			args{llrp.ReadEvent{PC: []byte{48, 0}, ID: []byte{48, 116, 75, 90, 28, 0, 0, 64, 0, 0, 0, 1}}},
+			"urn:epc:id:sgtin:1234567.000001.1",
+			[]string{"http://localhost:8080/a"},
+			false,
+		},
+		{
+			"SGTIN-96_item_reference",
+			fields{Subscriptions{
+				"http://localhost:8080/a": []string{"urn:epc:pat:sgtin-96:3.1234567.000001", "!urn:epc:pat:sgtin-96:3.1234567.000001.2"},
+			}, tdt.NewCore()},
+			args{llrp.ReadEvent{PC: []byte{48, 0}, ID: []byte{48, 116, 75, 90, 28, 0, 0, 64, 0, 0, 0, 1}}},
+			"urn:epc:id:sgtin:1234567.000001.1",
+			[]string{"http://localhost:8080/a"},
+			false,
+		},
+		{
+			"ISO17363",
+			fields{Subscriptions{
+				"http://localhost:8080/a": []string{"urn:epc:pat:iso17363:7B.ABC.U"},
+				"http://localhost:8080/b": []string{"urn:epc:pat:iso17365:25S.UN.ABC"},
+			}, tdt.NewCore()},
+			args{llrp.ReadEvent{PC: []byte{41, 169}, ID: []byte{220, 32, 66, 13, 92, 114, 207, 77, 118, 194}}},
+			"urn:epc:id:iso17363:7BABCU1234560",
+			[]string{"http://localhost:8080/a"},
+			false,
+		},
+		{
+			"SGTIN-96_excluded",
+			fields{Subscriptions{
+				"http://localhost:8080/a": []string{"urn:epc:pat:sgtin-96:3.1234567", "!urn:epc:pat:sgtin-96:3.1234567.000001.1"},
+			}, tdt.NewCore()},
+			args{llrp.ReadEvent{PC: []byte{48, 0}, ID: []byte{48, 116, 75, 90, 28, 0, 0, 64, 0, 0, 0, 1}}},
+			"urn:epc:id:sgtin:1234567.000001.1",
+			[]string{},
+			true,
+		},
+		{
+			"SGTIN-96_sorted",
+			fields{Subscriptions{
+				"http://localhost:8080/c": []string{"urn:epc:pat:sgtin-96:3.1234567"},
+				"http://localhost:8080/a": []string{"urn:epc:pat:sgtin-96:3.1234567.000001"},
+				"http://localhost:8080/b": []string{"urn:epc:pat:sgtin-96:3.1234567.000001.1"},
+				"http://localhost:8080/d": []string{"urn:epc:pat:sgtin-96:*.1234567"},
+			}, tdt.NewCore()},
+			args{llrp.ReadEvent{PC: []byte{48, 0}, ID: []byte{48, 116, 75, 90, 28, 0, 0, 64, 0, 0, 0, 1}}},
+			"urn:epc:id:sgtin:1234567.000001.1",
+			[]string{"http://localhost:8080/a", "http://localhost:8080/b", "http://localhost:8080/c", "http://localhost:8080/d"},
+			false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			filters, excludes := tt.fields.filters.Split()
 			le := &LegacyEngine{
-				filters: tt.fields.filters,
-				tdtCore: tt.fields.tdtCore,
+				filters:  filters,
+				excludes: excludes,
+				tdtCore:  tt.fields.tdtCore,
 			}
 			gotPureIdentity, gotReportURIs, err := le.Search(tt.args.re)
 			if (err != nil) != tt.wantErr {
@@ -237,6 +294,27 @@ func TestNewLegacyEngine(t *testing.T) {
 	}
 }
 
+func Test_matchIdentityFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity *tdt.Identity
+		scheme   string
+		fields   []string
+		want     bool
+	}{
+		{"match", &tdt.Identity{Scheme: "sgtin-96", Fields: []string{"1234567", "000001", "1"}}, "sgtin-96", []string{"1234567", "000001"}, true},
+		{"other scheme", &tdt.Identity{Scheme: "sgtin-198", Fields: []string{"1234567", "000001", "1"}}, "sgtin-96", []string{"1234567"}, false},
+		{"no fields", &tdt.Identity{Scheme: "sgtin-96"}, "sgtin-96", []string{"1234567", "000001"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchIdentityFields(tt.identity, tt.scheme, tt.fields); got != tt.want {
+				t.Errorf("matchIdentityFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_stringIndexInSlice(t *testing.T) {
 	type args struct {
 		a    string
@@ -9,7 +9,9 @@ import (
 	"bytes"
 	"encoding/gob"
 	"fmt"
+	"io"
 	"reflect"
+	"strings"
 
 	"github.com/iomz/go-llrp"
 	"github.com/iomz/gosstrak/tdt"
@@ -18,7 +20,9 @@ import (
 // List is a slice of pointers to ExactMatch
 type List struct {
 	filters ListFilters
-	tdtCore *tdt.Core
+	// excludes keeps the filters of the exclude patterns apart
+	excludes ListFilters
+	tdtCore  *tdt.Core
 }
 
 // ListFilters contains pointers to ExactMatch
@@ -26,38 +30,24 @@ type ListFilters []*ExactMatch
 
 // ExactMatch is a raw filter directly taken from ByteSubscriptions
 type ExactMatch struct {
-	filter    *FilterObject
-	reportURI string
+	filter     *FilterObject
+	reportURIs []string
 }
 
-// AddSubscription adds a set of subscriptions if not exists yet
+// AddSubscription adds a set of subscriptions,
+// each pattern holds a reference to the reportURI on the filters it compiles to
 func (list *List) AddSubscription(sub Subscriptions) {
-	bsub := sub.ToByteSubscriptions()
-	// store ExactMatch in sorted order from sub
-	for _, fs := range bsub.Keys() {
-		em := &ExactMatch{
-			filter:    NewFilter(fs, bsub[fs].Offset),
-			reportURI: bsub[fs].ReportURI,
-		}
-		if list.filters.IndexOf(em) < 0 {
-			list.filters = append(list.filters, em)
-		}
-	}
+	includes, excludes := sub.Split()
+	list.filters = list.filters.addSubscriptions(includes)
+	list.excludes = list.excludes.addSubscriptions(excludes)
 }
 
-// DeleteSubscription deletes a set of subscriptions if already exist
+// DeleteSubscription deletes a set of subscriptions if already exist,
+// a reportURI remains on a filter until none of its patterns refers to it
 func (list *List) DeleteSubscription(sub Subscriptions) {
-	bsub := sub.ToByteSubscriptions()
-	// store ExactMatch in sorted order from sub
-	for _, fs := range bsub.Keys() {
-		em := &ExactMatch{
-			filter:    NewFilter(fs, bsub[fs].Offset),
-			reportURI: bsub[fs].ReportURI,
-		}
-		if i := list.filters.IndexOf(em); i > -1 {
-			list.filters = append(list.filters[:i], list.filters[i+1:]...)
-		}
-	}
+	includes, excludes := sub.Split()
+	list.filters = list.filters.deleteSubscriptions(includes)
+	list.excludes = list.excludes.deleteSubscriptions(excludes)
 }
 
 // IndexOf check the index of ExactMatch in the List
@@ -71,11 +61,24 @@ func (lf ListFilters) IndexOf(em *ExactMatch) int {
 	return -1
 }
 
+// indexOfFilter check the index of ExactMatch with the filter in the List
+// returns -1 if not exist
+func (lf ListFilters) indexOfFilter(filter *FilterObject) int {
+	for i, a := range lf {
+		if reflect.DeepEqual(a.filter, filter) {
+			return i
+		}
+	}
+	return -1
+}
+
 // Dump returs a string representation of the PatriciaTrie
 func (list *List) Dump() string {
 	writer := &bytes.Buffer{}
-	for _, em := range list.filters {
-		fmt.Fprintf(writer, "--%s %s\n", em.filter.ToString(), em.reportURI)
+	list.filters.print(writer)
+	if len(list.excludes) != 0 {
+		fmt.Fprintln(writer, "excludes:")
+		list.excludes.print(writer)
 	}
 	return writer.String()
 }
@@ -88,15 +91,14 @@ func (list *List) MarshalBinary() (_ []byte, err error) {
 	// Type of Engine
 	enc.Encode("Engine:filtering.List")
 
-	// Size of List
-	enc.Encode(len(list.filters))
-	for _, em := range list.filters {
-		// Notify
-		enc.Encode(em.reportURI)
-		// Filter
-		err = enc.Encode(em.filter)
+	// ListFilters
+	if err = list.filters.encode(enc); err != nil {
+		return
 	}
 
+	// ListFilters of the exclude patterns
+	err = list.excludes.encode(enc)
+
 	return buf.Bytes(), err
 }
 
@@ -107,11 +109,7 @@ func (list *List) Name() string {
 
 // Search returns a pureIdentity of the llrp.ReadEvent if found any subscription without err
 func (list *List) Search(re llrp.ReadEvent) (pureIdentity string, reportURIs []string, err error) {
-	for _, em := range list.filters {
-		if em.filter.Match(re.ID) {
-			reportURIs = append(reportURIs, em.reportURI)
-		}
-	}
+	reportURIs = applyExclusions(list.filters.search(re.ID), list.excludes.search(re.ID))
 	if len(reportURIs) == 0 {
 		return pureIdentity, reportURIs, fmt.Errorf("no match found for %v", re.ID)
 	}
@@ -129,7 +127,100 @@ func (list *List) UnmarshalBinary(data []byte) (err error) {
 		return fmt.Errorf("Wrong Filtering Engine: %s", typeOfEngine)
 	}
 
-	// Size of List
+	// ListFilters
+	if list.filters, err = decodeListFilters(dec); err != nil {
+		return
+	}
+
+	// ListFilters of the exclude patterns
+	list.excludes, err = decodeListFilters(dec)
+
+	// tdt.Core
+	list.tdtCore = tdt.NewCore()
+
+	return
+}
+
+// addSubscriptions adds the filters of the subscriptions if not exists yet
+func (lf ListFilters) addSubscriptions(sub Subscriptions) ListFilters {
+	bsub := sub.ToByteSubscriptions()
+	// store ExactMatch in sorted order from sub
+	for _, fs := range bsub.Keys() {
+		for _, reportURI := range bsub[fs].ReportURIs {
+			lf = lf.add(fs, reportURI)
+		}
+	}
+	return lf
+}
+
+// deleteSubscriptions deletes the filters of the subscriptions if already exist
+func (lf ListFilters) deleteSubscriptions(sub Subscriptions) ListFilters {
+	bsub := sub.ToByteSubscriptions()
+	for _, fs := range bsub.Keys() {
+		for _, reportURI := range bsub[fs].ReportURIs {
+			lf = lf.delete(fs, reportURI)
+		}
+	}
+	return lf
+}
+
+// add appends the filter for the reportURI if not exists yet,
+// or adds a reference to the reportURI to the filter
+func (lf ListFilters) add(fs string, reportURI string) ListFilters {
+	i := lf.indexOfFilter(NewFilter(fs, 0))
+	if i < 0 {
+		return append(lf, &ExactMatch{
+			filter:     NewFilter(fs, 0),
+			reportURIs: []string{reportURI},
+		})
+	}
+	lf[i].reportURIs = addReportURI(lf[i].reportURIs, reportURI)
+	return lf
+}
+
+// delete removes a reference to the reportURI from the filter,
+// and the filter when no reportURI left
+func (lf ListFilters) delete(fs string, reportURI string) ListFilters {
+	i := lf.indexOfFilter(NewFilter(fs, 0))
+	if i < 0 {
+		return lf
+	}
+	lf[i].reportURIs = removeReportURI(lf[i].reportURIs, reportURI)
+	if len(lf[i].reportURIs) == 0 {
+		return append(lf[:i], lf[i+1:]...)
+	}
+	return lf
+}
+
+// search returns the reportURIs of all the filters matching the id
+func (lf ListFilters) search(id []byte) (reportURIs []string) {
+	for _, em := range lf {
+		if em.filter.Match(id) {
+			reportURIs = append(reportURIs, em.reportURIs...)
+		}
+	}
+	return
+}
+
+// encode writes the ListFilters to the gob encoder
+func (lf ListFilters) encode(enc *gob.Encoder) (err error) {
+	// Size of ListFilters
+	enc.Encode(len(lf))
+	for _, em := range lf {
+		// Notify
+		enc.Encode(len(em.reportURIs))
+		for _, reportURI := range em.reportURIs {
+			enc.Encode(reportURI)
+		}
+		// Filter
+		err = enc.Encode(em.filter)
+	}
+	return
+}
+
+// decodeListFilters reads the ListFilters written by encode from the gob decoder
+func decodeListFilters(dec *gob.Decoder) (lf ListFilters, err error) {
+	// Size of ListFilters
 	var listSize int
 	if err = dec.Decode(&listSize); err != nil {
 		return
@@ -138,37 +229,54 @@ func (list *List) UnmarshalBinary(data []byte) (err error) {
 	for i := 0; i < listSize; i++ {
 		em := ExactMatch{}
 		// Notify
-		if err = dec.Decode(&em.reportURI); err != nil {
+		var reportURIsSize int
+		if err = dec.Decode(&reportURIsSize); err != nil {
 			return
 		}
+		for j := 0; j < reportURIsSize; j++ {
+			var reportURI string
+			if err = dec.Decode(&reportURI); err != nil {
+				return
+			}
+			em.reportURIs = append(em.reportURIs, reportURI)
+		}
 		// Filter
 		err = dec.Decode(&em.filter)
-		list.filters = append(list.filters, &em)
+		lf = append(lf, &em)
 	}
-
-	// tdt.Core
-	list.tdtCore = tdt.NewCore()
-
 	return
 }
 
+// print writes the filters with their reportURIs, used for Dump()
+func (lf ListFilters) print(writer io.Writer) {
+	for _, em := range lf {
+		fmt.Fprintf(writer, "--%s %s\n", em.filter.ToString(), strings.Join(distinctReportURIs(em.reportURIs), ","))
+	}
+}
+
 // NewList builds a simple list of filters from filter.ByteSubscriptions
 // returns the pointer to the slice of ExactMatch struct
 func NewList(sub Subscriptions) Engine {
 	list := &List{}
 
 	// preprocess the subscriptions
-	bsub := sub.ToByteSubscriptions()
-
-	// store ExactMatch in sorted order from sub
-	for _, fs := range bsub.Keys() {
-		list.filters = append(list.filters, &ExactMatch{
-			filter:    NewFilter(fs, 0),
-			reportURI: bsub[fs].ReportURI,
-		})
-	}
+	includes, excludes := sub.Split()
+	list.filters = newListFilters(includes)
+	list.excludes = newListFilters(excludes)
 
 	// initialize the tdt.Core
 	list.tdtCore = tdt.NewCore()
 	return list
 }
+
+// newListFilters returns the ListFilters of the subscriptions in sorted order
+func newListFilters(sub Subscriptions) (lf ListFilters) {
+	bsub := sub.ToByteSubscriptions()
+	for _, fs := range bsub.Keys() {
+		lf = append(lf, &ExactMatch{
+			filter:     NewFilter(fs, 0),
+			reportURIs: bsub[fs].ReportURIs,
+		})
+	}
+	return
+}
@@ -70,8 +70,8 @@ func TestList_UnmarshalBinary(t *testing.T) {
 				"simple unmarshal",
 				&List{
 					FilterLists{
-						&ExactMatch{NewFilter("0011", 0), "http://localhost:8888/3"},
-						&ExactMatch{NewFilter("00110000", 0), "http://localhost:8888/3-0"},
+						&ExactMatch{NewFilter("0011", 0), []string{"http://localhost:8888/3"}},
+						&ExactMatch{NewFilter("00110000", 0), []string{"http://localhost:8888/3-0"}},
 					},
 					tdt.NewCore(),
 				},
@@ -104,26 +104,26 @@ func TestFilterLists_IndexOf(t *testing.T) {
 		{
 			"Contains true",
 			ListFilters{
-				&ExactMatch{NewFilter("0011", 0), "http://localhost:8888/3"},
-				&ExactMatch{NewFilter("00110000", 0), "http://localhost:8888/3-0"},
-				&ExactMatch{NewFilter("001100110000", 0), "http://localhost:8888/3-3-0"},
-				&ExactMatch{NewFilter("1111", 0), "http://localhost:8888/15"},
+				&ExactMatch{NewFilter("0011", 0), []string{"http://localhost:8888/3"}},
+				&ExactMatch{NewFilter("00110000", 0), []string{"http://localhost:8888/3-0"}},
+				&ExactMatch{NewFilter("001100110000", 0), []string{"http://localhost:8888/3-3-0"}},
+				&ExactMatch{NewFilter("1111", 0), []string{"http://localhost:8888/15"}},
 			},
 			args{
-				&ExactMatch{NewFilter("1111", 0), "http://localhost:8888/15"},
+				&ExactMatch{NewFilter("1111", 0), []string{"http://localhost:8888/15"}},
 			},
 			3,
 		},
 		{
 			"Contains false",
 			ListFilters{
-				&ExactMatch{NewFilter("0011", 0), "http://localhost:8888/3"},
-				&ExactMatch{NewFilter("00110000", 0), "http://localhost:8888/3-0"},
-				&ExactMatch{NewFilter("001100110000", 0), "http://localhost:8888/3-3-0"},
-				&ExactMatch{NewFilter("1111", 0), "http://localhost:8888/15"},
+				&ExactMatch{NewFilter("0011", 0), []string{"http://localhost:8888/3"}},
+				&ExactMatch{NewFilter("00110000", 0), []string{"http://localhost:8888/3-0"}},
+				&ExactMatch{NewFilter("001100110000", 0), []string{"http://localhost:8888/3-3-0"}},
+				&ExactMatch{NewFilter("1111", 0), []string{"http://localhost:8888/15"}},
 			},
 			args{
-				&ExactMatch{NewFilter("11", 0), "http://localhost:8888/3"},
+				&ExactMatch{NewFilter("11", 0), []string{"http://localhost:8888/3"}},
 			},
 			-1,
 		},
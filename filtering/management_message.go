@@ -30,3 +30,46 @@ type ManagementMessage struct {
 	MatchedCount            int64
 	EngineName              string
 }
+
+// ManagementCommand is to indicate the command of ManagementRequest
+type ManagementCommand string
+
+// ManagementRequest commands
+const (
+	AddSubscriptionCommand    ManagementCommand = "AddSubscription"
+	DeleteSubscriptionCommand ManagementCommand = "DeleteSubscription"
+	ListSubscriptionsCommand  ManagementCommand = "ListSubscriptions"
+	GetSelectedEngineCommand  ManagementCommand = "GetSelectedEngine"
+	GetEngineStatesCommand    ManagementCommand = "GetEngineStates"
+	DumpEngineCommand         ManagementCommand = "DumpEngine"
+	FireTriggerCommand        ManagementCommand = "FireTrigger"
+)
+
+// ManagementResponseType is to indicate the type of ManagementResponse
+type ManagementResponseType string
+
+// ManagementResponse types
+const (
+	Ack   ManagementResponseType = "Ack"
+	Error ManagementResponseType = "Error"
+)
+
+// ManagementRequest is a request from the management endpoint
+type ManagementRequest struct {
+	Command    ManagementCommand
+	ReportURI  string `json:",omitempty"`
+	Pattern    string `json:",omitempty"`
+	EngineName string `json:",omitempty"`
+	Trigger    string `json:",omitempty"`
+}
+
+// ManagementResponse is a reply to the ManagementRequest
+type ManagementResponse struct {
+	Type          ManagementResponseType
+	Command       ManagementCommand
+	Error         string            `json:",omitempty"`
+	Subscriptions Subscriptions     `json:",omitempty"`
+	EngineName    string            `json:",omitempty"`
+	EngineStates  map[string]string `json:",omitempty"`
+	EngineDump    string            `json:",omitempty"`
+}
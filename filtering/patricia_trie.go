@@ -20,38 +20,51 @@ import (
 
 // PatriciaTrie struct
 type PatriciaTrie struct {
-	root    *PatriciaTrieNode
-	tdtCore *tdt.Core
+	root *PatriciaTrieNode
+	// masked keeps the filters with the wildcard bits the trie cannot branch on
+	masked ListFilters
+	// excludes keeps the filters of the exclude patterns apart in another trie
+	excludes *PatriciaTrie
+	tdtCore  *tdt.Core
 }
 
 // PatriciaTrieNode is a node for PatriciaTrie
 type PatriciaTrieNode struct {
-	reportURI    string
+	reportURIs   []string
 	filterObject *FilterObject
 	one          *PatriciaTrieNode
 	zero         *PatriciaTrieNode
 }
 
-// AddSubscription adds a set of subscriptions if not exists yet
+// AddSubscription adds a set of subscriptions,
+// each pattern holds a reference to the reportURI on the filters it compiles to
 func (pt *PatriciaTrie) AddSubscription(sub Subscriptions) {
-	bsub := sub.ToByteSubscriptions()
-	for _, fs := range bsub.Keys() {
-		pt.root.add(fs, bsub[fs].ReportURI)
+	includes, excludes := sub.Split()
+	pt.add(includes)
+	if pt.excludes == nil {
+		pt.excludes = newPatriciaTrie(Subscriptions{})
 	}
+	pt.excludes.add(excludes)
 }
 
-// DeleteSubscription deletes a set of subscriptions if already exist
+// DeleteSubscription deletes a set of subscriptions if already exist,
+// a reportURI remains on a filter until none of its patterns refers to it
 func (pt *PatriciaTrie) DeleteSubscription(sub Subscriptions) {
-	bsub := sub.ToByteSubscriptions()
-	for _, fs := range bsub.Keys() {
-		pt.root.delete(fs, bsub[fs].ReportURI)
+	includes, excludes := sub.Split()
+	pt.delete(includes)
+	if pt.excludes != nil {
+		pt.excludes.delete(excludes)
 	}
 }
 
 // Dump returs a string representation of the PatriciaTrie
 func (pt *PatriciaTrie) Dump() string {
 	writer := &bytes.Buffer{}
-	pt.root.print(writer, 0)
+	pt.print(writer)
+	if !pt.excludes.isEmpty() {
+		fmt.Fprintln(writer, "excludes:")
+		pt.excludes.print(writer)
+	}
 	return writer.String()
 }
 
@@ -63,8 +76,13 @@ func (pt *PatriciaTrie) MarshalBinary() (_ []byte, err error) {
 	// Type of Engine
 	enc.Encode("Engine:filtering.PatriciaTrie")
 
-	// Encode PatriciaTrieNode
-	enc.Encode(pt.root)
+	// the trie of the include patterns
+	if err = pt.encode(enc); err != nil {
+		return
+	}
+
+	// the trie of the exclude patterns
+	err = pt.excludes.encode(enc)
 
 	return buf.Bytes(), err
 }
@@ -76,7 +94,7 @@ func (pt *PatriciaTrie) Name() string {
 
 // Search returns a pureIdentity of the llrp.ReadEvent if found any subscription without err
 func (pt *PatriciaTrie) Search(re llrp.ReadEvent) (pureIdentity string, reportURIs []string, err error) {
-	reportURIs = pt.root.search(re.ID)
+	reportURIs = applyExclusions(pt.search(re.ID), pt.excludes.search(re.ID))
 	if len(reportURIs) == 0 {
 		return pureIdentity, reportURIs, fmt.Errorf("no match found for %v", re.ID)
 	}
@@ -94,8 +112,14 @@ func (pt *PatriciaTrie) UnmarshalBinary(data []byte) (err error) {
 		return errors.New("Wrong Filtering Engine: " + typeOfEngine)
 	}
 
-	// Decode PatriciaTrieNode
-	err = dec.Decode(&pt.root)
+	// the trie of the include patterns
+	if err = pt.decode(dec); err != nil {
+		return
+	}
+
+	// the trie of the exclude patterns
+	pt.excludes = &PatriciaTrie{}
+	err = pt.excludes.decode(dec)
 
 	// tdt.Core
 	pt.tdtCore = tdt.NewCore()
@@ -103,13 +127,89 @@ func (pt *PatriciaTrie) UnmarshalBinary(data []byte) (err error) {
 	return
 }
 
+// add adds the subscriptions to the trie and the masked ListFilters
+func (pt *PatriciaTrie) add(sub Subscriptions) {
+	bsub := sub.ToByteSubscriptions()
+	for _, fs := range bsub.Keys() {
+		for _, reportURI := range bsub[fs].ReportURIs {
+			if isMasked(fs) {
+				pt.masked = pt.masked.add(fs, reportURI)
+				continue
+			}
+			pt.root.add(fs, reportURI)
+		}
+	}
+}
+
+// delete deletes the subscriptions from the trie and the masked ListFilters
+func (pt *PatriciaTrie) delete(sub Subscriptions) {
+	bsub := sub.ToByteSubscriptions()
+	for _, fs := range bsub.Keys() {
+		for _, reportURI := range bsub[fs].ReportURIs {
+			if isMasked(fs) {
+				pt.masked = pt.masked.delete(fs, reportURI)
+				continue
+			}
+			pt.root.delete(fs, reportURI)
+		}
+	}
+}
+
+// search returns the reportURIs of all the filters matching the id
+func (pt *PatriciaTrie) search(id []byte) []string {
+	if pt == nil {
+		return nil
+	}
+	return append(pt.root.search(id), pt.masked.search(id)...)
+}
+
+// isEmpty checks if the trie has no filter
+func (pt *PatriciaTrie) isEmpty() bool {
+	return pt == nil || len(pt.root.reportURIs) == 0 && pt.root.one == nil && pt.root.zero == nil && len(pt.masked) == 0
+}
+
+// print writes the trie and the masked ListFilters, used for Dump()
+func (pt *PatriciaTrie) print(writer io.Writer) {
+	pt.root.print(writer, 0)
+	pt.masked.print(writer)
+}
+
+// encode writes the trie and the masked ListFilters to the gob encoder
+func (pt *PatriciaTrie) encode(enc *gob.Encoder) error {
+	if pt == nil {
+		return newPatriciaTrie(Subscriptions{}).encode(enc)
+	}
+	// Encode PatriciaTrieNode
+	if err := enc.Encode(pt.root); err != nil {
+		return err
+	}
+
+	// the masked ListFilters
+	return pt.masked.encode(enc)
+}
+
+// decode reads the trie and the masked ListFilters written by encode from the gob decoder
+func (pt *PatriciaTrie) decode(dec *gob.Decoder) (err error) {
+	// Decode PatriciaTrieNode
+	if err = dec.Decode(&pt.root); err != nil {
+		return
+	}
+
+	// the masked ListFilters
+	pt.masked, err = decodeListFilters(dec)
+	return
+}
+
 // MarshalBinary overwrites the marshaller in gob encoding *PatriciaTrie
 func (ptn *PatriciaTrieNode) MarshalBinary() (_ []byte, err error) {
 	var buf bytes.Buffer
 	enc := gob.NewEncoder(&buf)
 
-	// reportURI
-	enc.Encode(ptn.reportURI)
+	// reportURIs
+	enc.Encode(len(ptn.reportURIs))
+	for _, reportURI := range ptn.reportURIs {
+		enc.Encode(reportURI)
+	}
 
 	// Filter
 	hasFilter := ptn.filterObject != nil
@@ -140,9 +240,18 @@ func (ptn *PatriciaTrieNode) UnmarshalBinary(data []byte) (err error) {
 	dec := gob.NewDecoder(bytes.NewReader(data))
 
 	// reportURIs
-	if err = dec.Decode(&ptn.reportURI); err != nil {
+	var reportURIsSize int
+	if err = dec.Decode(&reportURIsSize); err != nil {
 		return
 	}
+	ptn.reportURIs = nil
+	for i := 0; i < reportURIsSize; i++ {
+		var reportURI string
+		if err = dec.Decode(&reportURI); err != nil {
+			return
+		}
+		ptn.reportURIs = append(ptn.reportURIs, reportURI)
+	}
 
 	// Filter
 	var hasFilter bool
@@ -171,7 +280,7 @@ func (ptn *PatriciaTrieNode) UnmarshalBinary(data []byte) (err error) {
 	if err = dec.Decode(&hasZero); err != nil {
 		return
 	}
-	if hasOne {
+	if hasZero {
 		err = dec.Decode(&ptn.zero)
 	} else {
 		ptn.zero = nil
@@ -186,10 +295,8 @@ func (ptn *PatriciaTrieNode) UnmarshalBinary(data []byte) (err error) {
 func (ptn *PatriciaTrieNode) add(fs string, reportURI string) {
 	if strings.HasPrefix(fs, ptn.filterObject.String) { // fs \in pt.FilterObject.String
 		if fs == ptn.filterObject.String { // the identical filter
-			// if the reportURI is different, update it
-			if ptn.reportURI != reportURI {
-				ptn.reportURI = reportURI
-			}
+			// add a reference to the reportURI
+			ptn.reportURIs = addReportURI(ptn.reportURIs, reportURI)
 			return //end
 		}
 		//} else if len(fs) < pt.filterObject.Size { // Needs a reconstruction
@@ -200,11 +307,20 @@ func (ptn *PatriciaTrieNode) add(fs string, reportURI string) {
 		newNode.filterObject = NewFilter(ptn.filterObject.String[ncpLength:], ptn.filterObject.Offset+ncpLength)
 		newNode.one = ptn.one
 		newNode.zero = ptn.zero
-		newNode.reportURI = ptn.reportURI
-		ptn.reportURI = ""
+		newNode.reportURIs = ptn.reportURIs
+		ptn.reportURIs = nil
 		currentOffset := ptn.filterObject.Offset
 		ptn.filterObject = NewFilter(newCommonPrefix, currentOffset)
 		if ncpLength >= len(fs) {
+			// fs itself is the new common prefix
+			ptn.reportURIs = []string{reportURI}
+			ptn.one, ptn.zero = nil, nil
+			switch newNode.filterObject.String[0] {
+			case '1':
+				ptn.one = newNode
+			case '0':
+				ptn.zero = newNode
+			}
 			return
 		}
 		switch fs[ncpLength] {
@@ -212,12 +328,12 @@ func (ptn *PatriciaTrieNode) add(fs string, reportURI string) {
 			ptn.zero = newNode
 			ptn.one = &PatriciaTrieNode{}
 			ptn.one.filterObject = NewFilter(fs[ncpLength:], currentOffset+ncpLength)
-			ptn.one.reportURI = reportURI
+			ptn.one.reportURIs = []string{reportURI}
 		case '0':
 			ptn.one = newNode
 			ptn.zero = &PatriciaTrieNode{}
 			ptn.zero.filterObject = NewFilter(fs[ncpLength:], currentOffset+ncpLength)
-			ptn.zero.reportURI = reportURI
+			ptn.zero.reportURIs = []string{reportURI}
 		}
 		return //end
 	}
@@ -229,7 +345,7 @@ func (ptn *PatriciaTrieNode) add(fs string, reportURI string) {
 			if ptn.one == nil {
 				ptn.one = &PatriciaTrieNode{}
 				ptn.one.filterObject = NewFilter(fs[ptn.filterObject.Size:], ptn.filterObject.Offset+ptn.filterObject.Size)
-				ptn.one.reportURI = reportURI
+				ptn.one.reportURIs = []string{reportURI}
 				return //end
 			}
 			ptn.one.add(fs[ptn.filterObject.Size:], reportURI)
@@ -237,7 +353,7 @@ func (ptn *PatriciaTrieNode) add(fs string, reportURI string) {
 			if ptn.zero == nil {
 				ptn.zero = &PatriciaTrieNode{}
 				ptn.zero.filterObject = NewFilter(fs[ptn.filterObject.Size:], ptn.filterObject.Offset+ptn.filterObject.Size)
-				ptn.zero.reportURI = reportURI
+				ptn.zero.reportURIs = []string{reportURI}
 				return //end
 			}
 			ptn.zero.add(fs[ptn.filterObject.Size:], reportURI)
@@ -288,7 +404,7 @@ func (ptn *PatriciaTrieNode) build(prefix string, bsub ByteSubscriptions) {
 		cumulativePrefix = prefix + onePrefixBranch
 		// check if the prefix matches whole filter
 		if _, ok := bsub[cumulativePrefix]; ok {
-			ptn.one.reportURI = bsub[cumulativePrefix].ReportURI
+			ptn.one.reportURIs = bsub[cumulativePrefix].ReportURIs
 		}
 		ptn.one.build(cumulativePrefix, bsub)
 	}
@@ -299,7 +415,7 @@ func (ptn *PatriciaTrieNode) build(prefix string, bsub ByteSubscriptions) {
 		cumulativePrefix = prefix + zeroPrefixBranch
 		// check if the prefix matches whole filter
 		if _, ok := bsub[cumulativePrefix]; ok {
-			ptn.zero.reportURI = bsub[cumulativePrefix].ReportURI
+			ptn.zero.reportURIs = bsub[cumulativePrefix].ReportURIs
 		}
 		ptn.zero.build(cumulativePrefix, bsub)
 	}
@@ -314,18 +430,22 @@ func (ptn *PatriciaTrieNode) delete(fs string, reportURI string) {
 
 	// This is the filter to delete
 	if fs == ptn.filterObject.String {
+		ptn.reportURIs = removeReportURI(ptn.reportURIs, reportURI)
+		if len(ptn.reportURIs) != 0 { // other reportURIs still subscribe the filter
+			return //end
+		}
 		if ptn.one != nil && ptn.zero != nil { // node in the middle
-			ptn.reportURI = ""
+			ptn.reportURIs = nil
 		} else if ptn.one != nil { // has only one node
 			newFilter := NewFilter(ptn.filterObject.String+ptn.one.filterObject.String, ptn.filterObject.Offset)
 			ptn.filterObject = newFilter
-			ptn.reportURI = ptn.one.reportURI
+			ptn.reportURIs = ptn.one.reportURIs
 			ptn.zero = ptn.one.zero
 			ptn.one = ptn.one.one
 		} else if ptn.zero != nil { // has only zero node
 			newFilter := NewFilter(ptn.filterObject.String+ptn.zero.filterObject.String, ptn.filterObject.Offset)
 			ptn.filterObject = newFilter
-			ptn.reportURI = ptn.zero.reportURI
+			ptn.reportURIs = ptn.zero.reportURIs
 			ptn.one = ptn.zero.one
 			ptn.zero = ptn.zero.zero
 		}
@@ -338,7 +458,8 @@ func (ptn *PatriciaTrieNode) delete(fs string, reportURI string) {
 		case '1':
 			if ptn.one != nil {
 				if fs[ptn.filterObject.Size:] == ptn.one.filterObject.String &&
-					ptn.one.one == nil && ptn.one.zero == nil {
+					ptn.one.one == nil && ptn.one.zero == nil &&
+					len(removeReportURI(ptn.one.reportURIs, reportURI)) == 0 {
 					ptn.one = nil
 				} else {
 					ptn.one.delete(fs[ptn.filterObject.Size:], reportURI)
@@ -347,7 +468,8 @@ func (ptn *PatriciaTrieNode) delete(fs string, reportURI string) {
 		case '0':
 			if ptn.zero != nil {
 				if fs[ptn.filterObject.Size:] == ptn.zero.filterObject.String &&
-					ptn.zero.one == nil && ptn.zero.zero == nil {
+					ptn.zero.one == nil && ptn.zero.zero == nil &&
+					len(removeReportURI(ptn.zero.reportURIs, reportURI)) == 0 {
 					ptn.zero = nil
 				} else {
 					ptn.zero.delete(fs[ptn.filterObject.Size:], reportURI)
@@ -359,7 +481,7 @@ func (ptn *PatriciaTrieNode) delete(fs string, reportURI string) {
 }
 
 func (ptn *PatriciaTrieNode) equal(want *PatriciaTrieNode) (ok bool, got *PatriciaTrieNode, wanted *PatriciaTrieNode) {
-	if ptn.reportURI != want.reportURI ||
+	if !reflect.DeepEqual(ptn.reportURIs, want.reportURIs) ||
 		!reflect.DeepEqual(ptn.filterObject, want.filterObject) {
 		return false, ptn, want
 	}
@@ -386,8 +508,8 @@ func (ptn *PatriciaTrieNode) equal(want *PatriciaTrieNode) (ok bool, got *Patric
 
 func (ptn *PatriciaTrieNode) print(writer io.Writer, indent int) {
 	var n string
-	if len(ptn.reportURI) != 0 {
-		n = "-> " + ptn.reportURI
+	if len(ptn.reportURIs) != 0 {
+		n = "-> " + strings.Join(distinctReportURIs(ptn.reportURIs), ",")
 	}
 	fmt.Fprintf(writer, "%s--%s %s\n", strings.Repeat(" ", indent), ptn.filterObject.ToString(), n)
 	if ptn.one != nil {
@@ -404,10 +526,8 @@ func (ptn *PatriciaTrieNode) search(id []byte) (reportURIs []string) {
 		return
 	}
 
-	// if the id matched with this node, return reportURI
-	if len(ptn.reportURI) != 0 {
-		reportURIs = append(reportURIs, ptn.reportURI)
-	}
+	// if the id matched with this node, return reportURIs
+	reportURIs = append(reportURIs, ptn.reportURIs...)
 
 	// Determine next filter
 	nextBitOffset := ptn.filterObject.Offset + ptn.filterObject.Size
@@ -426,10 +546,23 @@ func (ptn *PatriciaTrieNode) search(id []byte) (reportURIs []string) {
 // NewPatriciaTrie builds PatriciaTrie from filter.ByteSubscriptions
 // returns the pointer to the node
 func NewPatriciaTrie(sub Subscriptions) Engine {
+	includes, excludes := sub.Split()
+	pt := newPatriciaTrie(includes)
+	pt.excludes = newPatriciaTrie(excludes)
+
+	// initialize the tdt.Core
+	pt.tdtCore = tdt.NewCore()
+
+	return pt
+}
+
+// newPatriciaTrie builds the trie and the masked ListFilters of the subscriptions
+func newPatriciaTrie(sub Subscriptions) *PatriciaTrie {
 	pt := &PatriciaTrie{}
 
 	// preprocess the subscriptions
 	bsub := sub.ToByteSubscriptions()
+	pt.masked = bsub.takeMasked()
 
 	// build PatriciaTrie
 	p1 := lcp(bsub.Keys())
@@ -438,10 +571,11 @@ func NewPatriciaTrie(sub Subscriptions) Engine {
 	}
 	pt.root = &PatriciaTrieNode{}
 	pt.root.filterObject = NewFilter(p1, 0)
+	// the common prefix itself can be a subscription
+	if _, ok := bsub[p1]; ok {
+		pt.root.reportURIs = bsub[p1].ReportURIs
+	}
 	pt.root.build(p1, bsub)
 
-	// initialize the tdt.Core
-	pt.tdtCore = tdt.NewCore()
-
 	return pt
 }
@@ -13,6 +13,7 @@ import (
 	"io"
 	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/iomz/go-llrp"
 	"github.com/iomz/gosstrak/tdt"
@@ -20,51 +21,79 @@ import (
 
 // SplayTree struct
 type SplayTree struct {
-	root    *SplayTreeNode
-	tdtCore *tdt.Core
+	root *SplayTreeNode
+	// masked keeps the filters with the wildcard bits overlapping the others in the tree
+	masked ListFilters
+	// excludes keeps the filters of the exclude patterns apart in another tree
+	excludes *SplayTree
+	tdtCore  *tdt.Core
+	// mutex guards the trees splayed on every search
+	mutex sync.Mutex
 }
 
 // SplayTreeNode is a node for SplayTree
 type SplayTreeNode struct {
-	reportURI    string
+	reportURIs   []string
 	filterObject *FilterObject
 	matchNext    *SplayTreeNode
 	mismatchNext *SplayTreeNode
 }
 
-// AddSubscription adds a set of subscriptions if not exists yet
+// AddSubscription adds a set of subscriptions,
+// each pattern holds a reference to the reportURI on the filters it compiles to
 func (st *SplayTree) AddSubscription(sub Subscriptions) {
-	bsub := sub.ToByteSubscriptions()
-	for _, fs := range bsub.Keys() {
-		st.root.add(fs, bsub[fs].ReportURI)
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	includes, excludes := sub.Split()
+	st.add(includes)
+	if st.excludes == nil {
+		st.excludes = newSplayTree(Subscriptions{})
 	}
+	st.excludes.add(excludes)
 }
 
-// DeleteSubscription deletes a set of subscriptions if already exist
+// DeleteSubscription deletes a set of subscriptions if already exist,
+// a reportURI remains on a filter until none of its patterns refers to it
 func (st *SplayTree) DeleteSubscription(sub Subscriptions) {
-	bsub := sub.ToByteSubscriptions()
-	for _, fs := range bsub.Keys() {
-		st.root.delete(fs, bsub[fs].ReportURI)
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	includes, excludes := sub.Split()
+	st.delete(includes)
+	if st.excludes != nil {
+		st.excludes.delete(excludes)
 	}
 }
 
 // Dump returs a string representation of the PatriciaTrie
 func (st *SplayTree) Dump() string {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
 	writer := &bytes.Buffer{}
-	st.root.print(writer, 0)
+	st.print(writer)
+	if !st.excludes.isEmpty() {
+		fmt.Fprintln(writer, "excludes:")
+		st.excludes.print(writer)
+	}
 	return writer.String()
 }
 
 // MarshalBinary overwrites the marshaller in gob encoding *SplayTree
 func (st *SplayTree) MarshalBinary() (_ []byte, err error) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
 	var buf bytes.Buffer
 	enc := gob.NewEncoder(&buf)
 
 	// Type of Engine
 	enc.Encode("Engine:filtering.SplayTree")
 
-	// Encode SplayTreeNode
-	enc.Encode(st.root)
+	// the tree of the include patterns
+	if err = st.encode(enc); err != nil {
+		return
+	}
+
+	// the tree of the exclude patterns
+	err = st.excludes.encode(enc)
 
 	return buf.Bytes(), err
 }
@@ -76,7 +105,9 @@ func (st *SplayTree) Name() string {
 
 // Search returns a pureIdentity of the llrp.ReadEvent if found any subscription without err
 func (st *SplayTree) Search(re llrp.ReadEvent) (pureIdentity string, reportURIs []string, err error) {
-	reportURIs = st.root.splaySearch(st, nil, re.ID)
+	st.mutex.Lock()
+	reportURIs = applyExclusions(st.search(re.ID), st.excludes.search(re.ID))
+	st.mutex.Unlock()
 	if len(reportURIs) == 0 {
 		return pureIdentity, reportURIs, fmt.Errorf("no match found for %v", re.ID)
 	}
@@ -94,8 +125,14 @@ func (st *SplayTree) UnmarshalBinary(data []byte) (err error) {
 		return errors.New("Wrong Filtering Engine: " + typeOfEngine)
 	}
 
-	// Decode SplayTreeNode
-	err = dec.Decode(&st.root)
+	// the tree of the include patterns
+	if err = st.decode(dec); err != nil {
+		return
+	}
+
+	// the tree of the exclude patterns
+	st.excludes = &SplayTree{}
+	err = st.excludes.decode(dec)
 
 	// tdt.Core
 	st.tdtCore = tdt.NewCore()
@@ -103,13 +140,89 @@ func (st *SplayTree) UnmarshalBinary(data []byte) (err error) {
 	return
 }
 
+// add adds the subscriptions to the tree and the masked ListFilters
+func (st *SplayTree) add(sub Subscriptions) {
+	bsub := sub.ToByteSubscriptions()
+	for _, fs := range bsub.Keys() {
+		for _, reportURI := range bsub[fs].ReportURIs {
+			if isMasked(fs) {
+				st.masked = st.masked.add(fs, reportURI)
+				continue
+			}
+			st.root.add(fs, reportURI)
+		}
+	}
+}
+
+// delete deletes the subscriptions from the tree and the masked ListFilters
+func (st *SplayTree) delete(sub Subscriptions) {
+	bsub := sub.ToByteSubscriptions()
+	for _, fs := range bsub.Keys() {
+		for _, reportURI := range bsub[fs].ReportURIs {
+			if isMasked(fs) {
+				st.masked = st.masked.delete(fs, reportURI)
+				continue
+			}
+			st.root.delete(fs, reportURI)
+		}
+	}
+}
+
+// search returns the reportURIs of all the filters matching the id
+func (st *SplayTree) search(id []byte) []string {
+	if st == nil {
+		return nil
+	}
+	return append(st.root.splaySearch(&st.root, nil, id), st.masked.search(id)...)
+}
+
+// isEmpty checks if the tree has no filter
+func (st *SplayTree) isEmpty() bool {
+	return st == nil || st.root.filterObject == nil && len(st.masked) == 0
+}
+
+// print writes the tree and the masked ListFilters, used for Dump()
+func (st *SplayTree) print(writer io.Writer) {
+	st.root.print(writer, 0)
+	st.masked.print(writer)
+}
+
+// encode writes the tree and the masked ListFilters to the gob encoder
+func (st *SplayTree) encode(enc *gob.Encoder) error {
+	if st == nil {
+		return newSplayTree(Subscriptions{}).encode(enc)
+	}
+	// Encode SplayTreeNode
+	if err := enc.Encode(st.root); err != nil {
+		return err
+	}
+
+	// the masked ListFilters
+	return st.masked.encode(enc)
+}
+
+// decode reads the tree and the masked ListFilters written by encode from the gob decoder
+func (st *SplayTree) decode(dec *gob.Decoder) (err error) {
+	// Decode SplayTreeNode
+	if err = dec.Decode(&st.root); err != nil {
+		return
+	}
+
+	// the masked ListFilters
+	st.masked, err = decodeListFilters(dec)
+	return
+}
+
 // MarshalBinary overwrites the marshaller in gob encoding *SplayTreeNode
 func (stn *SplayTreeNode) MarshalBinary() (_ []byte, err error) {
 	var buf bytes.Buffer
 	enc := gob.NewEncoder(&buf)
 
-	// ReportURI
-	enc.Encode(stn.reportURI)
+	// ReportURIs
+	enc.Encode(len(stn.reportURIs))
+	for _, reportURI := range stn.reportURIs {
+		enc.Encode(reportURI)
+	}
 
 	// Filter
 	hasFilter := stn.filterObject != nil
@@ -139,10 +252,19 @@ func (stn *SplayTreeNode) MarshalBinary() (_ []byte, err error) {
 func (stn *SplayTreeNode) UnmarshalBinary(data []byte) (err error) {
 	dec := gob.NewDecoder(bytes.NewReader(data))
 
-	// reportURI
-	if err = dec.Decode(&stn.reportURI); err != nil {
+	// reportURIs
+	var reportURIsSize int
+	if err = dec.Decode(&reportURIsSize); err != nil {
 		return
 	}
+	stn.reportURIs = nil
+	for i := 0; i < reportURIsSize; i++ {
+		var reportURI string
+		if err = dec.Decode(&reportURI); err != nil {
+			return
+		}
+		stn.reportURIs = append(stn.reportURIs, reportURI)
+	}
 
 	// FilterObject
 	var hasFilterObject bool
@@ -182,26 +304,51 @@ func (stn *SplayTreeNode) UnmarshalBinary(data []byte) (err error) {
 
 // add a set of subscriptions if not exists yet
 func (stn *SplayTreeNode) add(fs string, reportURI string) {
+	if stn.filterObject == nil { // the empty tree
+		stn.filterObject = NewFilter(fs, 0)
+		stn.reportURIs = []string{reportURI}
+		return
+	}
 	if strings.HasPrefix(fs, stn.filterObject.String) { // fs \in stn.FilterObject.String
 		if fs == stn.filterObject.String { // the identical filter
-			// if the reportURI is different, update it
-			if stn.reportURI != reportURI {
-				stn.reportURI = reportURI
-			}
+			// add a reference to the reportURI
+			stn.reportURIs = addReportURI(stn.reportURIs, reportURI)
 		} else { // it's a subset (matching branch) of the current node, and there's no matchNext node
 			if stn.matchNext == nil {
 				stn.matchNext = &SplayTreeNode{}
 				stn.matchNext.filterObject = NewFilter(fs[stn.filterObject.Size:], stn.filterObject.Offset+stn.filterObject.Size)
-				stn.matchNext.reportURI = reportURI
+				stn.matchNext.reportURIs = []string{reportURI}
 			} else { // if there's already matchNext node
 				stn.matchNext.add(fs[stn.filterObject.Size:], reportURI)
 			}
 		}
+	} else if strings.HasPrefix(stn.filterObject.String, fs) { // stn.FilterObject.String \in fs
+		// no filter can be a prefix of another in the same level to stop at the first match,
+		// so the current node and the following ones in fs become the subset of fs
+		offset := stn.filterObject.Offset
+		stn.matchNext = &SplayTreeNode{
+			reportURIs:   stn.reportURIs,
+			filterObject: NewFilter(stn.filterObject.String[len(fs):], offset+len(fs)),
+			matchNext:    stn.matchNext,
+		}
+		stn.filterObject = NewFilter(fs, offset)
+		stn.reportURIs = []string{reportURI}
+		for prev := stn; prev.mismatchNext != nil; {
+			next := prev.mismatchNext
+			if !strings.HasPrefix(next.filterObject.String, fs) {
+				prev = next
+				continue
+			}
+			prev.mismatchNext = next.mismatchNext
+			next.filterObject = NewFilter(next.filterObject.String[len(fs):], offset+len(fs))
+			next.mismatchNext = stn.matchNext
+			stn.matchNext = next
+		}
 	} else { // doesn't match with the current node, traverse the mismatchNext node
 		if stn.mismatchNext == nil { // there's no mismatchNext node
 			stn.mismatchNext = &SplayTreeNode{}
 			stn.mismatchNext.filterObject = NewFilter(fs, stn.filterObject.Offset)
-			stn.mismatchNext.reportURI = reportURI
+			stn.mismatchNext.reportURIs = []string{reportURI}
 		} else { // if there's already mismatchNext node
 			stn.mismatchNext.add(fs, reportURI)
 		}
@@ -214,7 +361,7 @@ func (stn *SplayTreeNode) build(sub ByteSubscriptions) *SplayTreeNode {
 	subscriptionSize := len(sub.Keys())
 	for i, fs := range sub.Keys() {
 		current.filterObject = NewFilter(fs, sub[fs].Offset)
-		current.reportURI = sub[fs].ReportURI
+		current.reportURIs = sub[fs].ReportURIs
 		// if this node has subset
 		if len(sub[fs].Subset) != 0 {
 			matchNext := &SplayTreeNode{}
@@ -234,27 +381,33 @@ func (stn *SplayTreeNode) build(sub ByteSubscriptions) *SplayTreeNode {
 
 // delete a set of subscriptions if not exists yet
 func (stn *SplayTreeNode) delete(fs string, reportURI string) {
+	if stn.filterObject == nil { // the empty tree
+		return
+	}
 	if strings.HasPrefix(fs, stn.filterObject.String) { // fs \in stn.FilterObject.String
 		if fs == stn.filterObject.String { // this node is to delete
-			if stn.matchNext == nil && stn.mismatchNext == nil { // something wrong
+			stn.reportURIs = removeReportURI(stn.reportURIs, reportURI)
+			if len(stn.reportURIs) != 0 { // other reportURIs still subscribe the filter
+			} else if stn.matchNext == nil && stn.mismatchNext == nil { // something wrong
 			} else if stn.matchNext != nil { // if there is subset, keep the node as an aggregation node
 				if stn.matchNext.mismatchNext != nil {
-					stn.reportURI = ""
+					stn.reportURIs = nil
 				} else { // if none other mismatch branch, concatenate the matchNext with to-be-deleted node
 					stn.filterObject = NewFilter(fs+stn.matchNext.filterObject.String, stn.filterObject.Offset)
-					stn.reportURI = stn.matchNext.reportURI
+					stn.reportURIs = stn.matchNext.reportURIs
 					stn.matchNext = stn.matchNext.matchNext
 				}
 			} else if stn.mismatchNext != nil { // replace this node with mismatchNext
 				stn.filterObject = stn.mismatchNext.filterObject
-				stn.reportURI = stn.mismatchNext.reportURI
+				stn.reportURIs = stn.mismatchNext.reportURIs
 				stn.matchNext = stn.mismatchNext.matchNext
 				stn.mismatchNext = stn.mismatchNext.mismatchNext
 			}
 		} else { // it's a subset (matching branch) of the current node, and there's no matchNext node
 			if stn.matchNext != nil { // if there's a matchNext node
 				if fs[stn.filterObject.Size:] == stn.matchNext.filterObject.String &&
-					stn.matchNext.matchNext == nil && stn.matchNext.mismatchNext == nil { // the matchNext is to delete
+					stn.matchNext.matchNext == nil && stn.matchNext.mismatchNext == nil &&
+					len(removeReportURI(stn.matchNext.reportURIs, reportURI)) == 0 { // the matchNext is to delete
 					stn.matchNext = nil
 				} else {
 					stn.matchNext.delete(fs[stn.filterObject.Size:], reportURI)
@@ -264,7 +417,8 @@ func (stn *SplayTreeNode) delete(fs string, reportURI string) {
 	} else { // doesn't match with the current node, traverse the mismatchNext node
 		if stn.mismatchNext != nil { // there's a mismatchNext node
 			if fs == stn.mismatchNext.filterObject.String &&
-				stn.mismatchNext.matchNext == nil && stn.mismatchNext.mismatchNext == nil {
+				stn.mismatchNext.matchNext == nil && stn.mismatchNext.mismatchNext == nil &&
+				len(removeReportURI(stn.mismatchNext.reportURIs, reportURI)) == 0 {
 				stn.mismatchNext = nil
 			} else {
 				stn.mismatchNext.delete(fs, reportURI)
@@ -275,7 +429,7 @@ func (stn *SplayTreeNode) delete(fs string, reportURI string) {
 }
 
 func (stn *SplayTreeNode) equal(want *SplayTreeNode) (ok bool, got *SplayTreeNode, wanted *SplayTreeNode) {
-	if stn.reportURI != want.reportURI ||
+	if !reflect.DeepEqual(stn.reportURIs, want.reportURIs) ||
 		!reflect.DeepEqual(stn.filterObject, want.filterObject) {
 		return false, stn, want
 	}
@@ -302,8 +456,8 @@ func (stn *SplayTreeNode) equal(want *SplayTreeNode) (ok bool, got *SplayTreeNod
 
 func (stn *SplayTreeNode) print(writer io.Writer, indent int) {
 	var n string
-	if len(stn.reportURI) != 0 {
-		n = "-> " + stn.reportURI
+	if len(stn.reportURIs) != 0 {
+		n = "-> " + strings.Join(distinctReportURIs(stn.reportURIs), ",")
 	}
 	fmt.Fprintf(writer, "--%s %s\n", stn.filterObject.ToString(), n)
 	if stn.matchNext != nil {
@@ -316,27 +470,30 @@ func (stn *SplayTreeNode) print(writer io.Writer, indent int) {
 	}
 }
 
-func (stn *SplayTreeNode) splaySearch(st *SplayTree, parent *SplayTreeNode, id []byte) []string {
+// splaySearch returns the reportURIs of the nodes matching the id from this node in the level,
+// and moves the matched node to the head of the level for the next search
+func (stn *SplayTreeNode) splaySearch(head **SplayTreeNode, parent *SplayTreeNode, id []byte) []string {
 	matches := []string{}
-	if stn.filterObject.Match(id) {
-		matches = append(matches, stn.reportURI)
+	// the tree is empty if all the filters are masked
+	if stn.filterObject != nil && stn.filterObject.Match(id) {
+		matches = append(matches, stn.reportURIs...)
 		if stn.matchNext != nil {
 			// Do Search & Splay in the subsets
-			matches = append(matches, stn.matchNext.splaySearch(st, nil, id)...)
+			matches = append(matches, stn.matchNext.splaySearch(&stn.matchNext, nil, id)...)
 		}
 		// Do Splay
-		// 0. Check if this is the root node, do nothing if so
+		// 0. Check if this is the head of the level, do nothing if so
 		if parent != nil {
 			// 1. Remove this node by connecting parent to the next mismatchNext node
 			parent.mismatchNext = stn.mismatchNext
-			// 2. Insert self to root
-			stn.mismatchNext = st.root
-			st.root = stn
+			// 2. Insert self to the head of the level
+			stn.mismatchNext = *head
+			*head = stn
 		}
 		return matches
 	}
 	if stn.mismatchNext != nil {
-		return stn.mismatchNext.splaySearch(st, stn, id)
+		return stn.mismatchNext.splaySearch(head, stn, id)
 	}
 	return matches
 }
@@ -344,10 +501,23 @@ func (stn *SplayTreeNode) splaySearch(st *SplayTree, parent *SplayTreeNode, id [
 // NewSplayTree builds SplayTree from ByteSubscriptions
 // returns the pointer to the node node
 func NewSplayTree(sub Subscriptions) Engine {
+	includes, excludes := sub.Split()
+	st := newSplayTree(includes)
+	st.excludes = newSplayTree(excludes)
+
+	// initialize the tdt.Core
+	st.tdtCore = tdt.NewCore()
+
+	return st
+}
+
+// newSplayTree builds the tree and the masked ListFilters of the subscriptions
+func newSplayTree(sub Subscriptions) *SplayTree {
 	st := &SplayTree{}
 
 	// preprocess the subscriptions
 	bsub := sub.ToByteSubscriptions()
+	st.masked = bsub.takeMasked()
 	// make subsets to the child subscriptions of the corresponding parents
 	bsub.linkSubset()
 
@@ -355,8 +525,5 @@ func NewSplayTree(sub Subscriptions) Engine {
 	st.root = &SplayTreeNode{}
 	st.root = st.root.build(bsub)
 
-	// initialize the tdt.Core
-	st.tdtCore = tdt.NewCore()
-
 	return st
 }
@@ -20,14 +20,18 @@ import (
 	"github.com/iomz/gosstrak/tdt"
 )
 
+// ExcludeMark marks an exclude pattern in Subscriptions, e.g., !urn:epc:pat:sgtin-96:3.999203,
+// the engines Split the exclude patterns from the others and keep them apart
+const ExcludeMark = "!"
+
 // ByteSubscriptions contains filter string as key and PartialSubscription as value
 type ByteSubscriptions map[string]*PartialSubscription
 
-// PartialSubscription contains reportURI and pValue for a filter
+// PartialSubscription contains reportURIs and pValue for a filter
 type PartialSubscription struct {
-	Offset    int
-	ReportURI string
-	Subset    ByteSubscriptions
+	Offset     int
+	ReportURIs []string
+	Subset     ByteSubscriptions
 }
 
 // Subscriptions contains a slice of urn:epc:pat as values and a URI to report events as keys
@@ -116,30 +120,187 @@ func (sub Subscriptions) UnmarshalBinary(data []byte) (err error) {
 	return
 }
 
-// ToByteSubscriptions preprocess the subscription and convert them in bytes
-func (sub Subscriptions) ToByteSubscriptions() ByteSubscriptions {
-	bsub := ByteSubscriptions{}
+// Split returns the include patterns and the exclude patterns in separate Subscriptions,
+// the ExcludeMark is removed from the exclude patterns
+func (sub Subscriptions) Split() (includes Subscriptions, excludes Subscriptions) {
+	includes, excludes = Subscriptions{}, Subscriptions{}
 	for reportURI, patterns := range sub {
 		for _, pat := range patterns {
-			tf := strings.Split(strings.TrimPrefix(pat, "urn:epc:pat:"), ":")
-			if len(tf) != 2 { // should only containts a type and fields
+			if strings.HasPrefix(pat, ExcludeMark) {
+				excludes.add(reportURI, strings.TrimPrefix(pat, ExcludeMark))
 				continue
 			}
-			fields := strings.Split(strings.ToUpper(tf[1]), ".")
-			pfs, err := tdt.MakePrefixFilterString(tf[0], fields)
+			includes.add(reportURI, pat)
+		}
+	}
+	return
+}
+
+// ToByteSubscriptions preprocess the subscription and convert them in bytes,
+// the ExcludeMark is ignored and the exclude patterns need to be Split beforehand
+func (sub Subscriptions) ToByteSubscriptions() ByteSubscriptions {
+	bsub := ByteSubscriptions{}
+	for reportURI, patterns := range sub {
+		for _, pat := range patterns {
+			filters, err := MakeFilterStringsFromPattern(pat)
 			if err != nil {
 				log.Print(err)
+				continue
 			}
-			bsub[pfs] = &PartialSubscription{
-				Offset:    0,
-				ReportURI: reportURI,
-				Subset:    ByteSubscriptions{},
+			for _, fs := range filters {
+				// several patterns can share the same filter,
+				// each of them holds a reference to the reportURI
+				if psub, ok := bsub[fs]; ok {
+					psub.ReportURIs = addReportURI(psub.ReportURIs, reportURI)
+					continue
+				}
+				bsub[fs] = &PartialSubscription{
+					Offset:     0,
+					ReportURIs: []string{reportURI},
+					Subset:     ByteSubscriptions{},
+				}
 			}
 		}
 	}
 	return bsub
 }
 
+// MakePrefixFilterStringFromPattern takes urn:epc:pat:<type>:<fields> or urn:epc:raw:x<hex>
+// and returns the binary representation of the prefix filter in string,
+// the ExcludeMark is ignored
+func MakePrefixFilterStringFromPattern(pat string) (string, error) {
+	filters, err := MakeFilterStringsFromPattern(pat)
+	if err != nil {
+		return "", err
+	}
+	if len(filters) != 1 || isMasked(filters[0]) {
+		return "", fmt.Errorf("not a prefix pattern: %v", pat)
+	}
+	return filters[0], nil
+}
+
+// MakeFilterStringsFromPattern takes urn:epc:pat:<type>:<fields> or urn:epc:raw:x<hex>
+// and returns the binary representations of the filters in string, the fields can be * or [lo-hi]
+// and the x bits in the filters match any bit, the ExcludeMark is ignored
+func MakeFilterStringsFromPattern(pat string) ([]string, error) {
+	pat = strings.TrimPrefix(pat, ExcludeMark)
+	// the raw patterns match the leading hex digits of any ID, e.g., urn:epc:raw:x30 for every SGTIN-96
+	if strings.HasPrefix(pat, "urn:epc:raw:") {
+		pfs, err := tdt.MakePrefixFilterString("raw", []string{strings.TrimPrefix(pat, "urn:epc:raw:")})
+		if err != nil {
+			return nil, err
+		}
+		return []string{pfs}, nil
+	}
+	tf := strings.Split(strings.TrimPrefix(pat, "urn:epc:pat:"), ":")
+	if len(tf) != 2 { // should only containts a type and fields
+		return nil, fmt.Errorf("invalid pattern: %v", pat)
+	}
+	// the alphanumeric serials are case sensitive
+	if strings.HasPrefix(tf[0], "iso") {
+		tf[1] = strings.ToUpper(tf[1])
+	}
+	fields := strings.Split(tf[1], ".")
+	return tdt.MakeFilterStrings(tf[0], fields)
+}
+
+// isMasked checks if the filter string has any wildcard bit x,
+// which the prefix trees cannot branch on
+func isMasked(fs string) bool {
+	return strings.Contains(fs, "x")
+}
+
+// takeMasked removes the filters with any wildcard bit from the ByteSubscriptions
+// and returns them in ListFilters for the prefix trees
+func (bsub ByteSubscriptions) takeMasked() (lf ListFilters) {
+	for _, fs := range bsub.Keys() {
+		if !isMasked(fs) {
+			continue
+		}
+		for _, reportURI := range bsub[fs].ReportURIs {
+			lf = lf.add(fs, reportURI)
+		}
+		delete(bsub, fs)
+	}
+	return
+}
+
+// add appends the pattern to the reportURI if not exists yet
+// returns true if the subscriptions changed
+func (sub Subscriptions) add(reportURI string, pattern string) bool {
+	if stringIndexInSlice(pattern, sub[reportURI]) > -1 {
+		return false
+	}
+	sub[reportURI] = append(sub[reportURI], pattern)
+	return true
+}
+
+// remove deletes the pattern from the reportURI if already exists
+// returns true if the subscriptions changed
+func (sub Subscriptions) remove(reportURI string, pattern string) bool {
+	i := stringIndexInSlice(pattern, sub[reportURI])
+	if i < 0 {
+		return false
+	}
+	patterns := append([]string{}, sub[reportURI][:i]...)
+	sub[reportURI] = append(patterns, sub[reportURI][i+1:]...)
+	if len(sub[reportURI]) == 0 {
+		delete(sub, reportURI)
+	}
+	return true
+}
+
+// addReportURI inserts a reference to the reportURI in the sorted reportURIs,
+// a reportURI appears once for each of its patterns compiled to the same filter
+func addReportURI(reportURIs []string, reportURI string) []string {
+	i := sort.SearchStrings(reportURIs, reportURI)
+	reportURIs = append(reportURIs, "")
+	copy(reportURIs[i+1:], reportURIs[i:])
+	reportURIs[i] = reportURI
+	return reportURIs
+}
+
+// removeReportURI deletes a reference to the reportURI from the sorted reportURIs if already exists,
+// the reportURI remains as long as another of its patterns still refers to the filter
+func removeReportURI(reportURIs []string, reportURI string) []string {
+	i := sort.SearchStrings(reportURIs, reportURI)
+	if i == len(reportURIs) || reportURIs[i] != reportURI {
+		return reportURIs
+	}
+	return append(reportURIs[:i:i], reportURIs[i+1:]...)
+}
+
+// distinctReportURIs returns the sorted reportURIs without the repeated references
+func distinctReportURIs(reportURIs []string) []string {
+	distinct := []string{}
+	for i, reportURI := range reportURIs {
+		if i > 0 && reportURIs[i-1] == reportURI {
+			continue
+		}
+		distinct = append(distinct, reportURI)
+	}
+	return distinct
+}
+
+// applyExclusions removes the reportURIs matched by the exclude patterns
+// and the duplicates from the reportURIs matched by the include patterns
+func applyExclusions(matches []string, excludeMatches []string) []string {
+	excluded := map[string]bool{}
+	for _, dest := range excludeMatches {
+		excluded[dest] = true
+	}
+	reportURIs := []string{}
+	for _, dest := range matches {
+		if len(dest) == 0 || excluded[dest] {
+			continue
+		}
+		// a reportURI can match with several filters
+		excluded[dest] = true
+		reportURIs = append(reportURIs, dest)
+	}
+	return reportURIs
+}
+
 // LoadSubscriptionsFromCSVFile takes a csv file name and returns Subscriptions
 func LoadSubscriptionsFromCSVFile(f string) Subscriptions {
 	sub := Subscriptions{}
@@ -167,7 +328,7 @@ func LoadSubscriptionsFromCSVFile(f string) Subscriptions {
 		}
 		for i := 1; i < len(record); i++ {
 			pat := record[i]
-			if strings.HasPrefix(strings.ToLower(pat), "urn:epc:pat:") {
+			if p := strings.ToLower(strings.TrimPrefix(pat, ExcludeMark)); strings.HasPrefix(p, "urn:epc:pat:") || strings.HasPrefix(p, "urn:epc:raw:") {
 				if _, ok := sub[reportURI]; !ok {
 					sub[reportURI] = []string{}
 				}
@@ -188,8 +349,11 @@ func (psub *PartialSubscription) MarshalBinary() (_ []byte, err error) {
 	// Offset
 	enc.Encode(psub.Offset)
 
-	// ReportURI
-	enc.Encode(psub.ReportURI)
+	// ReportURIs
+	enc.Encode(len(psub.ReportURIs))
+	for _, reportURI := range psub.ReportURIs {
+		enc.Encode(reportURI)
+	}
 
 	// Subset
 	enc.Encode(psub.Subset)
@@ -207,10 +371,19 @@ func (psub *PartialSubscription) UnmarshalBinary(data []byte) (err error) {
 		return
 	}
 
-	// ReportURI
-	if err = dec.Decode(&psub.ReportURI); err != nil {
+	// ReportURIs
+	var reportURIsSize int
+	if err = dec.Decode(&reportURIsSize); err != nil {
 		return
 	}
+	psub.ReportURIs = nil
+	for i := 0; i < reportURIsSize; i++ {
+		var reportURI string
+		if err = dec.Decode(&reportURI); err != nil {
+			return
+		}
+		psub.ReportURIs = append(psub.ReportURIs, reportURI)
+	}
 
 	// Subset
 	if err = dec.Decode(&psub.Subset); err != nil {
@@ -242,17 +415,17 @@ func (bsub ByteSubscriptions) Keys() []string {
 // linkSubset finds subsets and nest them under the parents
 func (bsub ByteSubscriptions) linkSubset() {
 	type element struct {
-		filter    string
-		offset    int
-		reportURI string
+		filter     string
+		offset     int
+		reportURIs []string
 	}
 
 	var elements []*element
 	for _, fs := range bsub.Keys() {
 		elements = append(elements, &element{
-			filter:    fs,
-			offset:    bsub[fs].Offset,
-			reportURI: bsub[fs].ReportURI,
+			filter:     fs,
+			offset:     bsub[fs].Offset,
+			reportURIs: bsub[fs].ReportURIs,
 		})
 	}
 
@@ -267,13 +440,13 @@ func (bsub ByteSubscriptions) linkSubset() {
 				if len(bsub[linkCandidate].Subset) == 0 {
 					bsub[linkCandidate].Subset = ByteSubscriptions{}
 					bsub[linkCandidate].Subset[fs[len(linkCandidate):]] = &PartialSubscription{
-						Offset:    psub.Offset + len(linkCandidate),
-						ReportURI: psub.ReportURI,
+						Offset:     psub.Offset + len(linkCandidate),
+						ReportURIs: psub.ReportURIs,
 					}
 				} else {
 					bsub[linkCandidate].Subset[fs[len(linkCandidate):]] = &PartialSubscription{
-						Offset:    psub.Offset + len(linkCandidate),
-						ReportURI: psub.ReportURI,
+						Offset:     psub.Offset + len(linkCandidate),
+						ReportURIs: psub.ReportURIs,
 					}
 				}
 				// recursively link the subset
@@ -317,9 +490,9 @@ func (bsub ByteSubscriptions) makePatriciaSubset() {
 				// if the commonPrefix itself is a subscription
 				// make this a superset of subscirptions with current commonPrefix
 				superset = &PartialSubscription{
-					Offset:    currentOffset,
-					ReportURI: bsub[fs].ReportURI,
-					Subset:    bsub[fs].Subset,
+					Offset:     currentOffset,
+					ReportURIs: bsub[fs].ReportURIs,
+					Subset:     bsub[fs].Subset,
 				}
 				// delete the superset
 				delete(bsub, fs)
@@ -327,9 +500,9 @@ func (bsub ByteSubscriptions) makePatriciaSubset() {
 				// if this is PartialSubscription is a subset of this commonPrefix
 				// check if this is not a superset?
 				(*sbsub)[fs[len(commonPrefix):]] = &PartialSubscription{
-					Offset:    currentOffset + len(commonPrefix),
-					ReportURI: bsub[fs].ReportURI,
-					Subset:    bsub[fs].Subset,
+					Offset:     currentOffset + len(commonPrefix),
+					ReportURIs: bsub[fs].ReportURIs,
+					Subset:     bsub[fs].Subset,
 				}
 				// delete the subset
 				delete(bsub, fs)
@@ -359,7 +532,7 @@ func (bsub ByteSubscriptions) makePatriciaSubset() {
 // print used for Dump()
 func (bsub ByteSubscriptions) print(writer io.Writer, indent int) {
 	for _, fs := range bsub.Keys() {
-		fmt.Fprintf(writer, "%s--%s %v %s\n", strings.Repeat(" ", indent), fs, bsub[fs].Offset, bsub[fs].ReportURI)
+		fmt.Fprintf(writer, "%s--%s %v %s\n", strings.Repeat(" ", indent), fs, bsub[fs].Offset, strings.Join(distinctReportURIs(bsub[fs].ReportURIs), ","))
 		ss := bsub[fs].Subset
 		if len(ss) != 0 {
 			ss.print(writer, indent+2)
@@ -22,8 +22,8 @@ func TestByteSubscriptions_keys(t *testing.T) {
 		{
 			"0,8",
 			ByteSubscriptions{
-				"0000": &PartialSubscription{0, "0", ByteSubscriptions{}},
-				"1000": &PartialSubscription{0, "8", ByteSubscriptions{}},
+				"0000": &PartialSubscription{0, []string{"0"}, ByteSubscriptions{}},
+				"1000": &PartialSubscription{0, []string{"8"}, ByteSubscriptions{}},
 			},
 			[]string{"0000", "1000"},
 		},
@@ -46,11 +46,11 @@ func TestByteSubscriptions_Dump(t *testing.T) {
 		{
 			"Test Dump ByteSubscriptions",
 			ByteSubscriptions{
-				"0011":         &PartialSubscription{0, "3", ByteSubscriptions{}},
-				"00110011":     &PartialSubscription{0, "3-3", ByteSubscriptions{}},
-				"1111":         &PartialSubscription{0, "15", ByteSubscriptions{}},
-				"00110000":     &PartialSubscription{0, "3-0", ByteSubscriptions{}},
-				"001100110000": &PartialSubscription{0, "3-3-0", ByteSubscriptions{}},
+				"0011":         &PartialSubscription{0, []string{"3"}, ByteSubscriptions{}},
+				"00110011":     &PartialSubscription{0, []string{"3-3"}, ByteSubscriptions{}},
+				"1111":         &PartialSubscription{0, []string{"15"}, ByteSubscriptions{}},
+				"00110000":     &PartialSubscription{0, []string{"3-0"}, ByteSubscriptions{}},
+				"001100110000": &PartialSubscription{0, []string{"3-3-0"}, ByteSubscriptions{}},
 			},
 			"--0011 0 3\n" +
 				"--00110000 0 3-0\n" +
@@ -77,20 +77,20 @@ func TestByteSubscriptions_linkSubset(t *testing.T) {
 		{
 			"Subset linking test for ByteSubscriptions",
 			ByteSubscriptions{
-				"0011":         &PartialSubscription{0, "3", ByteSubscriptions{}},
-				"00110011":     &PartialSubscription{0, "3-3", ByteSubscriptions{}},
-				"1111":         &PartialSubscription{0, "15", ByteSubscriptions{}},
-				"00110000":     &PartialSubscription{0, "3-0", ByteSubscriptions{}},
-				"001100110000": &PartialSubscription{0, "3-3-0", ByteSubscriptions{}},
+				"0011":         &PartialSubscription{0, []string{"3"}, ByteSubscriptions{}},
+				"00110011":     &PartialSubscription{0, []string{"3-3"}, ByteSubscriptions{}},
+				"1111":         &PartialSubscription{0, []string{"15"}, ByteSubscriptions{}},
+				"00110000":     &PartialSubscription{0, []string{"3-0"}, ByteSubscriptions{}},
+				"001100110000": &PartialSubscription{0, []string{"3-3-0"}, ByteSubscriptions{}},
 			},
 			ByteSubscriptions{
-				"0011": &PartialSubscription{0, "3", ByteSubscriptions{
-					"0000": &PartialSubscription{4, "3-0", ByteSubscriptions{}},
-					"0011": &PartialSubscription{4, "3-3", ByteSubscriptions{
-						"0000": &PartialSubscription{8, "3-3-0", ByteSubscriptions{}},
+				"0011": &PartialSubscription{0, []string{"3"}, ByteSubscriptions{
+					"0000": &PartialSubscription{4, []string{"3-0"}, ByteSubscriptions{}},
+					"0011": &PartialSubscription{4, []string{"3-3"}, ByteSubscriptions{
+						"0000": &PartialSubscription{8, []string{"3-3-0"}, ByteSubscriptions{}},
 					}},
 				}},
-				"1111": &PartialSubscription{0, "15", ByteSubscriptions{}},
+				"1111": &PartialSubscription{0, []string{"15"}, ByteSubscriptions{}},
 			},
 		},
 	}
@@ -115,18 +115,38 @@ func TestSubscriptions_ToByteSubscriptions(t *testing.T) {
 			Subscriptions{
 				"http://localhost:8888/grai":  []string{"urn:epc:pat:grai-96:3.123456.1.1"},
 				"http://localhost:8888/17365": []string{"urn:epc:pat:iso17365:25S.UN.ABC.0THANK0YOU0FOR0READING0THIS1"},
-				"http://localhost:8888/giai":  []string{"urn:epc:pat:giai-96:3.02283922192.45325296932379"},
+				"http://localhost:8888/giai":  []string{"urn:epc:pat:giai-96:3.02283922192.1234567"},
 				"http://localhost:8888/17363": []string{"urn:epc:pat:iso17363:7B.MTR"},
 				"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.999203.7757355"},
 				"http://localhost:8888/sscc":  []string{"urn:epc:pat:sscc-96:3.00039579721"},
 			},
 			ByteSubscriptions{
-				"0011000001111011110011111100100011011101100101111000101011":                                        &PartialSubscription{Offset: 0, ReportURI: "http://localhost:8888/sgtin"},
-				"001100010110010000000000010010110111111000001001001":                                               &PartialSubscription{Offset: 0, ReportURI: "http://localhost:8888/sscc"},
-				"001100110111100001111000100100000000000000000000000000000100000000000000000000000000000000000001":  &PartialSubscription{Offset: 0, ReportURI: "http://localhost:8888/grai"},
-				"0011010001100100000100010000010000111100011000100001010010011100100011110001110010001011000011011": &PartialSubscription{Offset: 0, ReportURI: "http://localhost:8888/giai"},
-				"110010110101010011010101001110000001000010000011110000010100001000000001001110001011110000011001001111010101110000000110001111010010110000010010000101000001000100001001001110000111110000010100001000001001010011110001": &PartialSubscription{Offset: 0, ReportURI: "http://localhost:8888/17365"},
-				"110111000010001101010100010010": &PartialSubscription{Offset: 0, ReportURI: "http://localhost:8888/17363"},
+				"0011000001111011110011111100100011011101100101111000101011":                                       &PartialSubscription{Offset: 0, ReportURIs: []string{"http://localhost:8888/sgtin"}},
+				"001100010110010000000000010010110111111000001001001":                                              &PartialSubscription{Offset: 0, ReportURIs: []string{"http://localhost:8888/sscc"}},
+				"001100110111100001111000100100000000000000000000000000000100000000000000000000000000000000000001": &PartialSubscription{Offset: 0, ReportURIs: []string{"http://localhost:8888/grai"}},
+				"001101000110010000010001000001000011110001100010000000000000000000000000000100101101011010000111": &PartialSubscription{Offset: 0, ReportURIs: []string{"http://localhost:8888/giai"}},
+				"110010110101010011010101001110000001000010000011110000010100001000000001001110001011110000011001001111010101110000000110001111010010110000010010000101000001000100001001001110000111110000010100001000001001010011110001": &PartialSubscription{Offset: 0, ReportURIs: []string{"http://localhost:8888/17365"}},
+				"110111000010001101010100010010": &PartialSubscription{Offset: 0, ReportURIs: []string{"http://localhost:8888/17363"}},
+			},
+		},
+		{
+			"exclude pattern",
+			Subscriptions{
+				"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.999203", "!urn:epc:pat:sgtin-96:3.999203.7757355"},
+			},
+			ByteSubscriptions{
+				"0011000001111011110011111100100011":                         &PartialSubscription{Offset: 0, ReportURIs: []string{"http://localhost:8888/sgtin"}},
+				"0011000001111011110011111100100011011101100101111000101011": &PartialSubscription{Offset: 0, ReportURIs: []string{"http://localhost:8888/sgtin"}},
+			},
+		},
+		{
+			"shared pattern",
+			Subscriptions{
+				"http://localhost:8888/b": []string{"urn:epc:pat:sgtin-96:3.999203"},
+				"http://localhost:8888/a": []string{"urn:epc:pat:sgtin-96:3.999203"},
+			},
+			ByteSubscriptions{
+				"0011000001111011110011111100100011": &PartialSubscription{Offset: 0, ReportURIs: []string{"http://localhost:8888/a", "http://localhost:8888/b"}},
 			},
 		},
 	}
@@ -138,7 +158,7 @@ func TestSubscriptions_ToByteSubscriptions(t *testing.T) {
 					t.Errorf("Subscriptions.ToByteSubscriptions() =  want %v", pfs)
 				} else if gotPsub.Offset != psub.Offset {
 					t.Errorf("Subscriptions.ToByteSubscriptions() = %q, want %q", gotPsub, psub)
-				} else if gotPsub.ReportURI != psub.ReportURI {
+				} else if !reflect.DeepEqual(gotPsub.ReportURIs, psub.ReportURIs) {
 					t.Errorf("Subscriptions.ToByteSubscriptions() = %q, want %q", gotPsub, psub)
 				}
 			}
@@ -146,6 +166,130 @@ func TestSubscriptions_ToByteSubscriptions(t *testing.T) {
 	}
 }
 
+func TestSubscriptions_Split(t *testing.T) {
+	tests := []struct {
+		name         string
+		sub          Subscriptions
+		wantIncludes Subscriptions
+		wantExcludes Subscriptions
+	}{
+		{
+			"includes only",
+			Subscriptions{"http://localhost:8888/a": []string{"urn:epc:pat:sgtin-96:3.999203"}},
+			Subscriptions{"http://localhost:8888/a": []string{"urn:epc:pat:sgtin-96:3.999203"}},
+			Subscriptions{},
+		},
+		{
+			"exclude pattern",
+			Subscriptions{"http://localhost:8888/a": []string{"urn:epc:pat:sgtin-96:3.999203", "!urn:epc:pat:sgtin-96:3.999203.7757355"}},
+			Subscriptions{"http://localhost:8888/a": []string{"urn:epc:pat:sgtin-96:3.999203"}},
+			Subscriptions{"http://localhost:8888/a": []string{"urn:epc:pat:sgtin-96:3.999203.7757355"}},
+		},
+		{
+			"marked reportURI",
+			Subscriptions{"!http://localhost:8888/a": []string{"urn:epc:pat:sgtin-96:3.999203"}},
+			Subscriptions{"!http://localhost:8888/a": []string{"urn:epc:pat:sgtin-96:3.999203"}},
+			Subscriptions{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotIncludes, gotExcludes := tt.sub.Split()
+			if !reflect.DeepEqual(gotIncludes, tt.wantIncludes) {
+				t.Errorf("Subscriptions.Split() gotIncludes = %v, want %v", gotIncludes, tt.wantIncludes)
+			}
+			if !reflect.DeepEqual(gotExcludes, tt.wantExcludes) {
+				t.Errorf("Subscriptions.Split() gotExcludes = %v, want %v", gotExcludes, tt.wantExcludes)
+			}
+		})
+	}
+}
+
+func TestSubscriptions_add(t *testing.T) {
+	type args struct {
+		reportURI string
+		pattern   string
+	}
+	tests := []struct {
+		name string
+		sub  Subscriptions
+		args args
+		want bool
+		then Subscriptions
+	}{
+		{
+			"new reportURI",
+			Subscriptions{},
+			args{"http://localhost:8888/sgtin", "urn:epc:pat:sgtin-96:3.999203"},
+			true,
+			Subscriptions{"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.999203"}},
+		},
+		{
+			"existing pattern",
+			Subscriptions{"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.999203"}},
+			args{"http://localhost:8888/sgtin", "urn:epc:pat:sgtin-96:3.999203"},
+			false,
+			Subscriptions{"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.999203"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sub.add(tt.args.reportURI, tt.args.pattern); got != tt.want {
+				t.Errorf("Subscriptions.add() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.sub, tt.then) {
+				t.Errorf("Subscriptions.add() results %v, want %v", tt.sub, tt.then)
+			}
+		})
+	}
+}
+
+func TestSubscriptions_remove(t *testing.T) {
+	type args struct {
+		reportURI string
+		pattern   string
+	}
+	tests := []struct {
+		name string
+		sub  Subscriptions
+		args args
+		want bool
+		then Subscriptions
+	}{
+		{
+			"last pattern",
+			Subscriptions{"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.999203"}},
+			args{"http://localhost:8888/sgtin", "urn:epc:pat:sgtin-96:3.999203"},
+			true,
+			Subscriptions{},
+		},
+		{
+			"one of the patterns",
+			Subscriptions{"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.999203", "urn:epc:pat:sgtin-96:3.999204"}},
+			args{"http://localhost:8888/sgtin", "urn:epc:pat:sgtin-96:3.999203"},
+			true,
+			Subscriptions{"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.999204"}},
+		},
+		{
+			"unknown pattern",
+			Subscriptions{"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.999203"}},
+			args{"http://localhost:8888/sscc", "urn:epc:pat:sgtin-96:3.999203"},
+			false,
+			Subscriptions{"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.999203"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sub.remove(tt.args.reportURI, tt.args.pattern); got != tt.want {
+				t.Errorf("Subscriptions.remove() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.sub, tt.then) {
+				t.Errorf("Subscriptions.remove() results %v, want %v", tt.sub, tt.then)
+			}
+		})
+	}
+}
+
 func TestLoadSubscriptionsFromCSVFile(t *testing.T) {
 	type args struct {
 		f string
@@ -161,7 +305,7 @@ func TestLoadSubscriptionsFromCSVFile(t *testing.T) {
 			Subscriptions{
 				"http://localhost:8888/grai":  []string{"urn:epc:pat:grai-96:3.123456.1.1"},
 				"http://localhost:8888/17365": []string{"urn:epc:pat:iso17365:25S.UN.ABC.0THANK0YOU0FOR0READING0THIS1"},
-				"http://localhost:8888/giai":  []string{"urn:epc:pat:giai-96:3.02283922192.45325296932379"},
+				"http://localhost:8888/giai":  []string{"urn:epc:pat:giai-96:3.02283922192.1234567"},
 				"http://localhost:8888/17363": []string{"urn:epc:pat:iso17363:7B.MTR"},
 				"http://localhost:8888/sgtin": []string{"urn:epc:pat:sgtin-96:3.999203.7757355"},
 				"http://localhost:8888/sscc":  []string{"urn:epc:pat:sscc-96:3.00039579721"},
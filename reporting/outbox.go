@@ -0,0 +1,278 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package reporting
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DeadLetterFile is the name of the file to keep the reports failed to deliver
+const DeadLetterFile = "dead-letter"
+
+// reportURIFile is the name of the file to keep the reportURI of the Outbox
+const reportURIFile = "report-uri"
+
+// reportFileExt is the extension of the queued report files
+const reportFileExt = ".json"
+
+// tmpFileExt is the extension of the report files being written
+const tmpFileExt = ".tmp"
+
+// errOutboxEmpty is returned when there is no report in the Outbox
+var errOutboxEmpty = errors.New("outbox is empty")
+
+// Outbox is a disk-backed FIFO queue of Reports for a reportURI
+// each report is stored in a file named by its sequence number,
+// and the names are indexed in memory to never list the directory again
+type Outbox struct {
+	ReportURI string
+	dir       string
+	mutex     sync.Mutex
+	seq       uint64
+	names     []string
+}
+
+// NewOutbox opens the Outbox for the reportURI under the baseDir
+// the reports left in the directory are kept in order
+func NewOutbox(baseDir string, reportURI string) (*Outbox, error) {
+	ob := &Outbox{
+		ReportURI: reportURI,
+		dir:       filepath.Join(baseDir, outboxDirName(reportURI)),
+	}
+	if _, err := os.Stat(ob.dir); os.IsNotExist(err) {
+		if err = ob.create(baseDir); err != nil {
+			return nil, err
+		}
+	}
+	names, err := ob.list()
+	if err != nil {
+		return nil, err
+	}
+	ob.names = names
+	if len(names) != 0 {
+		ob.seq, _ = strconv.ParseUint(strings.TrimSuffix(names[len(names)-1], reportFileExt), 10, 64)
+	}
+	return ob, nil
+}
+
+// LoadOutboxes opens all the Outboxes under the baseDir
+func LoadOutboxes(baseDir string) ([]*Outbox, error) {
+	entries, err := os.ReadDir(baseDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	outboxes := []*Outbox{}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(baseDir, e.Name(), reportURIFile))
+		if err != nil {
+			continue
+		}
+		ob, err := NewOutbox(baseDir, string(data))
+		if err != nil {
+			return nil, err
+		}
+		outboxes = append(outboxes, ob)
+	}
+	return outboxes, nil
+}
+
+// Len returns the number of reports in the Outbox
+func (ob *Outbox) Len() int {
+	ob.mutex.Lock()
+	defer ob.mutex.Unlock()
+	return len(ob.names)
+}
+
+// Push appends the Report to the tail of the Outbox
+// and returns after the report is on the disk
+func (ob *Outbox) Push(r Report) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	ob.mutex.Lock()
+	defer ob.mutex.Unlock()
+	ob.seq++
+	name := fmt.Sprintf("%020d%s", ob.seq, reportFileExt)
+	// write aside and rename to never leave a partial report in the queue
+	if err = writeFileSync(filepath.Join(ob.dir, name), append(data, '\n')); err != nil {
+		return err
+	}
+	ob.names = append(ob.names, name)
+	return nil
+}
+
+// Peek returns the Report at the head of the Outbox and its file name
+func (ob *Outbox) Peek() (Report, string, error) {
+	ob.mutex.Lock()
+	defer ob.mutex.Unlock()
+	var r Report
+	if len(ob.names) == 0 {
+		return r, "", errOutboxEmpty
+	}
+	name := ob.names[0]
+	data, err := os.ReadFile(filepath.Join(ob.dir, name))
+	if err != nil {
+		return r, name, err
+	}
+	if err = json.Unmarshal(data, &r); err != nil {
+		return r, name, fmt.Errorf("corrupted report %s: %v", name, err)
+	}
+	return r, name, nil
+}
+
+// Remove deletes the report from the Outbox,
+// a crash before the removal reaches the disk only delivers the report again
+func (ob *Outbox) Remove(name string) error {
+	ob.mutex.Lock()
+	defer ob.mutex.Unlock()
+	if err := os.Remove(filepath.Join(ob.dir, name)); err != nil {
+		return err
+	}
+	ob.drop(name)
+	return nil
+}
+
+// DeadLetter moves the report from the Outbox to the tail of the DeadLetterFile
+func (ob *Outbox) DeadLetter(name string) error {
+	ob.mutex.Lock()
+	defer ob.mutex.Unlock()
+	data, err := os.ReadFile(filepath.Join(ob.dir, name))
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(ob.dir, DeadLetterFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	// the report must be in the DeadLetterFile before it leaves the queue
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if err = os.Remove(filepath.Join(ob.dir, name)); err != nil {
+		return err
+	}
+	ob.drop(name)
+	return nil
+}
+
+// create makes the directory of the Outbox with the reportURI in it
+func (ob *Outbox) create(baseDir string) error {
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		return err
+	}
+	// build the directory aside to never leave one without the reportURI
+	tmp, err := os.MkdirTemp(baseDir, filepath.Base(ob.dir)+tmpFileExt)
+	if err != nil {
+		return err
+	}
+	if err = writeFileSync(filepath.Join(tmp, reportURIFile), []byte(ob.ReportURI)); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	if err = os.Rename(tmp, ob.dir); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	return syncDir(baseDir)
+}
+
+// drop removes the name from the index
+func (ob *Outbox) drop(name string) {
+	for i, n := range ob.names {
+		if n == name {
+			ob.names = append(ob.names[:i], ob.names[i+1:]...)
+			return
+		}
+	}
+}
+
+// list returns the file names of the queued reports in order
+// and cleans up the ones left partially written
+func (ob *Outbox) list() ([]string, error) {
+	entries, err := os.ReadDir(ob.dir)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, e := range entries {
+		switch {
+		case e.IsDir():
+		case strings.HasSuffix(e.Name(), reportFileExt):
+			names = append(names, e.Name())
+		case strings.HasSuffix(e.Name(), tmpFileExt):
+			os.Remove(filepath.Join(ob.dir, e.Name()))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// outboxDirName returns the directory name for the reportURI,
+// the hash keeps it in the limit of the file name for any length of the reportURI
+func outboxDirName(reportURI string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(reportURI)))
+}
+
+// writeFileSync writes the data to the file through a temporary one,
+// and flushes both the file and its directory to the disk
+func writeFileSync(name string, data []byte) error {
+	tmp := name + tmpFileExt
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, name); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(name))
+}
+
+// syncDir flushes the entries of the directory to the disk
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	if err = d.Sync(); err != nil {
+		d.Close()
+		return err
+	}
+	return d.Close()
+}
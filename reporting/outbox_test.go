@@ -0,0 +1,133 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package reporting
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOutbox(t *testing.T) {
+	dir := t.TempDir()
+	reportURI := "http://localhost:8888/sgtin"
+	ob, err := NewOutbox(dir, reportURI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = ob.Peek(); err != errOutboxEmpty {
+		t.Fatalf("Outbox.Peek() error = %v, want %v", err, errOutboxEmpty)
+	}
+	for _, id := range []string{"1", "2"} {
+		if err = ob.Push(Report{ReportURI: reportURI, IDs: []string{id}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// reopen the Outbox as after a restart
+	outboxes, err := LoadOutboxes(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outboxes) != 1 || outboxes[0].ReportURI != reportURI {
+		t.Fatalf("LoadOutboxes() = %v, want an Outbox for %s", outboxes, reportURI)
+	}
+	ob = outboxes[0]
+	if err = ob.Push(Report{ReportURI: reportURI, IDs: []string{"3"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := ob.Len(); got != 3 {
+		t.Errorf("Outbox.Len() = %v, want 3", got)
+	}
+
+	got := []string{}
+	for {
+		r, name, err := ob.Peek()
+		if err == errOutboxEmpty {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, r.IDs...)
+		if r.IDs[0] == "2" {
+			err = ob.DeadLetter(name)
+		} else {
+			err = ob.Remove(name)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []string{"1", "2", "3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Outbox.Peek() = %v, want %v", got, want)
+	}
+
+	deadLetter, err := os.ReadFile(filepath.Join(ob.dir, DeadLetterFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"ReportURI":"http://localhost:8888/sgtin","Time":"0001-01-01T00:00:00Z","IDs":["2"]}` + "\n"; string(deadLetter) != want {
+		t.Errorf("DeadLetterFile = %q, want %q", deadLetter, want)
+	}
+}
+
+func TestOutbox_corrupted(t *testing.T) {
+	ob, err := NewOutbox(t.TempDir(), "http://localhost:8888/sgtin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(ob.dir, "00000000000000000001"+reportFileExt), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if ob, err = NewOutbox(filepath.Dir(ob.dir), ob.ReportURI); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = ob.Peek(); err == nil || err == errOutboxEmpty {
+		t.Errorf("Outbox.Peek() error = nil, want an error for a corrupted report")
+	}
+}
+
+func TestOutbox_longReportURI(t *testing.T) {
+	dir := t.TempDir()
+	reportURI := "http://localhost:8888/" + strings.Repeat("a", 300)
+	ob, err := NewOutbox(dir, reportURI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ob.Push(Report{ReportURI: reportURI, IDs: []string{"1"}}); err != nil {
+		t.Fatalf("Outbox.Push() error = %v", err)
+	}
+	outboxes, err := LoadOutboxes(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outboxes) != 1 || outboxes[0].ReportURI != reportURI || outboxes[0].Len() != 1 {
+		t.Errorf("LoadOutboxes() = %v, want an Outbox with 1 report for %s", outboxes, reportURI)
+	}
+}
+
+func TestOutbox_partialReport(t *testing.T) {
+	ob, err := NewOutbox(t.TempDir(), "http://localhost:8888/sgtin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a crash while writing leaves the temporary file only
+	tmp := filepath.Join(ob.dir, "00000000000000000001"+reportFileExt+tmpFileExt)
+	if err = os.WriteFile(tmp, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if ob, err = NewOutbox(filepath.Dir(ob.dir), ob.ReportURI); err != nil {
+		t.Fatal(err)
+	}
+	if got := ob.Len(); got != 0 {
+		t.Errorf("Outbox.Len() = %v, want 0", got)
+	}
+	if _, err = os.Stat(tmp); !os.IsNotExist(err) {
+		t.Errorf("the partial report is left: %v", err)
+	}
+}
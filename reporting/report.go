@@ -0,0 +1,55 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+// Package reporting delivers the filtered events to the reportURIs
+package reporting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PayloadFormat is the format of the report body
+type PayloadFormat string
+
+// Available PayloadFormats
+const (
+	// JSON encodes the report in a JSON object
+	JSON PayloadFormat = "json"
+	// Text lists the IDs separated by newlines
+	Text PayloadFormat = "text"
+)
+
+// PayloadFormats contains the names of the available PayloadFormats
+var PayloadFormats = []string{string(JSON), string(Text)}
+
+// Report contains IDs to deliver to a reportURI
+type Report struct {
+	ReportURI string
+	Time      time.Time
+	// Set is the ALE report set type of the IDs, if from an event cycle
+	Set string `json:",omitempty"`
+	IDs []string
+	// Invalid lists the IDs with a wrong check digit, if flagged
+	Invalid []string `json:",omitempty"`
+}
+
+// Encode returns the payload and its content type in the given format
+func (r *Report) Encode(format PayloadFormat) ([]byte, string, error) {
+	switch format {
+	case JSON:
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(r); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "application/json", nil
+	case Text:
+		return []byte(strings.Join(r.IDs, "\n") + "\n"), "text/plain; charset=utf-8", nil
+	}
+	return nil, "", fmt.Errorf("unknown payload format: %v", format)
+}
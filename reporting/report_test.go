@@ -0,0 +1,83 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package reporting
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReport_Encode(t *testing.T) {
+	r := &Report{
+		ReportURI: "http://localhost:8888/sgtin",
+		Time:      time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC),
+		IDs:       []string{"urn:epc:id:sgtin:12345678.00001.1", "urn:epc:id:sgtin:12345678.00001.2"},
+	}
+	tests := []struct {
+		name            string
+		format          PayloadFormat
+		wantPayload     string
+		wantContentType string
+		wantErr         bool
+	}{
+		{
+			"json",
+			JSON,
+			`{"ReportURI":"http://localhost:8888/sgtin","Time":"2018-01-02T03:04:05Z","IDs":["urn:epc:id:sgtin:12345678.00001.1","urn:epc:id:sgtin:12345678.00001.2"]}` + "\n",
+			"application/json",
+			false,
+		},
+		{
+			"text",
+			Text,
+			"urn:epc:id:sgtin:12345678.00001.1\nurn:epc:id:sgtin:12345678.00001.2\n",
+			"text/plain; charset=utf-8",
+			false,
+		},
+		{"unknown", PayloadFormat("xml"), "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, contentType, err := r.Encode(tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Report.Encode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if string(payload) != tt.wantPayload {
+				t.Errorf("Report.Encode() payload = %q, want %q", payload, tt.wantPayload)
+			}
+			if contentType != tt.wantContentType {
+				t.Errorf("Report.Encode() contentType = %q, want %q", contentType, tt.wantContentType)
+			}
+		})
+	}
+}
+
+func TestReport_Encode_set(t *testing.T) {
+	r := &Report{
+		ReportURI: "http://localhost:8888/door",
+		Time:      time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC),
+		Set:       "ADDITIONS",
+		IDs:       []string{"urn:epc:id:sgtin:12345678.00001.1"},
+	}
+	want := `{"ReportURI":"http://localhost:8888/door","Time":"2018-01-02T03:04:05Z","Set":"ADDITIONS","IDs":["urn:epc:id:sgtin:12345678.00001.1"]}` + "\n"
+	if payload, _, err := r.Encode(JSON); err != nil || string(payload) != want {
+		t.Errorf("Report.Encode() = %q, %v, want %q", payload, err, want)
+	}
+}
+
+func TestReport_Encode_invalid(t *testing.T) {
+	r := &Report{
+		ReportURI: "http://localhost:8888/container",
+		Time:      time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC),
+		IDs:       []string{"urn:epc:id:iso17363:7BABCU1234560", "urn:epc:id:iso17363:7BABCU1234570"},
+		Invalid:   []string{"urn:epc:id:iso17363:7BABCU1234570"},
+	}
+	want := `{"ReportURI":"http://localhost:8888/container","Time":"2018-01-02T03:04:05Z","IDs":["urn:epc:id:iso17363:7BABCU1234560","urn:epc:id:iso17363:7BABCU1234570"],"Invalid":["urn:epc:id:iso17363:7BABCU1234570"]}` + "\n"
+	if payload, _, err := r.Encode(JSON); err != nil || string(payload) != want {
+		t.Errorf("Report.Encode() = %q, %v, want %q", payload, err, want)
+	}
+}
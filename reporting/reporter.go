@@ -0,0 +1,233 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package reporting
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// QueueSize is the number of Reports to hold for each reportURI
+const QueueSize = 128
+
+// RetryPolicy determines how the Reporter retries the failed deliveries
+type RetryPolicy struct {
+	// MaxAttempts is the number of deliveries before giving up a report
+	MaxAttempts int
+	// InitialBackoff is the wait after the first failure, doubled for each failure
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between the retries
+	MaxBackoff time.Duration
+}
+
+// Reporter receives Reports and POSTs them to the reportURIs
+type Reporter struct {
+	ReportChannel chan Report
+	format        PayloadFormat
+	client        *http.Client
+	concurrency   int
+	queues        map[string]chan Report
+	outboxDir     string
+	retry         RetryPolicy
+	outboxes      map[string]*Outbox
+	wakes         map[string]chan struct{}
+}
+
+// NewReporter creates a new instance of Reporter
+// each reportURI gets its own queue with the concurrency number of senders
+func NewReporter(format PayloadFormat, timeout time.Duration, concurrency int) *Reporter {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	rep := &Reporter{
+		ReportChannel: make(chan Report, QueueSize),
+		format:        format,
+		client:        &http.Client{Timeout: timeout},
+		concurrency:   concurrency,
+		queues:        map[string]chan Report{},
+	}
+
+	go rep.run()
+
+	return rep
+}
+
+// NewReporterWithOutbox creates a new instance of Reporter
+// which keeps the Reports in the Outboxes under the outboxDir until delivered,
+// the Reports left from the previous run are replayed in order
+func NewReporterWithOutbox(format PayloadFormat, timeout time.Duration, outboxDir string, retry RetryPolicy) (*Reporter, error) {
+	if retry.MaxAttempts < 1 {
+		retry.MaxAttempts = 1
+	}
+	rep := &Reporter{
+		ReportChannel: make(chan Report, QueueSize),
+		format:        format,
+		client:        &http.Client{Timeout: timeout},
+		concurrency:   1,
+		outboxDir:     outboxDir,
+		retry:         retry,
+		outboxes:      map[string]*Outbox{},
+		wakes:         map[string]chan struct{}{},
+	}
+
+	outboxes, err := LoadOutboxes(outboxDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, ob := range outboxes {
+		if n := ob.Len(); n != 0 {
+			log.Printf("[Reporter] replaying %v reports for %s", n, ob.ReportURI)
+		}
+		rep.startOutbox(ob)
+	}
+
+	go rep.run()
+
+	return rep, nil
+}
+
+// Deliver POSTs the Report to its reportURI
+func (rep *Reporter) Deliver(r Report) error {
+	payload, contentType, err := r.Encode(rep.format)
+	if err != nil {
+		return err
+	}
+	res, err := rep.client.Post(r.ReportURI, contentType, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+	if res.StatusCode < 200 || 300 <= res.StatusCode {
+		return fmt.Errorf("%s responded %s", r.ReportURI, res.Status)
+	}
+	return nil
+}
+
+// run dispatches the Reports from the ReportChannel to the reportURIs
+func (rep *Reporter) run() {
+	for {
+		r, ok := <-rep.ReportChannel
+		if !ok {
+			break
+		}
+		if rep.outboxDir != "" {
+			rep.enqueue(r)
+			continue
+		}
+		queue, ok := rep.queues[r.ReportURI]
+		if !ok {
+			queue = make(chan Report, QueueSize)
+			rep.queues[r.ReportURI] = queue
+			for i := 0; i < rep.concurrency; i++ {
+				go rep.sender(queue)
+			}
+		}
+		select {
+		case queue <- r:
+		default:
+			log.Printf("[Reporter] queue for %s is full, dropping %v IDs", r.ReportURI, len(r.IDs))
+		}
+	}
+	for _, queue := range rep.queues {
+		close(queue)
+	}
+	for _, wake := range rep.wakes {
+		close(wake)
+	}
+	log.Println("[Reporter] ReportChannel closed")
+}
+
+// enqueue stores the Report in the Outbox for its reportURI
+func (rep *Reporter) enqueue(r Report) {
+	ob, ok := rep.outboxes[r.ReportURI]
+	if !ok {
+		var err error
+		ob, err = NewOutbox(rep.outboxDir, r.ReportURI)
+		if err != nil {
+			log.Printf("[Reporter] %v, dropping %v IDs for %s", err, len(r.IDs), r.ReportURI)
+			return
+		}
+		rep.startOutbox(ob)
+	}
+	if err := ob.Push(r); err != nil {
+		log.Printf("[Reporter] %v, dropping %v IDs for %s", err, len(r.IDs), r.ReportURI)
+		return
+	}
+	select {
+	case rep.wakes[r.ReportURI] <- struct{}{}:
+	default:
+	}
+}
+
+// startOutbox registers the Outbox and starts delivering its Reports
+func (rep *Reporter) startOutbox(ob *Outbox) {
+	wake := make(chan struct{}, 1)
+	rep.outboxes[ob.ReportURI] = ob
+	rep.wakes[ob.ReportURI] = wake
+	go rep.drain(ob, wake)
+}
+
+// sender delivers the Reports in the queue
+func (rep *Reporter) sender(queue chan Report) {
+	for r := range queue {
+		if err := rep.Deliver(r); err != nil {
+			log.Printf("[Reporter] %v", err)
+		}
+	}
+}
+
+// drain delivers the Reports in the Outbox one by one,
+// a failed Report is retried with exponential backoff
+// and moved to the DeadLetterFile after the MaxAttempts
+func (rep *Reporter) drain(ob *Outbox, wake chan struct{}) {
+	attempts := 0
+	backoff := rep.retry.InitialBackoff
+	for {
+		r, name, err := ob.Peek()
+		if err == errOutboxEmpty {
+			if _, ok := <-wake; !ok {
+				return
+			}
+			continue
+		}
+		if err == nil {
+			err = rep.Deliver(r)
+			if err == nil {
+				if err = ob.Remove(name); err != nil {
+					log.Printf("[Reporter] %v", err)
+				}
+				attempts = 0
+				backoff = rep.retry.InitialBackoff
+				continue
+			}
+			attempts++
+		} else {
+			// an unreadable report never succeeds
+			attempts = rep.retry.MaxAttempts
+		}
+		if attempts >= rep.retry.MaxAttempts {
+			log.Printf("[Reporter] giving up %s for %s: %v", name, ob.ReportURI, err)
+			if err = ob.DeadLetter(name); err != nil {
+				log.Printf("[Reporter] %v", err)
+				time.Sleep(backoff)
+			}
+			attempts = 0
+			backoff = rep.retry.InitialBackoff
+			continue
+		}
+		log.Printf("[Reporter] %v, retrying in %v", err, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+		if rep.retry.MaxBackoff > 0 && backoff > rep.retry.MaxBackoff {
+			backoff = rep.retry.MaxBackoff
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package reporting
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReporter_Deliver(t *testing.T) {
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ok.Close()
+	ng := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ng.Close()
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+
+	tests := []struct {
+		name      string
+		reportURI string
+		wantErr   bool
+	}{
+		{"2xx", ok.URL, false},
+		{"5xx", ng.URL, true},
+		{"timeout", slow.URL, true},
+		{"unreachable", "http://127.0.0.1:1", true},
+	}
+	rep := NewReporter(Text, 100*time.Millisecond, 1)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := rep.Deliver(Report{ReportURI: tt.reportURI, Time: time.Now(), IDs: []string{"urn:epc:id:sgtin:12345678.00001.1"}})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Reporter.Deliver() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReporter_ReportChannel(t *testing.T) {
+	received := make(chan string, QueueSize)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r.URL.Path + " " + string(body)
+	})
+	blocked := make(chan struct{})
+	stuck := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-blocked
+	}))
+	defer stuck.Close()
+	defer close(blocked)
+	s := httptest.NewServer(handler)
+	defer s.Close()
+
+	rep := NewReporter(Text, time.Second, 1)
+	// a stuck destination must not hold back the others
+	rep.ReportChannel <- Report{ReportURI: stuck.URL, IDs: []string{"0"}}
+	for _, id := range []string{"1", "2", "3"} {
+		rep.ReportChannel <- Report{ReportURI: s.URL + "/a", IDs: []string{id}}
+	}
+
+	got := []string{}
+	for len(got) < 3 {
+		select {
+		case r := <-received:
+			got = append(got, r)
+		case <-time.After(500 * time.Millisecond):
+			t.Fatalf("Reporter delivered %v, want 3 reports", got)
+		}
+	}
+	want := []string{"/a 1\n", "/a 2\n", "/a 3\n"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Reporter delivered %v, want %v", got, want)
+	}
+}
+
+func TestReporterWithOutbox(t *testing.T) {
+	dir := t.TempDir()
+	received := make(chan string, QueueSize)
+	failures := 2
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) == "fail\n" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received <- string(body)
+	}))
+	defer s.Close()
+
+	// reports left from the previous run
+	ob, err := NewOutbox(dir, s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"1", "fail", "2"} {
+		ob.Push(Report{ReportURI: s.URL, IDs: []string{id}})
+	}
+
+	retry := RetryPolicy{MaxAttempts: 3, InitialBackoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond}
+	rep, err := NewReporterWithOutbox(Text, time.Second, dir, retry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep.ReportChannel <- Report{ReportURI: s.URL, IDs: []string{"3"}}
+
+	got := []string{}
+	for len(got) < 3 {
+		select {
+		case r := <-received:
+			got = append(got, r)
+		case <-time.After(time.Second):
+			t.Fatalf("Reporter delivered %v, want 3 reports", got)
+		}
+	}
+	if want := []string{"1\n", "2\n", "3\n"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Reporter delivered %v, want %v", got, want)
+	}
+	// the report is removed from the disk after the delivery
+	for i := 0; ; i++ {
+		if ob, err = NewOutbox(dir, s.URL); err != nil {
+			t.Fatal(err)
+		}
+		if ob.Len() == 0 {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("Outbox.Len() = %v, want 0", ob.Len())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(rep.ReportChannel)
+	deadLetter, err := os.ReadFile(filepath.Join(ob.dir, DeadLetterFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(deadLetter), `"IDs":["fail"]`) {
+		t.Errorf("DeadLetterFile = %q, want the failed report", deadLetter)
+	}
+}
@@ -28,6 +28,8 @@ const (
 	ATDigits
 	IARBits
 	IARDigits
+	LRBits
+	LRDigits
 )
 
 // GIAI96PartitionTable is PT for GIAI
@@ -41,7 +43,18 @@ var GIAI96PartitionTable = PartitionTable{
 	6:  {PValue: 6, CPBits: 20, IARBits: 62, IARDigits: 19},
 }
 
-// GRAI96PartitionTable is PT for GRAI
+// GIAI202PartitionTable is PT for GIAI-202, where IARDigits is the maximum number of characters
+var GIAI202PartitionTable = PartitionTable{
+	12: {PValue: 0, CPBits: 40, IARBits: 148, IARDigits: 18},
+	11: {PValue: 1, CPBits: 37, IARBits: 151, IARDigits: 19},
+	10: {PValue: 2, CPBits: 34, IARBits: 154, IARDigits: 20},
+	9:  {PValue: 3, CPBits: 30, IARBits: 158, IARDigits: 21},
+	8:  {PValue: 4, CPBits: 27, IARBits: 161, IARDigits: 22},
+	7:  {PValue: 5, CPBits: 24, IARBits: 164, IARDigits: 23},
+	6:  {PValue: 6, CPBits: 20, IARBits: 168, IARDigits: 24},
+}
+
+// GRAI96PartitionTable is PT for GRAI, also used for GRAI-170
 var GRAI96PartitionTable = PartitionTable{
 	12: {PValue: 0, CPBits: 40, ATBits: 4, ATDigits: 0},
 	11: {PValue: 1, CPBits: 37, ATBits: 7, ATDigits: 1},
@@ -52,6 +65,17 @@ var GRAI96PartitionTable = PartitionTable{
 	6:  {PValue: 6, CPBits: 20, ATBits: 24, ATDigits: 6},
 }
 
+// SGLN96PartitionTable is PT for SGLN, also used for SGLN-195
+var SGLN96PartitionTable = PartitionTable{
+	12: {PValue: 0, CPBits: 40, LRBits: 1, LRDigits: 0},
+	11: {PValue: 1, CPBits: 37, LRBits: 4, LRDigits: 1},
+	10: {PValue: 2, CPBits: 34, LRBits: 7, LRDigits: 2},
+	9:  {PValue: 3, CPBits: 30, LRBits: 11, LRDigits: 3},
+	8:  {PValue: 4, CPBits: 27, LRBits: 14, LRDigits: 4},
+	7:  {PValue: 5, CPBits: 24, LRBits: 17, LRDigits: 5},
+	6:  {PValue: 6, CPBits: 20, LRBits: 21, LRDigits: 6},
+}
+
 // SGTIN96PartitionTable is PT for SGTIN
 var SGTIN96PartitionTable = PartitionTable{
 	12: {PValue: 0, CPBits: 40, IRBits: 4, IRDigits: 1},
@@ -74,6 +98,18 @@ var SSCC96PartitionTable = PartitionTable{
 	6:  {PValue: 6, CPBits: 20, EBits: 38, EDigits: 11},
 }
 
+// GetAlphanumericSerial converts an alphanumeric serial to 7-bit characters in rune slice
+// padded with zeros to the serialLength
+func GetAlphanumericSerial(s string, serialLength int) (serial []rune) {
+	if s == "" {
+		s = binutil.GenerateNLengthAlphanumericString(binutil.GenerateRandomInt(1, serialLength/7))
+	}
+	for i := 0; i < len(s) && len(serial)+7 <= serialLength; i++ {
+		serial = append(serial, []rune(fmt.Sprintf("%.7b", s[i]))...)
+	}
+	return append(serial, binutil.GenerateNLengthZeroPaddingRuneSlice(serialLength-len(serial))...)
+}
+
 // GetAssetType returns Asset Type as rune slice
 func GetAssetType(at string, pr map[PartitionTableKey]int) (assetType []rune) {
 	if at != "" {
@@ -155,6 +191,22 @@ func GetItemReference(ir string, pr map[PartitionTableKey]int) (itemReference []
 	return
 }
 
+// GetLocationReference converts LocationReference value to rune slice
+func GetLocationReference(lr string, pr map[PartitionTableKey]int) (locationReference []rune) {
+	if lr != "" {
+		locationReference = binutil.ParseDecimalStringToBinRuneSlice(lr)
+		if pr[LRBits] > len(locationReference) {
+			leftPadding := binutil.GenerateNLengthZeroPaddingRuneSlice(pr[LRBits] - len(locationReference))
+			locationReference = append(leftPadding, locationReference...)
+		}
+	} else if pr[LRDigits] == 0 {
+		locationReference = binutil.GenerateNLengthZeroPaddingRuneSlice(pr[LRBits])
+	} else {
+		locationReference, _ = binutil.GenerateNLengthRandomBinRuneSlice(pr[LRBits], uint(math.Pow(float64(10), float64(pr[LRDigits]))))
+	}
+	return
+}
+
 // GetSerial converts serial to rune slice
 func GetSerial(s string, serialLength int) (serial []rune) {
 	if s != "" {
@@ -230,6 +282,50 @@ func MakeGIAI96(pf bool, fv string, cp string, iar string) ([]byte, string, stri
 	return binutil.Pack(giai96), "", "", nil
 }
 
+// MakeGIAI202 generates GIAI-202
+func MakeGIAI202(pf bool, fv string, cp string, iar string) ([]byte, string, string, error) {
+	filter := GetFilter(fv)
+	// CP
+	if cp == "" {
+		if pf {
+			return []byte{}, "00111000" + string(filter), "urn:epc:pat:giai-202:" + fv, nil
+		}
+		return []byte{}, "", "", errors.New("companyPrefix is empty")
+	}
+	companyPrefix := GetCompanyPrefix(cp, GIAI202PartitionTable)
+	partition := []rune(fmt.Sprintf("%.3b", GIAI202PartitionTable[len(cp)][PValue]))
+
+	// IAR
+	if iar == "" {
+		if pf {
+			return []byte{}, "00111000" + string(filter) + string(partition) + string(companyPrefix), "urn:epc:pat:giai-202:" + fv + "." + cp, nil
+		}
+	}
+	indivisualAssetReference := GetAlphanumericSerial(iar, GIAI202PartitionTable[len(cp)][IARBits])
+
+	// Exact match
+	if pf {
+		return []byte{}, "00111000" + string(filter) + string(partition) + string(companyPrefix) + string(indivisualAssetReference), "urn:epc:pat:giai-202:" + fv + "." + cp + "." + iar, nil
+	}
+
+	bs := append(filter, partition...)
+	bs = append(bs, companyPrefix...)
+	bs = append(bs, indivisualAssetReference...)
+
+	if len(bs) != 194 {
+		return []byte{}, "", "", fmt.Errorf("len(bs): %v, want 194", len(bs))
+	}
+	// pad to the word boundary
+	bs = append(bs, binutil.GenerateNLengthZeroPaddingRuneSlice(6)...)
+
+	p, err := binutil.ParseBinRuneSliceToUint8Slice(bs)
+	if err != nil {
+		return []byte{}, "", "", err
+	}
+
+	return append([]byte{uint8(56)}, p...), "", "", nil // GIAI-202 Header 0011 1000
+}
+
 // MakeGRAI96 generates GRAI-96
 func MakeGRAI96(pf bool, fv string, cp string, at string, ser string) ([]byte, string, string, error) {
 	filter := GetFilter(fv)
@@ -297,6 +393,178 @@ func MakeGRAI96(pf bool, fv string, cp string, at string, ser string) ([]byte, s
 	return binutil.Pack(grai96), "", "", nil
 }
 
+// MakeGRAI170 generates GRAI-170
+func MakeGRAI170(pf bool, fv string, cp string, at string, ser string) ([]byte, string, string, error) {
+	filter := GetFilter(fv)
+	// CP
+	if cp == "" {
+		if pf {
+			return []byte{}, "00110111" + string(filter), "urn:epc:pat:grai-170:" + fv, nil
+		}
+		return []byte{}, "", "", errors.New("companyPrefix is empty")
+	}
+	companyPrefix := GetCompanyPrefix(cp, GRAI96PartitionTable)
+	partition := []rune(fmt.Sprintf("%.3b", GRAI96PartitionTable[len(cp)][PValue]))
+
+	// AT
+	if at == "" && GRAI96PartitionTable[len(cp)][ATDigits] != 0 {
+		if pf {
+			return []byte{}, "00110111" + string(filter) + string(partition) + string(companyPrefix), "urn:epc:pat:grai-170:" + fv + "." + cp, nil
+		}
+	}
+	assetType := GetAssetType(at, GRAI96PartitionTable[len(cp)])
+
+	// SER
+	if ser == "" {
+		if pf {
+			return []byte{}, "00110111" + string(filter) + string(partition) + string(companyPrefix) + string(assetType), "urn:epc:pat:grai-170:" + fv + "." + cp + "." + at, nil
+		}
+	}
+	serial := GetAlphanumericSerial(ser, 112)
+
+	// Exact match
+	if pf {
+		return []byte{}, "00110111" + string(filter) + string(partition) + string(companyPrefix) + string(assetType) + string(serial), "urn:epc:pat:grai-170:" + fv + "." + cp + "." + at + "." + ser, nil
+	}
+
+	bs := append(filter, partition...)
+	bs = append(bs, companyPrefix...)
+	bs = append(bs, assetType...)
+	bs = append(bs, serial...)
+
+	if len(bs) != 162 {
+		return []byte{}, "", "", fmt.Errorf("len(bs): %v, want 162", len(bs))
+	}
+	// pad to the word boundary
+	bs = append(bs, binutil.GenerateNLengthZeroPaddingRuneSlice(6)...)
+
+	p, err := binutil.ParseBinRuneSliceToUint8Slice(bs)
+	if err != nil {
+		return []byte{}, "", "", err
+	}
+
+	return append([]byte{uint8(55)}, p...), "", "", nil // GRAI-170 Header 0011 0111
+}
+
+// MakeSGLN96 generates SGLN-96
+func MakeSGLN96(pf bool, fv string, cp string, lr string, ext string) ([]byte, string, string, error) {
+	filter := GetFilter(fv)
+	// CP
+	if cp == "" {
+		if pf {
+			return []byte{}, "00110010" + string(filter), "urn:epc:pat:sgln-96:" + fv, nil
+		}
+		return []byte{}, "", "", errors.New("companyPrefix is empty")
+	}
+	companyPrefix := GetCompanyPrefix(cp, SGLN96PartitionTable)
+	partition := []rune(fmt.Sprintf("%.3b", SGLN96PartitionTable[len(cp)][PValue]))
+
+	// LR
+	if lr == "" && SGLN96PartitionTable[len(cp)][LRDigits] != 0 {
+		if pf {
+			return []byte{}, "00110010" + string(filter) + string(partition) + string(companyPrefix), "urn:epc:pat:sgln-96:" + fv + "." + cp, nil
+		}
+	}
+	locationReference := GetLocationReference(lr, SGLN96PartitionTable[len(cp)])
+
+	// EXT
+	if ext == "" {
+		if pf {
+			return []byte{}, "00110010" + string(filter) + string(partition) + string(companyPrefix) + string(locationReference), "urn:epc:pat:sgln-96:" + fv + "." + cp + "." + lr, nil
+		}
+	}
+	extension := GetSerial(ext, 41)
+
+	// Exact match
+	if pf {
+		return []byte{}, "00110010" + string(filter) + string(partition) + string(companyPrefix) + string(locationReference) + string(extension), "urn:epc:pat:sgln-96:" + fv + "." + cp + "." + lr + "." + ext, nil
+	}
+
+	bs := append(filter, partition...)
+	bs = append(bs, companyPrefix...)
+	bs = append(bs, locationReference...)
+	bs = append(bs, extension...)
+
+	if len(bs) != 88 {
+		return []byte{}, "", "", fmt.Errorf("len(bs): %v, want 88", len(bs))
+	}
+
+	p, err := binutil.ParseBinRuneSliceToUint8Slice(bs)
+	if err != nil {
+		return []byte{}, "", "", err
+	}
+
+	var sgln96 = []interface{}{
+		uint8(50), // SGLN-96 Header 0011 0010
+		p[0],      // 8 bits -> 16 bits
+		p[1],      // 8 bits -> 24 bits
+		p[2],      // 8 bits -> 32 bits
+		p[3],      // 8 bits -> 40 bits
+		p[4],      // 8 bits -> 48 bits
+		p[5],      // 8 bits -> 56 bits
+		p[6],      // 8 bits -> 64 bits
+		p[7],      // 8 bits -> 72 bits
+		p[8],      // 8 bits -> 80 bits
+		p[9],      // 8 bits -> 88 bits
+		p[10],     // 8 bits -> 96 bits
+	}
+
+	return binutil.Pack(sgln96), "", "", nil
+}
+
+// MakeSGLN195 generates SGLN-195 padded to 208 bits
+func MakeSGLN195(pf bool, fv string, cp string, lr string, ext string) ([]byte, string, string, error) {
+	filter := GetFilter(fv)
+	// CP
+	if cp == "" {
+		if pf {
+			return []byte{}, "00111001" + string(filter), "urn:epc:pat:sgln-195:" + fv, nil
+		}
+		return []byte{}, "", "", errors.New("companyPrefix is empty")
+	}
+	companyPrefix := GetCompanyPrefix(cp, SGLN96PartitionTable)
+	partition := []rune(fmt.Sprintf("%.3b", SGLN96PartitionTable[len(cp)][PValue]))
+
+	// LR
+	if lr == "" && SGLN96PartitionTable[len(cp)][LRDigits] != 0 {
+		if pf {
+			return []byte{}, "00111001" + string(filter) + string(partition) + string(companyPrefix), "urn:epc:pat:sgln-195:" + fv + "." + cp, nil
+		}
+	}
+	locationReference := GetLocationReference(lr, SGLN96PartitionTable[len(cp)])
+
+	// EXT
+	if ext == "" {
+		if pf {
+			return []byte{}, "00111001" + string(filter) + string(partition) + string(companyPrefix) + string(locationReference), "urn:epc:pat:sgln-195:" + fv + "." + cp + "." + lr, nil
+		}
+	}
+	extension := GetAlphanumericSerial(ext, 140)
+
+	// Exact match
+	if pf {
+		return []byte{}, "00111001" + string(filter) + string(partition) + string(companyPrefix) + string(locationReference) + string(extension), "urn:epc:pat:sgln-195:" + fv + "." + cp + "." + lr + "." + ext, nil
+	}
+
+	bs := append(filter, partition...)
+	bs = append(bs, companyPrefix...)
+	bs = append(bs, locationReference...)
+	bs = append(bs, extension...)
+
+	if len(bs) != 187 {
+		return []byte{}, "", "", fmt.Errorf("len(bs): %v, want 187", len(bs))
+	}
+	// pad to the word boundary
+	bs = append(bs, binutil.GenerateNLengthZeroPaddingRuneSlice(13)...)
+
+	p, err := binutil.ParseBinRuneSliceToUint8Slice(bs)
+	if err != nil {
+		return []byte{}, "", "", err
+	}
+
+	return append([]byte{uint8(57)}, p...), "", "", nil // SGLN-195 Header 0011 1001
+}
+
 // MakeSGTIN96 generates SGTIN-96
 func MakeSGTIN96(pf bool, fv string, cp string, ir string, ser string) ([]byte, string, string, error) {
 	filter := GetFilter(fv)
@@ -276,6 +276,170 @@ func TestMakeGRAI96(t *testing.T) {
 	}
 }
 
+func TestMakeGIAI202(t *testing.T) {
+	type args struct {
+		pf  bool
+		fv  string
+		cp  string
+		iar string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []byte
+		want1   string
+		want2   string
+		wantErr bool
+	}{
+		{"3874257BF59B2C2BF1", args{false, "3", "0614141", "32a/b"}, []byte{56, 116, 37, 123, 245, 155, 44, 43, 241, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, "", "", false},
+		{"3874257BF59B2C2BF1", args{true, "3", "0614141", ""}, []byte{}, "00111000011101000010010101111011111101", "urn:epc:pat:giai-202:3.0614141", false},
+		{"3874257BF59B2C2BF1", args{true, "3", "", ""}, []byte{}, "00111000011", "urn:epc:pat:giai-202:3", false},
+		{"3874257BF59B2C2BF1", args{false, "3", "", ""}, []byte{}, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, got1, got2, err := MakeGIAI202(tt.args.pf, tt.args.fv, tt.args.cp, tt.args.iar)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MakeGIAI202() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MakeGIAI202() got = %v, want %v", got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("MakeGIAI202() got1 = %v, want %v", got1, tt.want1)
+			}
+			if got2 != tt.want2 {
+				t.Errorf("MakeGIAI202() got2 = %v, want %v", got2, tt.want2)
+			}
+		})
+	}
+}
+
+func TestMakeGRAI170(t *testing.T) {
+	type args struct {
+		pf  bool
+		fv  string
+		cp  string
+		at  string
+		ser string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []byte
+		want1   string
+		want2   string
+		wantErr bool
+	}{
+		{"3774257BF40C0E59B2C2BF1", args{false, "3", "0614141", "12345", "32a/b"}, []byte{55, 116, 37, 123, 244, 12, 14, 89, 178, 194, 191, 16, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, "", "", false},
+		{"3774257BF40C0E59B2C2BF1", args{true, "3", "0614141", "12345", ""}, []byte{}, "0011011101110100001001010111101111110100000011000000111001", "urn:epc:pat:grai-170:3.0614141.12345", false},
+		{"3774257BF40C0E59B2C2BF1", args{true, "3", "0614141", "", ""}, []byte{}, "00110111011101000010010101111011111101", "urn:epc:pat:grai-170:3.0614141", false},
+		{"3774257BF40C0E59B2C2BF1", args{false, "3", "", "", ""}, []byte{}, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, got1, got2, err := MakeGRAI170(tt.args.pf, tt.args.fv, tt.args.cp, tt.args.at, tt.args.ser)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MakeGRAI170() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MakeGRAI170() got = %v, want %v", got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("MakeGRAI170() got1 = %v, want %v", got1, tt.want1)
+			}
+			if got2 != tt.want2 {
+				t.Errorf("MakeGRAI170() got2 = %v, want %v", got2, tt.want2)
+			}
+		})
+	}
+}
+
+func TestMakeSGLN96(t *testing.T) {
+	type args struct {
+		pf  bool
+		fv  string
+		cp  string
+		lr  string
+		ext string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []byte
+		want1   string
+		want2   string
+		wantErr bool
+	}{
+		{"3274257BF460720000000190", args{false, "3", "0614141", "12345", "400"}, []byte{50, 116, 37, 123, 244, 96, 114, 0, 0, 0, 1, 144}, "", "", false},
+		{"3274257BF460720000000190", args{true, "3", "0614141", "12345", "400"}, []byte{}, "001100100111010000100101011110111111010001100000011100100000000000000000000000000000000110010000", "urn:epc:pat:sgln-96:3.0614141.12345.400", false},
+		{"3274257BF460720000000190", args{true, "3", "0614141", "12345", ""}, []byte{}, "0011001001110100001001010111101111110100011000000111001", "urn:epc:pat:sgln-96:3.0614141.12345", false},
+		{"3274257BF460720000000190", args{true, "3", "0614141", "", ""}, []byte{}, "00110010011101000010010101111011111101", "urn:epc:pat:sgln-96:3.0614141", false},
+		{"32200B7F7070D40000000000", args{false, "1", "012345678901", "", "0"}, []byte{50, 32, 11, 127, 112, 112, 212, 0, 0, 0, 0, 0}, "", "", false},
+		{"32200B7F7070D40000000000", args{true, "1", "012345678901", "", ""}, []byte{}, "0011001000100000000010110111111101110000011100001101010", "urn:epc:pat:sgln-96:1.012345678901.", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, got1, got2, err := MakeSGLN96(tt.args.pf, tt.args.fv, tt.args.cp, tt.args.lr, tt.args.ext)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MakeSGLN96() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MakeSGLN96() got = %v, want %v", got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("MakeSGLN96() got1 = %v, want %v", got1, tt.want1)
+			}
+			if got2 != tt.want2 {
+				t.Errorf("MakeSGLN96() got2 = %v, want %v", got2, tt.want2)
+			}
+		})
+	}
+}
+
+func TestMakeSGLN195(t *testing.T) {
+	type args struct {
+		pf  bool
+		fv  string
+		cp  string
+		lr  string
+		ext string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []byte
+		want1   string
+		want2   string
+		wantErr bool
+	}{
+		{"3974257BF46072CD9615F880", args{false, "3", "0614141", "12345", "32a/b"}, []byte{57, 116, 37, 123, 244, 96, 114, 205, 150, 21, 248, 128, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, "", "", false},
+		{"3974257BF46072CD9615F880", args{true, "3", "0614141", "12345", ""}, []byte{}, "0011100101110100001001010111101111110100011000000111001", "urn:epc:pat:sgln-195:3.0614141.12345", false},
+		{"3974257BF46072CD9615F880", args{true, "3", "", "", ""}, []byte{}, "00111001011", "urn:epc:pat:sgln-195:3", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, got1, got2, err := MakeSGLN195(tt.args.pf, tt.args.fv, tt.args.cp, tt.args.lr, tt.args.ext)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MakeSGLN195() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MakeSGLN195() got = %v, want %v", got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("MakeSGLN195() got1 = %v, want %v", got1, tt.want1)
+			}
+			if got2 != tt.want2 {
+				t.Errorf("MakeSGLN195() got2 = %v, want %v", got2, tt.want2)
+			}
+		})
+	}
+}
+
 func TestMakeSGTIN96(t *testing.T) {
 	type args struct {
 		pf  bool
@@ -21,8 +21,9 @@ func CheckIfStringInSlice(a string, list []string) bool {
 	return false
 }
 
-// MakeEPC generates EPC in binary string and PC in hex string or prefix and elements
-func MakeEPC(pf bool, cs string, fv string, cp string, ir string, ext string, ser string, iar string, at string) (string, string) {
+// MakeEPC generates EPC in binary string and PC in hex string or prefix and elements,
+// ext is the extension and lr is the location reference for SGLN
+func MakeEPC(pf bool, cs string, fv string, cp string, ir string, ext string, ser string, iar string, at string, lr string) (string, string) {
 	var uii []byte
 	var f string
 	var elem string
@@ -30,8 +31,16 @@ func MakeEPC(pf bool, cs string, fv string, cp string, ir string, ext string, se
 	switch strings.ToUpper(cs) {
 	case "GIAI-96":
 		uii, f, elem, _ = MakeGIAI96(pf, fv, cp, iar)
+	case "GIAI-202":
+		uii, f, elem, _ = MakeGIAI202(pf, fv, cp, iar)
 	case "GRAI-96":
 		uii, f, elem, _ = MakeGRAI96(pf, fv, cp, at, ser)
+	case "GRAI-170":
+		uii, f, elem, _ = MakeGRAI170(pf, fv, cp, at, ser)
+	case "SGLN-96":
+		uii, f, elem, _ = MakeSGLN96(pf, fv, cp, lr, ext)
+	case "SGLN-195":
+		uii, f, elem, _ = MakeSGLN195(pf, fv, cp, lr, ext)
 	case "SGTIN-96":
 		uii, f, elem, _ = MakeSGTIN96(pf, fv, cp, ir, ser)
 	case "SSCC-96":
@@ -43,11 +52,7 @@ func MakeEPC(pf bool, cs string, fv string, cp string, ir string, ext string, se
 		return f, elem
 	}
 
-	// TODO: update pc when length changed (for non-96-bit codes)
-	pc := binutil.Pack([]interface{}{
-		uint8(48), // L4-0=11000(6words=96bits), UMI=0, XI=0
-		uint8(0),  // RFU=0
-	})
+	pc := MakeEPCPC(len(uii) * 8)
 
 	uiibs, _ := binutil.ParseHexStringToBinString(hex.EncodeToString(uii))
 
@@ -71,10 +76,22 @@ func MakeISO(pf bool, std string, oc string, ei string, csn string, di string, i
 		afi := "A9" // 0xA9 ISO 17363 freight containers
 		uii, length, f, elem, _ = MakeISO17363(pf, oc, ei, csn)
 		pc = MakeISOPC(length, afi)
+	case "17364":
+		afi := "A3" // 0xA3 ISO 17364 returnable transport items
+		uii, length, f, elem, _ = MakeISO17364(pf, di, iac, cin, sn)
+		pc = MakeISOPC(length, afi)
 	case "17365":
 		afi := "A2" // 0xA2 ISO 17365 transport uit
 		uii, length, f, elem, _ = MakeISO17365(pf, di, iac, cin, sn)
 		pc = MakeISOPC(length, afi)
+	case "17366":
+		afi := "A5" // 0xA5 ISO 17366 product packaging
+		uii, length, f, elem, _ = MakeISO17366(pf, di, iac, cin, sn)
+		pc = MakeISOPC(length, afi)
+	case "17367":
+		afi := "A1" // 0xA1 ISO 17367 product tagging
+		uii, length, f, elem, _ = MakeISO17367(pf, di, iac, cin, sn)
+		pc = MakeISOPC(length, afi)
 	}
 
 	// If only prefix flag is on, return prefix as iso uii
@@ -94,6 +111,14 @@ func MakeISO(pf bool, std string, oc string, ei string, csn string, di string, i
 	*/
 }
 
+// MakeEPCPC returns PC bits in []byte for the EPC of the length in bits
+func MakeEPCPC(length int) []byte {
+	return binutil.Pack([]interface{}{
+		uint8((length + 15) / 16 << 3), // L4-0=words, UMI=0, XI=0
+		uint8(0),                       // T=0, RFU=0
+	})
+}
+
 // MakeISOPC returns PC bits in []byte
 func MakeISOPC(length int, afi string) []byte {
 	l := []rune(fmt.Sprintf("%.5b", length/16))
@@ -137,7 +162,7 @@ func PrintID(bs string, opt string) string {
 }
 
 /*
-	bs, opt = MakeEPC(*prefixFilter, *epcScheme, *epcFilter, *epcCompanyPrefix, *epcItemReference, *epcExtension, *epcSerial, *epcIndivisualAssetReference, *epcAssetType)
+	bs, opt = MakeEPC(*prefixFilter, *epcScheme, *epcFilter, *epcCompanyPrefix, *epcItemReference, *epcExtension, *epcSerial, *epcIndivisualAssetReference, *epcAssetType, *epcLocationReference)
 	bs, opt = MakeISO(*prefixFilter, "17363", *isoOwnerCode, *isoEquipmentCategoryIdentifier, *isoContainerSerialNumber, *isoDataIdeintifier, *isoIssuingAgencyCode, *isoCompanyIdentification, *isoSerialNumber)
 	bs, opt = MakeISO(*prefixFilter, "17365", *isoOwnerCode, *isoEquipmentCategoryIdentifier, *isoContainerSerialNumber, *isoDataIdeintifier, *isoIssuingAgencyCode, *isoCompanyIdentification, *isoSerialNumber)
 */
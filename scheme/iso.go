@@ -87,14 +87,34 @@ func MakeISO17363(pf bool, oc string, ei string, csn string) ([]byte, int, strin
 	return binutil.Pack(iso17363), length, "", "", nil
 }
 
-// MakeISO17365 generates a random 17367 code
+// MakeISO17364 generates a random 17364 code
+func MakeISO17364(pf bool, di string, iac string, cin string, sn string) ([]byte, int, string, string, error) {
+	return makeISO15459(pf, "17364", di, iac, cin, sn)
+}
+
+// MakeISO17365 generates a random 17365 code
 func MakeISO17365(pf bool, di string, iac string, cin string, sn string) ([]byte, int, string, string, error) {
+	return makeISO15459(pf, "17365", di, iac, cin, sn)
+}
+
+// MakeISO17366 generates a random 17366 code
+func MakeISO17366(pf bool, di string, iac string, cin string, sn string) ([]byte, int, string, string, error) {
+	return makeISO15459(pf, "17366", di, iac, cin, sn)
+}
+
+// MakeISO17367 generates a random 17367 code
+func MakeISO17367(pf bool, di string, iac string, cin string, sn string) ([]byte, int, string, string, error) {
+	return makeISO15459(pf, "17367", di, iac, cin, sn)
+}
+
+// makeISO15459 generates a random code of the ISO/IEC 15459 unique identifier in the standard
+func makeISO15459(pf bool, std string, di string, iac string, cin string, sn string) ([]byte, int, string, string, error) {
 	dataIdentifier := binutil.ParseRuneSliceTo6BinRuneSlice([]rune(di))
 
 	// IAC
 	if iac == "" {
 		if pf {
-			return []byte{}, 0, string(dataIdentifier), "urn:epc:pat:iso17365:" + di, nil
+			return []byte{}, 0, string(dataIdentifier), "urn:epc:pat:iso" + std + ":" + di, nil
 		}
 		return []byte{}, 0, "", "", errors.New("IAC not provided")
 	}
@@ -103,7 +123,7 @@ func MakeISO17365(pf bool, di string, iac string, cin string, sn string) ([]byte
 	// CIN
 	if cin == "" {
 		if pf {
-			return []byte{}, 0, string(dataIdentifier) + string(issuingAgencyCode), "urn:epc:pat:iso17365:" + di + "." + iac, nil
+			return []byte{}, 0, string(dataIdentifier) + string(issuingAgencyCode), "urn:epc:pat:iso" + std + ":" + di + "." + iac, nil
 		}
 		return []byte{}, 0, "", "", errors.New("CIN not provided")
 	}
@@ -112,7 +132,7 @@ func MakeISO17365(pf bool, di string, iac string, cin string, sn string) ([]byte
 	// SN
 	if sn == "" {
 		if pf {
-			return []byte{}, 0, string(dataIdentifier) + string(issuingAgencyCode) + string(companyIdentification), "urn:epc:pat:iso17365:" + di + "." + iac + "." + cin, nil
+			return []byte{}, 0, string(dataIdentifier) + string(issuingAgencyCode) + string(companyIdentification), "urn:epc:pat:iso" + std + ":" + di + "." + iac + "." + cin, nil
 		}
 		sn = binutil.GenerateNLengthHexString(18)
 	}
@@ -120,7 +140,7 @@ func MakeISO17365(pf bool, di string, iac string, cin string, sn string) ([]byte
 
 	// Exact match filter
 	if pf {
-		return []byte{}, 0, string(dataIdentifier) + string(issuingAgencyCode) + string(companyIdentification) + string(serialNumber), "urn:epc:pat:iso17365:" + di + "." + iac + "." + cin + "." + sn, nil
+		return []byte{}, 0, string(dataIdentifier) + string(issuingAgencyCode) + string(companyIdentification) + string(serialNumber), "urn:epc:pat:iso" + std + ":" + di + "." + iac + "." + cin + "." + sn, nil
 	}
 
 	bs := append(dataIdentifier, issuingAgencyCode...)
@@ -135,9 +155,9 @@ func MakeISO17365(pf bool, di string, iac string, cin string, sn string) ([]byte
 		return []byte{}, 0, "", "", err
 	}
 
-	var iso17365 = []interface{}{p}
+	var iso15459 = []interface{}{p}
 
-	return binutil.Pack(iso17365), length, "", "", nil
+	return binutil.Pack(iso15459), length, "", "", nil
 }
 
 // Pad6BitEncodingRuneSlice returns a new length
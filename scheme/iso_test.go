@@ -123,6 +123,55 @@ func TestMakeISO17365(t *testing.T) {
 	}
 }
 
+func TestMakeISO15459(t *testing.T) {
+	type args struct {
+		pf  bool
+		di  string
+		iac string
+		cin string
+		sn  string
+	}
+	tests := []struct {
+		name    string
+		make    func(bool, string, string, string, string) ([]byte, int, string, string, error)
+		args    args
+		want    []byte
+		want1   int
+		want2   string
+		want3   string
+		wantErr bool
+	}{
+		{"17364_25BUN043325711RTI0001", MakeISO17364, args{false, "25B", "UN", "043325711", "RTI0001"}, []byte{203, 80, 149, 59, 13, 51, 207, 45, 119, 199, 20, 148, 39, 12, 48, 198}, 128, "", "", false},
+		{"17364_25BUN", MakeISO17364, args{true, "25B", "UN", "", ""}, []byte{}, 0, "110010110101000010010101001110", "urn:epc:pat:iso17364:25B.UN", false},
+		{"17366_25SODCIN1PKG001", MakeISO17366, args{false, "25S", "OD", "CIN1", "PKG001"}, []byte{203, 84, 207, 16, 50, 78, 197, 2, 199, 195, 12, 96}, 96, "", "", false},
+		{"17366_25SOD", MakeISO17366, args{true, "25S", "OD", "", ""}, []byte{}, 0, "110010110101010011001111000100", "urn:epc:pat:iso17366:25S.OD", false},
+		{"17367_25SLAACMESN12345", MakeISO17367, args{false, "25S", "LA", "ACME", "SN12345"}, []byte{203, 84, 204, 4, 16, 205, 21, 51, 177, 203, 61, 53}, 96, "", "", false},
+		{"17367_25SLA", MakeISO17367, args{true, "25S", "LA", "", ""}, []byte{}, 0, "110010110101010011001100000001", "urn:epc:pat:iso17367:25S.LA", false},
+		{"17367_no_IAC", MakeISO17367, args{false, "25S", "", "", ""}, []byte{}, 0, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, got1, got2, got3, err := tt.make(tt.args.pf, tt.args.di, tt.args.iac, tt.args.cin, tt.args.sn)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MakeISO() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MakeISO() got = %v, want %v", got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("MakeISO() got1 = %v, want %v", got1, tt.want1)
+			}
+			if got2 != tt.want2 {
+				t.Errorf("MakeISO() got2 = %v, want %v", got2, tt.want2)
+			}
+			if got3 != tt.want3 {
+				t.Errorf("MakeISO() got3 = %v, want %v", got3, tt.want3)
+			}
+		})
+	}
+}
+
 func TestPad6BitEncodingRuneSlice(t *testing.T) {
 	type args struct {
 		bs []rune
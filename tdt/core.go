@@ -7,66 +7,85 @@
 package tdt
 
 import (
-	"errors"
 	"fmt"
-	//"io/ioutil"
-	//"log"
-	"math/big"
-	//"strconv"
+	"strconv"
 	"strings"
-	//"xml"
 )
 
-// Core is the TDT core
-type Core struct {
-	//schemePrefixMap map[schemePrefix]string
-	epcTDSVersion string
-}
+// URIForm is the syntax of the identifiers translated from the EPCs
+type URIForm int
+
+const (
+	// PureIdentityURI is the urn:epc:id: URI without the filter value
+	PureIdentityURI URIForm = iota
+	// TagURI is the urn:epc:tag: URI with the scheme and the filter value
+	TagURI
+	// GS1Key is the GS1 key with the check digit, e.g., the GTIN-14 of an SGTIN
+	GS1Key
+	// ElementString is the GS1 element string with the AIs, e.g., (01)...(21)...
+	ElementString
+	// DigitalLinkURI is the GS1 Digital Link URI, e.g., https://id.gs1.org/01/.../21/...
+	DigitalLinkURI
+)
 
-// NewCore returns a new instance of TDT core
-func NewCore() *Core {
-	c := new(Core)
-	//c.loadEPCTagDataTranslation()
-	return c
+// uriFormNames are the names of the URIForms in the flags and the ECSpecs
+var uriFormNames = map[URIForm]string{
+	PureIdentityURI: "pure-identity",
+	TagURI:          "tag",
+	GS1Key:          "gs1-key",
+	ElementString:   "element-string",
+	DigitalLinkURI:  "digital-link",
 }
 
-// LoadEPCTagDataTranslation loads EPC scheme from scheme files
-func (c *Core) LoadEPCTagDataTranslation() {
-	//schemaDir := os.Getenv("GOPATH") + "/src/github.com/iomz/gosstrak/vendor/schemes/"
-	//files, err := ioutil.ReadDir(schemaDir)
-	//if err != nil {
-	//	log.Fatal(err)
-	//}
+// String returns the name of the URIForm
+func (f URIForm) String() string {
+	if name, ok := uriFormNames[f]; ok {
+		return name
+	}
+	return "URIForm(" + strconv.Itoa(int(f)) + ")"
+}
 
-	//for _, f := range files {
-	//}
+// ParseURIForm returns the URIForm for the name, e.g., tag
+func ParseURIForm(name string) (URIForm, error) {
+	for f, n := range uriFormNames {
+		if n == name {
+			return f, nil
+		}
+	}
+	return PureIdentityURI, fmt.Errorf("unknown URI form: %q", name)
 }
 
-/*
-type schemePrefix struct {
-	prefixMatch     []byte
-	prefixBitLength int
+// Core is the TDT core
+type Core struct {
+	epcTDSVersion string
 }
 
-type schemePatternMap map[string][]schemePattern
+// NewCore returns a new instance of TDT core
+// with the bundled and the registered GS1 TDT definitions
+func NewCore() *Core {
+	return new(Core)
+}
 
-type schemePattern struct {
-	pattern string
-	fields  []schemeField
+// LoadEPCTagDataTranslation loads the GS1 TDT definition files (*.xml) in dir
+// as RegisterEPCTagDataTranslation, for all the Cores and the patterns
+func (c *Core) LoadEPCTagDataTranslation(dir string) error {
+	return RegisterEPCTagDataTranslation(dir)
 }
 
-type schemeFiled struct {
-	decimalMinimum int
-	decimalMaximum int
-	bitLength      int
-	name           string
+// schemes returns the bundled and the registered GS1 TDT definitions,
+// the same ones for every Core and the patterns
+func (c *Core) schemes() []*scheme {
+	return registeredSchemes()
 }
-*/
 
 // Translate takes ID in binary ([]byte) and returns the corresponding PureIdentity
 func (c *Core) Translate(pc []byte, id []byte) (string, error) {
-	if len(pc) != 2 {
-		return "", errors.New("Invalid PC bits")
+	p, err := ParsePC(pc)
+	if err != nil {
+		return "", err
+	}
+	if id, err = c.trimID(p, id); err != nil {
+		return "", err
 	}
 
 	// Check the NSI toggle
@@ -81,723 +100,52 @@ func (c *Core) Translate(pc []byte, id []byte) (string, error) {
 	return c.buildProprietary(id)
 }
 
-func (c *Core) buildEPC(id []byte) (string, error) {
-	urn := ""
+// TranslateURI takes ID in binary ([]byte) and returns the URI in the form,
+// the form applies only to the EPCs and the ISO UIIs are returned as in Translate
+func (c *Core) TranslateURI(pc []byte, id []byte, form URIForm) (string, error) {
+	if _, ok := uriFormNames[form]; !ok {
+		return "", fmt.Errorf("unknown URI form: %v", form)
+	}
+	p, err := ParsePC(pc)
+	if err != nil {
+		return "", err
+	}
+	if form == PureIdentityURI || p.NSI {
+		return c.Translate(pc, id)
+	}
+	if id, err = c.trimID(p, id); err != nil {
+		return "", err
+	}
+	pureIdentity, err := c.buildEPC(id)
+	if err != nil {
+		return "", err
+	}
+	// the raw URIs have no other form
+	if strings.HasPrefix(pureIdentity, "urn:epc:raw:") {
+		return pureIdentity, nil
+	}
+	if form != TagURI {
+		return formatGS1(pureIdentity, form)
+	}
 
-	// EPC Header
-	switch id[0] {
-	case 48: /* ------------- SGTIN-96 00110000 ------------- */
-		if len(id) != 12 {
-			return "", errors.New("Invalid ID")
-		}
-		urn = "urn:epc:id:sgtin:"
-		// FILTER
-		//urn += strconv.Itoa(int((id[1]&224)>>5)) + "." // 224: 11100000
-		// PARTITION
-		partition := int((id[1] & 28) >> 2) // 28: 00011100
-		ptm := map[PartitionTableKey]int{}
-		var cpLength int
-		for k, v := range SGTIN96PartitionTable {
-			if v[PValue] == partition {
-				ptm = v
-				cpLength = k
-				break
-			}
-		}
-		// COMPANY_PREFIX and ITEM_REFERENCE
-		z := new(big.Int)
-		switch ptm[CPBits] {
-		case 40:
-			cp := make([]byte, 5)
-			remainder := id[1] & 3
-			cp[0] = remainder<<6 | id[2]>>2
-			remainder = id[2] & 3
-			cp[1] = remainder<<6 | id[3]>>2
-			remainder = id[3] & 3
-			cp[2] = remainder<<6 | id[4]>>2
-			remainder = id[4] & 3
-			cp[3] = remainder<<6 | id[5]>>2
-			remainder = id[5] & 3
-			cp[4] = remainder<<6 | id[6]>>2
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			ir := make([]byte, 1) // 4 bits
-			remainder = id[6] & 3
-			ir[0] = remainder<<2 | id[7]>>6
-			z.SetBytes(ir)
-			itemReference := z.String()
-			urn += strings.Repeat("0", ptm[IRDigits]-len(itemReference)) + itemReference + "."
-		case 37:
-			cp := make([]byte, 5)
-			remainder := id[1] & 3
-			cp[0] = remainder<<3 | id[2]>>5
-			remainder = id[2] & 31
-			cp[1] = remainder<<3 | id[3]>>5
-			remainder = id[3] & 31
-			cp[2] = remainder<<3 | id[4]>>5
-			remainder = id[4] & 31
-			cp[3] = remainder<<3 | id[5]>>5
-			remainder = id[5] & 31
-			cp[4] = remainder<<3 | id[6]>>5
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			ir := make([]byte, 1) // 7 bits
-			remainder = id[6] & 31
-			ir[0] = remainder<<2 | id[7]>>6
-			z.SetBytes(ir)
-			itemReference := z.String()
-			urn += strings.Repeat("0", ptm[IRDigits]-len(itemReference)) + itemReference + "."
-		case 34:
-			cp := make([]byte, 5)
-			cp[0] = id[1] & 3
-			cp[1] = id[2]
-			cp[2] = id[3]
-			cp[3] = id[4]
-			cp[4] = id[5]
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			ir := make([]byte, 2) // 10 bits
-			ir[0] = id[6] >> 6
-			remainder := id[6] & 63
-			ir[1] = remainder<<2 | id[7]>>6
-			z.SetBytes(ir)
-			itemReference := z.String()
-			urn += strings.Repeat("0", ptm[IRDigits]-len(itemReference)) + itemReference + "."
-		case 30:
-			cp := make([]byte, 4)
-			remainder := id[1] & 3
-			cp[0] = remainder<<4 | id[2]>>4
-			remainder = id[2] & 15
-			cp[1] = remainder<<4 | id[3]>>4
-			remainder = id[3] & 15
-			cp[2] = remainder<<4 | id[4]>>4
-			remainder = id[4] & 15
-			cp[3] = remainder<<4 | id[5]>>4
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			ir := make([]byte, 2) // 14 bits
-			remainder = id[5] & 15
-			ir[0] = remainder<<2 | id[6]>>6
-			remainder = id[6] & 63
-			ir[1] = remainder<<2 | id[7]>>6
-			z.SetBytes(ir)
-			itemReference := z.String()
-			urn += strings.Repeat("0", ptm[IRDigits]-len(itemReference)) + itemReference + "."
-		case 27:
-			cp := make([]byte, 4)
-			remainder := id[1] & 3
-			cp[0] = remainder<<1 | id[2]>>7
-			remainder = id[2] & 127
-			cp[1] = remainder<<1 | id[3]>>7
-			remainder = id[3] & 127
-			cp[2] = remainder<<1 | id[4]>>7
-			remainder = id[4] & 127
-			cp[3] = remainder<<1 | id[5]>>7
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			ir := make([]byte, 3) // 17 bits
-			remainder = id[5] & 127
-			ir[0] = remainder >> 6
-			remainder = remainder & 63
-			ir[1] = remainder<<2 | id[6]>>6
-			remainder = id[6] & 63
-			ir[2] = remainder<<2 | id[7]>>6
-			z.SetBytes(ir)
-			itemReference := z.String()
-			urn += strings.Repeat("0", ptm[IRDigits]-len(itemReference)) + itemReference + "."
-		case 24:
-			cp := make([]byte, 3)
-			remainder := id[1] & 3
-			cp[0] = remainder<<6 | id[2]>>2
-			remainder = id[2] & 3
-			cp[1] = remainder<<6 | id[3]>>2
-			remainder = id[3] & 3
-			cp[2] = remainder<<6 | id[4]>>2
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			ir := make([]byte, 3) // 20 bits
-			remainder = id[4] & 3
-			ir[0] = remainder<<2 | id[5]>>6
-			remainder = id[5] & 63
-			ir[1] = remainder<<2 | id[6]>>6
-			remainder = id[6] & 63
-			ir[2] = remainder<<2 | id[7]>>6
-			z.SetBytes(ir)
-			itemReference := z.String()
-			urn += strings.Repeat("0", ptm[IRDigits]-len(itemReference)) + itemReference + "."
-		case 20:
-			cp := make([]byte, 3)
-			remainder := id[1] & 3
-			cp[0] = remainder<<2 | id[2]>>6
-			remainder = id[2] & 63
-			cp[1] = remainder<<2 | id[3]>>6
-			remainder = id[3] & 63
-			cp[2] = remainder<<2 | id[4]>>6
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			ir := make([]byte, 3) // 24 bits
-			remainder = id[4] & 63
-			ir[0] = remainder<<2 | id[5]>>6
-			remainder = id[5] & 63
-			ir[1] = remainder<<2 | id[6]>>6
-			remainder = id[6] & 63
-			ir[2] = remainder<<2 | id[7]>>6
-			z.SetBytes(ir)
-			itemReference := z.String()
-			urn += strings.Repeat("0", ptm[IRDigits]-len(itemReference)) + itemReference + "."
-		}
-		// SERIAL
-		ser := make([]byte, 5)
-		ser[0] = id[7] & 63
-		ser[1] = id[8]
-		ser[2] = id[9]
-		ser[3] = id[10]
-		ser[4] = id[11]
-		z.SetBytes(ser)
-		urn += z.String()
-	case 49: /* ------------- SSCC-96  00110001 ------------- */
-		if len(id) != 12 {
-			return "", errors.New("Invalid ID")
-		}
-		urn = "urn:epc:id:sscc:"
-		// FILTER
-		//urn += strconv.Itoa(int((id[1]&224)>>5)) + "." // 224: 11100000
-		// PARTITION
-		partition := int((id[1] & 28) >> 2) // 28: 00011100
-		ptm := map[PartitionTableKey]int{}
-		var cpLength int
-		for k, v := range SSCC96PartitionTable {
-			if v[PValue] == partition {
-				ptm = v
-				cpLength = k
-				break
-			}
+	// GS1 TDT definitions
+	for _, s := range c.schemes() {
+		if s.matchHeader(id) {
+			return s.translate(id, s.tagEncoding)
 		}
-		// COMPANY_PREFIX and Extension
-		z := new(big.Int)
-		switch ptm[CPBits] {
-		case 40:
-			cp := make([]byte, 5)
-			remainder := id[1] & 3
-			cp[0] = remainder<<6 | id[2]>>2
-			remainder = id[2] & 3
-			cp[1] = remainder<<6 | id[3]>>2
-			remainder = id[3] & 3
-			cp[2] = remainder<<6 | id[4]>>2
-			remainder = id[4] & 3
-			cp[3] = remainder<<6 | id[5]>>2
-			remainder = id[5] & 3
-			cp[4] = remainder<<6 | id[6]>>2
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			ext := make([]byte, 3) // 18 bits
-			remainder = id[6] & 3
-			ext[0] = remainder
-			ext[1] = id[7]
-			ext[2] = id[8]
-			z.SetBytes(ext)
-			extension := z.String()
-			urn += strings.Repeat("0", ptm[EDigits]-len(extension)) + extension
-		case 37:
-			cp := make([]byte, 5)
-			remainder := id[1] & 3
-			cp[0] = remainder<<3 | id[2]>>5
-			remainder = id[2] & 31
-			cp[1] = remainder<<3 | id[3]>>5
-			remainder = id[3] & 31
-			cp[2] = remainder<<3 | id[4]>>5
-			remainder = id[4] & 31
-			cp[3] = remainder<<3 | id[5]>>5
-			remainder = id[5] & 31
-			cp[4] = remainder<<3 | id[6]>>5
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			ext := make([]byte, 3) // 21 bits
-			remainder = id[6] & 31
-			ext[0] = remainder
-			ext[1] = id[7]
-			ext[2] = id[8]
-			z.SetBytes(ext)
-			extension := z.String()
-			urn += strings.Repeat("0", ptm[EDigits]-len(extension)) + extension
-		case 34:
-			cp := make([]byte, 5)
-			cp[0] = id[1] & 3
-			cp[1] = id[2]
-			cp[2] = id[3]
-			cp[3] = id[4]
-			cp[4] = id[5]
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			ext := make([]byte, 3) // 24 bits
-			ext[0] = id[6]
-			ext[1] = id[7]
-			ext[2] = id[8]
-			z.SetBytes(ext)
-			extension := z.String()
-			urn += strings.Repeat("0", ptm[EDigits]-len(extension)) + extension
-		case 30:
-			cp := make([]byte, 4)
-			remainder := id[1] & 3
-			cp[0] = remainder<<4 | id[2]>>4
-			remainder = id[2] & 15
-			cp[1] = remainder<<4 | id[3]>>4
-			remainder = id[3] & 15
-			cp[2] = remainder<<4 | id[4]>>4
-			remainder = id[4] & 15
-			cp[3] = remainder<<4 | id[5]>>4
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			ext := make([]byte, 4) // 28 bits
-			remainder = id[5] & 15
-			ext[0] = remainder
-			ext[1] = id[6]
-			ext[2] = id[7]
-			ext[3] = id[8]
-			z.SetBytes(ext)
-			extension := z.String()
-			urn += strings.Repeat("0", ptm[EDigits]-len(extension)) + extension
-		case 27:
-			cp := make([]byte, 4)
-			remainder := id[1] & 3
-			cp[0] = remainder<<1 | id[2]>>7
-			remainder = id[2] & 127
-			cp[1] = remainder<<1 | id[3]>>7
-			remainder = id[3] & 127
-			cp[2] = remainder<<1 | id[4]>>7
-			remainder = id[4] & 127
-			cp[3] = remainder<<1 | id[5]>>7
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			ext := make([]byte, 4) // 31 bits
-			remainder = id[5] & 127
-			ext[0] = remainder
-			ext[1] = id[6]
-			ext[2] = id[7]
-			ext[3] = id[8]
-			z.SetBytes(ext)
-			extension := z.String()
-			urn += strings.Repeat("0", ptm[EDigits]-len(extension)) + extension
-		case 24:
-			cp := make([]byte, 3)
-			remainder := id[1] & 3
-			cp[0] = remainder<<6 | id[2]>>2
-			remainder = id[2] & 3
-			cp[1] = remainder<<6 | id[3]>>2
-			remainder = id[3] & 3
-			cp[2] = remainder<<6 | id[4]>>2
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			ext := make([]byte, 5) // 34 bits
-			remainder = id[4] & 3
-			ext[0] = remainder
-			ext[1] = id[5]
-			ext[2] = id[6]
-			ext[3] = id[7]
-			ext[4] = id[8]
-			z.SetBytes(ext)
-			extension := z.String()
-			urn += strings.Repeat("0", ptm[EDigits]-len(extension)) + extension
-		case 20:
-			cp := make([]byte, 3)
-			remainder := id[1] & 3
-			cp[0] = remainder<<2 | id[2]>>6
-			remainder = id[2] & 63
-			cp[1] = remainder<<2 | id[3]>>6
-			remainder = id[3] & 63
-			cp[2] = remainder<<2 | id[4]>>6
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			ext := make([]byte, 5) // 38 bits
-			remainder = id[4] & 63
-			ext[0] = remainder
-			ext[1] = id[5]
-			ext[2] = id[6]
-			ext[3] = id[7]
-			ext[4] = id[8]
-			z.SetBytes(ext)
-			extension := z.String()
-			urn += strings.Repeat("0", ptm[EDigits]-len(extension)) + extension
-		}
-	case 51: /* ------------- GRAI-96  00110011------------- */
-		if len(id) != 12 {
-			return "", errors.New("Invalid ID")
-		}
-		urn = "urn:epc:id:grai:"
-		// FILTER
-		//urn += strconv.Itoa(int((id[1]&224)>>5)) + "." // 224: 11100000
-		// PARTITION
-		partition := int((id[1] & 28) >> 2) // 28: 00011100
-		ptm := map[PartitionTableKey]int{}
-		var cpLength int
-		for k, v := range GRAI96PartitionTable {
-			if v[PValue] == partition {
-				ptm = v
-				cpLength = k
-				break
-			}
-		}
-		// COMPANY_PREFIX and ITEM_REFERENCE
-		z := new(big.Int)
-		switch ptm[CPBits] {
-		case 40:
-			cp := make([]byte, 5)
-			remainder := id[1] & 3
-			cp[0] = remainder<<6 | id[2]>>2
-			remainder = id[2] & 3
-			cp[1] = remainder<<6 | id[3]>>2
-			remainder = id[3] & 3
-			cp[2] = remainder<<6 | id[4]>>2
-			remainder = id[4] & 3
-			cp[3] = remainder<<6 | id[5]>>2
-			remainder = id[5] & 3
-			cp[4] = remainder<<6 | id[6]>>2
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			/*
-				at := make([]byte, 1) // 4 bits = 0 digit
-				remainder = id[6] & 3
-				at[0] = remainder<<2 | id[7]>>6
-				z.SetBytes(at)
-				assetType := z.String()
-				urn += strings.Repeat("0", ptm[ATDigits]-len(assetType)) + assetType + "."
-			*/
-		case 37:
-			cp := make([]byte, 5)
-			remainder := id[1] & 3
-			cp[0] = remainder<<3 | id[2]>>5
-			remainder = id[2] & 31
-			cp[1] = remainder<<3 | id[3]>>5
-			remainder = id[3] & 31
-			cp[2] = remainder<<3 | id[4]>>5
-			remainder = id[4] & 31
-			cp[3] = remainder<<3 | id[5]>>5
-			remainder = id[5] & 31
-			cp[4] = remainder<<3 | id[6]>>5
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			at := make([]byte, 1) // 7 bits
-			remainder = id[6] & 31
-			at[0] = remainder<<2 | id[7]>>6
-			z.SetBytes(at)
-			assetType := z.String()
-			urn += strings.Repeat("0", ptm[ATDigits]-len(assetType)) + assetType + "."
-		case 34:
-			cp := make([]byte, 5)
-			cp[0] = id[1] & 3
-			cp[1] = id[2]
-			cp[2] = id[3]
-			cp[3] = id[4]
-			cp[4] = id[5]
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			at := make([]byte, 2) // 10 bits
-			at[0] = id[6] >> 6
-			remainder := id[6] & 63
-			at[1] = remainder<<2 | id[7]>>6
-			z.SetBytes(at)
-			assetType := z.String()
-			urn += strings.Repeat("0", ptm[ATDigits]-len(assetType)) + assetType + "."
-		case 30:
-			cp := make([]byte, 4)
-			remainder := id[1] & 3
-			cp[0] = remainder<<4 | id[2]>>4
-			remainder = id[2] & 15
-			cp[1] = remainder<<4 | id[3]>>4
-			remainder = id[3] & 15
-			cp[2] = remainder<<4 | id[4]>>4
-			remainder = id[4] & 15
-			cp[3] = remainder<<4 | id[5]>>4
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			at := make([]byte, 2) // 14 bits
-			remainder = id[5] & 15
-			at[0] = remainder<<2 | id[6]>>6
-			remainder = id[6] & 63
-			at[1] = remainder<<2 | id[7]>>6
-			z.SetBytes(at)
-			assetType := z.String()
-			urn += strings.Repeat("0", ptm[ATDigits]-len(assetType)) + assetType + "."
-		case 27:
-			cp := make([]byte, 4)
-			remainder := id[1] & 3
-			cp[0] = remainder<<1 | id[2]>>7
-			remainder = id[2] & 127
-			cp[1] = remainder<<1 | id[3]>>7
-			remainder = id[3] & 127
-			cp[2] = remainder<<1 | id[4]>>7
-			remainder = id[4] & 127
-			cp[3] = remainder<<1 | id[5]>>7
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			at := make([]byte, 3) // 17 bits
-			remainder = id[5] & 127
-			at[0] = remainder >> 6
-			remainder = remainder & 63
-			at[1] = remainder<<2 | id[6]>>6
-			remainder = id[6] & 63
-			at[2] = remainder<<2 | id[7]>>6
-			z.SetBytes(at)
-			assetType := z.String()
-			urn += strings.Repeat("0", ptm[ATDigits]-len(assetType)) + assetType + "."
-		case 24:
-			cp := make([]byte, 3)
-			remainder := id[1] & 3
-			cp[0] = remainder<<6 | id[2]>>2
-			remainder = id[2] & 3
-			cp[1] = remainder<<6 | id[3]>>2
-			remainder = id[3] & 3
-			cp[2] = remainder<<6 | id[4]>>2
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			at := make([]byte, 3) // 20 bits
-			remainder = id[4] & 3
-			at[0] = remainder<<2 | id[5]>>6
-			remainder = id[5] & 63
-			at[1] = remainder<<2 | id[6]>>6
-			remainder = id[6] & 63
-			at[2] = remainder<<2 | id[7]>>6
-			z.SetBytes(at)
-			assetType := z.String()
-			urn += strings.Repeat("0", ptm[ATDigits]-len(assetType)) + assetType + "."
-		case 20:
-			cp := make([]byte, 3)
-			remainder := id[1] & 3
-			cp[0] = remainder<<2 | id[2]>>6
-			remainder = id[2] & 63
-			cp[1] = remainder<<2 | id[3]>>6
-			remainder = id[3] & 63
-			cp[2] = remainder<<2 | id[4]>>6
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			at := make([]byte, 3) // 24 bits
-			remainder = id[4] & 63
-			at[0] = remainder<<2 | id[5]>>6
-			remainder = id[5] & 63
-			at[1] = remainder<<2 | id[6]>>6
-			remainder = id[6] & 63
-			at[2] = remainder<<2 | id[7]>>6
-			z.SetBytes(at)
-			assetType := z.String()
-			urn += strings.Repeat("0", ptm[ATDigits]-len(assetType)) + assetType + "."
-		}
-		// SERIAL
-		ser := make([]byte, 5)
-		ser[0] = id[7] & 63
-		ser[1] = id[8]
-		ser[2] = id[9]
-		ser[3] = id[10]
-		ser[4] = id[11]
-		z.SetBytes(ser)
-		urn += z.String()
-	case 52: /* ------------- GIAI-96  00110100 ------------- */
-		if len(id) != 12 {
-			return "", errors.New("Invalid ID")
-		}
-		urn = "urn:epc:id:giai:"
-		// FILTER
-		//urn += strconv.Itoa(int((id[1]&224)>>5)) + "." // 224: 11100000
-		// PARTITION
-		partition := int((id[1] & 28) >> 2) // 28: 00011100
-		ptm := map[PartitionTableKey]int{}
-		var cpLength int
-		for k, v := range GIAI96PartitionTable {
-			if v[PValue] == partition {
-				ptm = v
-				cpLength = k
-				break
-			}
-		}
-		// COMPANY_PREFIX and Individual Asset Reference
-		z := new(big.Int)
-		switch ptm[CPBits] {
-		case 40:
-			cp := make([]byte, 5)
-			remainder := id[1] & 3
-			cp[0] = remainder<<6 | id[2]>>2
-			remainder = id[2] & 3
-			cp[1] = remainder<<6 | id[3]>>2
-			remainder = id[3] & 3
-			cp[2] = remainder<<6 | id[4]>>2
-			remainder = id[4] & 3
-			cp[3] = remainder<<6 | id[5]>>2
-			remainder = id[5] & 3
-			cp[4] = remainder<<6 | id[6]>>2
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			iar := make([]byte, 6) // 42 bits
-			remainder = id[6] & 3
-			iar[0] = remainder
-			iar[1] = id[7]
-			iar[2] = id[8]
-			iar[3] = id[9]
-			iar[4] = id[10]
-			iar[5] = id[11]
-			z.SetBytes(iar)
-			urn += z.String()
-		case 37:
-			cp := make([]byte, 5)
-			remainder := id[1] & 3
-			cp[0] = remainder<<3 | id[2]>>5
-			remainder = id[2] & 31
-			cp[1] = remainder<<3 | id[3]>>5
-			remainder = id[3] & 31
-			cp[2] = remainder<<3 | id[4]>>5
-			remainder = id[4] & 31
-			cp[3] = remainder<<3 | id[5]>>5
-			remainder = id[5] & 31
-			cp[4] = remainder<<3 | id[6]>>5
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			iar := make([]byte, 6) // 45 bits
-			remainder = id[6] & 31
-			iar[0] = remainder
-			iar[1] = id[7]
-			iar[2] = id[8]
-			iar[3] = id[9]
-			iar[4] = id[10]
-			iar[5] = id[11]
-			z.SetBytes(iar)
-			urn += z.String()
-		case 34:
-			cp := make([]byte, 5)
-			cp[0] = id[1] & 3
-			cp[1] = id[2]
-			cp[2] = id[3]
-			cp[3] = id[4]
-			cp[4] = id[5]
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			iar := make([]byte, 6) // 48 bits
-			iar[0] = id[6]
-			iar[1] = id[7]
-			iar[2] = id[8]
-			iar[3] = id[9]
-			iar[4] = id[10]
-			iar[5] = id[11]
-			z.SetBytes(iar)
-			urn += z.String()
-		case 30:
-			cp := make([]byte, 4)
-			remainder := id[1] & 3
-			cp[0] = remainder<<4 | id[2]>>4
-			remainder = id[2] & 15
-			cp[1] = remainder<<4 | id[3]>>4
-			remainder = id[3] & 15
-			cp[2] = remainder<<4 | id[4]>>4
-			remainder = id[4] & 15
-			cp[3] = remainder<<4 | id[5]>>4
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			iar := make([]byte, 7) // 52 bits
-			remainder = id[5] & 15
-			iar[0] = remainder
-			iar[1] = id[6]
-			iar[2] = id[7]
-			iar[3] = id[8]
-			iar[4] = id[9]
-			iar[5] = id[10]
-			iar[6] = id[11]
-			z.SetBytes(iar)
-			urn += z.String()
-		case 27:
-			cp := make([]byte, 4)
-			remainder := id[1] & 3
-			cp[0] = remainder<<1 | id[2]>>7
-			remainder = id[2] & 127
-			cp[1] = remainder<<1 | id[3]>>7
-			remainder = id[3] & 127
-			cp[2] = remainder<<1 | id[4]>>7
-			remainder = id[4] & 127
-			cp[3] = remainder<<1 | id[5]>>7
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			iar := make([]byte, 7) // 55 bits
-			remainder = id[5] & 127
-			iar[0] = remainder
-			iar[1] = id[6]
-			iar[2] = id[7]
-			iar[3] = id[8]
-			iar[4] = id[9]
-			iar[5] = id[10]
-			iar[6] = id[11]
-			z.SetBytes(iar)
-			urn += z.String()
-		case 24:
-			cp := make([]byte, 3)
-			remainder := id[1] & 3
-			cp[0] = remainder<<6 | id[2]>>2
-			remainder = id[2] & 3
-			cp[1] = remainder<<6 | id[3]>>2
-			remainder = id[3] & 3
-			cp[2] = remainder<<6 | id[4]>>2
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			iar := make([]byte, 8) // 58 bits
-			remainder = id[4] & 3
-			iar[0] = remainder
-			iar[1] = id[5]
-			iar[2] = id[6]
-			iar[3] = id[7]
-			iar[4] = id[8]
-			iar[5] = id[9]
-			iar[6] = id[10]
-			iar[7] = id[11]
-			z.SetBytes(iar)
-			urn += z.String()
-		case 20:
-			cp := make([]byte, 3)
-			remainder := id[1] & 3
-			cp[0] = remainder<<2 | id[2]>>6
-			remainder = id[2] & 63
-			cp[1] = remainder<<2 | id[3]>>6
-			remainder = id[3] & 63
-			cp[2] = remainder<<2 | id[4]>>6
-			z.SetBytes(cp)
-			companyPrefix := z.String()
-			urn += strings.Repeat("0", cpLength-len(companyPrefix)) + companyPrefix + "."
-			iar := make([]byte, 8) // 62 bits
-			remainder = id[4] & 63
-			iar[0] = remainder
-			iar[1] = id[5]
-			iar[2] = id[6]
-			iar[3] = id[7]
-			iar[4] = id[8]
-			iar[5] = id[9]
-			iar[6] = id[10]
-			iar[7] = id[11]
-			z.SetBytes(iar)
-			urn += z.String()
+	}
+	return "", fmt.Errorf("no tag URI for the EPC header: %#02x", id[0])
+}
+
+func (c *Core) buildEPC(id []byte) (string, error) {
+	// GS1 TDT definitions
+	for _, s := range c.schemes() {
+		if s.matchHeader(id) {
+			return s.translate(id, s.pureIdentity)
 		}
 	}
-	return urn, nil
+	// unknown EPC header
+	return buildRaw(id, false, 0), nil
 }
 
 func (c *Core) buildUII(id []byte, afi byte) (string, error) {
@@ -824,7 +172,8 @@ func (c *Core) buildUII(id []byte, afi byte) (string, error) {
 	case 170:
 		urn += "17363h:"
 	default:
-		return "", errors.New("invalid afi")
+		// unknown AFI
+		return buildRaw(id, true, afi), nil
 	}
 
 	sid, err := parse6BitEncodedByteSliceToString(id)
@@ -837,25 +186,28 @@ func (c *Core) buildUII(id []byte, afi byte) (string, error) {
 }
 
 func (c *Core) buildProprietary(id []byte) (string, error) {
-	return "", nil
+	return buildRaw(id, false, 0), nil
 }
 
 // MakePrefixFilterString takes a pattern type and a slice of fields
 // return a binary reporesentation of the prefix filter in string
 func MakePrefixFilterString(patternType string, fields []string) (string, error) {
+	if s := lookupScheme(patternType); s != nil {
+		return s.prefixFilter(fields)
+	}
 	switch patternType { // type
-	case "giai-96":
-		return NewPrefixFilterGIAI96(fields)
-	case "grai-96":
-		return NewPrefixFilterGRAI96(fields)
-	case "sgtin-96":
-		return NewPrefixFilterSGTIN96(fields)
-	case "sscc-96":
-		return NewPrefixFilterSSCC96(fields)
-	case "iso17363":
+	case "iso17363", "iso17363h":
 		return NewPrefixFilterISO17363(fields)
-	case "iso17365":
+	case "iso17364", "iso17364h":
+		return NewPrefixFilterISO17364(fields)
+	case "iso17365", "iso17365h":
 		return NewPrefixFilterISO17365(fields)
+	case "iso17366", "iso17366h":
+		return NewPrefixFilterISO17366(fields)
+	case "iso17367", "iso17367h":
+		return NewPrefixFilterISO17367(fields)
+	case "raw":
+		return NewPrefixFilterRaw(fields)
 	default:
 		return "", fmt.Errorf("unknown patternType: %v", patternType)
 	}
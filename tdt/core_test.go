@@ -235,6 +235,144 @@ func Test_core_Translate(t *testing.T) {
 			args{[]byte{48, 0}, []byte{51, 120, 120, 144, 0, 0, 0, 64, 0, 0, 0, 1}},
 			"urn:epc:id:grai:123456.000001.1",
 			false,
+		}, {
+			"SGTIN-198_3_5_0614141_812345_32a/b",
+			fields{""},
+			args{[]byte{104, 0}, []byte{54, 116, 37, 123, 247, 25, 78, 89, 178, 194, 191, 16, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+			"urn:epc:id:sgtin:0614141.812345.32a%2Fb",
+			false,
+		}, {
+			"SGTIN-198_1_4_12345678_00001_A.1",
+			fields{""},
+			args{[]byte{104, 0}, []byte{54, 48, 94, 48, 167, 0, 0, 96, 174, 98, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+			"urn:epc:id:sgtin:12345678.00001.A.1",
+			false,
+		}, {
+			"SGTIN-198_short",
+			fields{""},
+			args{[]byte{48, 0}, []byte{54, 48, 94, 48, 167, 0, 0, 96, 174, 98, 0, 0}},
+			"",
+			true,
+		}, {
+			"SGTIN-198_invalid_partition",
+			fields{""},
+			args{[]byte{104, 0}, []byte{54, 124, 94, 48, 167, 0, 0, 96, 174, 98, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+			"",
+			true,
+		}, {
+			"SGLN-96_3_5_0614141_12345_400",
+			fields{""},
+			args{[]byte{48, 0}, []byte{50, 116, 37, 123, 244, 96, 114, 0, 0, 0, 1, 144}},
+			"urn:epc:id:sgln:0614141.12345.400",
+			false,
+		}, {
+			"SGLN-96_1_0_012345678901__0",
+			fields{""},
+			args{[]byte{48, 0}, []byte{50, 32, 11, 127, 112, 112, 212, 0, 0, 0, 0, 0}},
+			"urn:epc:id:sgln:012345678901..0",
+			false,
+		}, {
+			"SGLN-96_short",
+			fields{""},
+			args{[]byte{48, 0}, []byte{50, 116, 37, 123, 244, 96, 114, 0, 0, 0, 1}},
+			"",
+			true,
+		}, {
+			"SGLN-195_3_5_0614141_12345_32a/b",
+			fields{""},
+			args{[]byte{104, 0}, []byte{57, 116, 37, 123, 244, 96, 114, 205, 150, 21, 248, 128, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+			"urn:epc:id:sgln:0614141.12345.32a%2Fb",
+			false,
+		}, {
+			"GRAI-170_3_5_0614141_12345_32a/b",
+			fields{""},
+			args{[]byte{88, 0}, []byte{55, 116, 37, 123, 244, 12, 14, 89, 178, 194, 191, 16, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+			"urn:epc:id:grai:0614141.12345.32a%2Fb",
+			false,
+		}, {
+			"GRAI-170_short",
+			fields{""},
+			args{[]byte{88, 0}, []byte{55, 116, 37, 123, 244, 12, 14, 89, 178, 194, 191, 16}},
+			"",
+			true,
+		}, {
+			"GIAI-202_3_5_0614141_32a/b",
+			fields{""},
+			args{[]byte{104, 0}, []byte{56, 116, 37, 123, 245, 155, 44, 43, 241, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+			"urn:epc:id:giai:0614141.32a%2Fb",
+			false,
+		}, {
+			"GIAI-202_invalid_partition",
+			fields{""},
+			args{[]byte{104, 0}, []byte{56, 124, 37, 123, 245, 155, 44, 43, 241, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+			"",
+			true,
+		}, {
+			"GDTI-96_3_5_0614141_12345_400",
+			fields{""},
+			args{[]byte{48, 0}, []byte{44, 116, 37, 123, 244, 96, 114, 0, 0, 0, 1, 144}},
+			"urn:epc:id:gdti:0614141.12345.400",
+			false,
+		}, {
+			"GDTI-174_3_5_0614141_12345_32a/b",
+			fields{""},
+			args{[]byte{88, 0}, []byte{62, 116, 37, 123, 244, 96, 114, 205, 150, 21, 248, 128, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+			"urn:epc:id:gdti:0614141.12345.32a%2Fb",
+			false,
+		}, {
+			"GSRN-96_0_5_0614141_1234567890",
+			fields{""},
+			args{[]byte{48, 0}, []byte{45, 20, 37, 123, 244, 73, 150, 2, 210, 0, 0, 0}},
+			"urn:epc:id:gsrn:0614141.1234567890",
+			false,
+		}, {
+			"SGCN-96_3_5_4012345_67890_04711",
+			fields{""},
+			args{[]byte{48, 0}, []byte{63, 116, 244, 228, 230, 18, 100, 0, 0, 1, 153, 7}},
+			"urn:epc:id:sgcn:4012345.67890.04711",
+			false,
+		}, {
+			"SGCN-96_invalid_numeric_string",
+			fields{""},
+			args{[]byte{48, 0}, []byte{63, 116, 244, 228, 230, 18, 100, 0, 0, 0, 0, 0}},
+			"",
+			true,
+		}, {
+			"CPI-96_3_5_0614141_123457_12345",
+			fields{""},
+			args{[]byte{48, 0}, []byte{60, 116, 37, 123, 244, 0, 241, 32, 128, 0, 48, 57}},
+			"urn:epc:id:cpi:0614141.123457.12345",
+			false,
+		}, {
+			"CPI-var_3_5_0614141_5PQ7/Z43_12345",
+			fields{""},
+			args{[]byte{72, 0}, []byte{61, 116, 37, 123, 247, 84, 17, 222, 246, 180, 204, 0, 0, 0, 3, 3, 144, 0}},
+			"urn:epc:id:cpi:0614141.5PQ7%2FZ43.12345",
+			false,
+		}, {
+			"CPI-var_no_serial",
+			fields{""},
+			args{[]byte{72, 0}, []byte{61, 116, 37, 123, 247, 84, 17, 222, 246, 180, 204, 0, 0}},
+			"",
+			true,
+		}, {
+			"unknown_header",
+			fields{""},
+			args{[]byte{48, 0}, []byte{255, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+			"urn:epc:raw:96.xFF0000000000000000000000",
+			false,
+		}, {
+			"unknown_header_padded",
+			fields{""},
+			args{[]byte{16, 0}, []byte{255, 1, 2, 3, 0, 0, 0, 0}},
+			"urn:epc:raw:32.xFF010203",
+			false,
+		}, {
+			"unknown_AFI",
+			fields{""},
+			args{[]byte{17, 176}, []byte{220, 32, 66, 13}},
+			"urn:epc:raw:32.xB0.xDC20420D",
+			false,
 		}, {
 			"ISO17363_7B_ABC_U_1234560",
 			fields{""},
@@ -242,6 +380,27 @@ func Test_core_Translate(t *testing.T) {
 			"urn:epc:id:iso17363:7BABCU1234560",
 			false,
 		},
+		{
+			"ISO17364_25B_UN_043325711_RTI0001",
+			fields{""},
+			args{[]byte{65, 163}, []byte{203, 80, 149, 59, 13, 51, 207, 45, 119, 199, 20, 148, 39, 12, 48, 198}},
+			"urn:epc:id:iso17364:25BUN043325711RTI0001",
+			false,
+		},
+		{
+			"ISO17366_25S_OD_CIN1_PKG001",
+			fields{""},
+			args{[]byte{49, 165}, []byte{203, 84, 207, 16, 50, 78, 197, 2, 199, 195, 12, 96}},
+			"urn:epc:id:iso17366:25SODCIN1PKG001",
+			false,
+		},
+		{
+			"ISO17367_25S_LA_ACME_SN12345",
+			fields{""},
+			args{[]byte{49, 161}, []byte{203, 84, 204, 4, 16, 205, 21, 51, 177, 203, 61, 53}},
+			"urn:epc:id:iso17367:25SLAACMESN12345",
+			false,
+		},
 		{
 			"ISO17365_25S_UN_ABC_0THANK0YOU0FOR0READING0THIS1",
 			fields{""},
@@ -267,6 +426,67 @@ func Test_core_Translate(t *testing.T) {
 	}
 }
 
+func TestCore_TranslateURI(t *testing.T) {
+	tests := []struct {
+		name    string
+		pc      []byte
+		id      []byte
+		form    URIForm
+		want    string
+		wantErr bool
+	}{
+		{"SGTIN-96_pure_identity", []byte{48, 0}, []byte{48, 116, 75, 90, 28, 0, 0, 64, 0, 0, 0, 1}, PureIdentityURI, "urn:epc:id:sgtin:1234567.000001.1", false},
+		{"SGTIN-96_tag", []byte{48, 0}, []byte{48, 116, 75, 90, 28, 0, 0, 64, 0, 0, 0, 1}, TagURI, "urn:epc:tag:sgtin-96:3.1234567.000001.1", false},
+		{"SSCC-96_tag", []byte{48, 0}, []byte{49, 96, 114, 250, 100, 104, 80, 0, 1, 0, 0, 0}, TagURI, "urn:epc:tag:sscc-96:3.123456789012.00001", false},
+		{"GSRN-96_tag", []byte{48, 0}, []byte{45, 20, 37, 123, 244, 73, 150, 2, 210, 0, 0, 0}, TagURI, "urn:epc:tag:gsrn-96:0.0614141.1234567890", false},
+		{"CPI-var_tag", []byte{72, 0}, []byte{61, 116, 37, 123, 247, 84, 17, 222, 246, 180, 204, 0, 0, 0, 3, 3, 144, 0}, TagURI, "urn:epc:tag:cpi-var:3.0614141.5PQ7%2FZ43.12345", false},
+		{"GID-96_tag", []byte{48, 0}, []byte{53, 90, 177, 198, 0, 3, 3, 144, 0, 0, 1, 144}, TagURI, "urn:epc:tag:gid-96:95100000.12345.400", false},
+		{"ISO17363_tag", []byte{41, 169}, []byte{220, 32, 66, 13, 92, 114, 207, 77, 118, 194}, TagURI, "urn:epc:id:iso17363:7BABCU1234560", false},
+		{"SGTIN-96_short", []byte{48, 0}, []byte{48, 116, 75, 90, 28, 0, 0, 64, 0, 0, 0}, TagURI, "", true},
+		{"unknown_form", []byte{48, 0}, []byte{48, 116, 75, 90, 28, 0, 0, 64, 0, 0, 0, 1}, URIForm(9), "", true},
+	}
+	c := NewCore()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.TranslateURI(tt.pc, tt.id, tt.form)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Core.TranslateURI() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Core.TranslateURI() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseURIForm(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    URIForm
+		wantErr bool
+	}{
+		{"pure-identity", PureIdentityURI, false},
+		{"tag", TagURI, false},
+		{"raw", PureIdentityURI, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseURIForm(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseURIForm() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseURIForm() = %v, want %v", got, tt.want)
+			}
+			if !tt.wantErr && got.String() != tt.name {
+				t.Errorf("URIForm.String() = %v, want %v", got.String(), tt.name)
+			}
+		})
+	}
+}
+
 func benchmarkTranslateNTags(nTags int, b *testing.B) {
 	largeTagsGOB := "test/data/bench-100subs-tags.gob"
 	// load up the tags from the file
@@ -0,0 +1,145 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package tdt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Identity is an ID decoded with the fields of the scheme
+type Identity struct {
+	// Scheme is the tag URI scheme, e.g., sgtin-96, the ISO standard, e.g., iso17363, or raw
+	Scheme string
+	// Type is the pure identity type, e.g., sgtin, or raw for the IDs not translated
+	Type string
+	// Header is the EPC header, or the first byte of the ISO UII
+	Header byte
+	// Filter is the filter value, -1 if none
+	Filter int
+	// Partition is the partition value, -1 if none
+	Partition int
+	// CompanyPrefix is the GS1 company prefix of the GS1 EPC schemes
+	CompanyPrefix string
+	// Reference is the item, location, asset type, document type, part or coupon reference
+	Reference string
+	// Serial is the serial number, the serial reference of SSCC, the extension of SGLN,
+	// the individual asset reference of GIAI or the service reference of GSRN
+	Serial string
+	// DataIdentifier is the ASC MH10 data identifier of the ISO UII, e.g., 25S
+	DataIdentifier string
+	// Fields are the fields of the pure identity
+	Fields []string
+	// PureIdentity is the URI returned from Translate
+	PureIdentity string
+	// PC is the PC bits
+	PC []byte
+	// ID is the EPC or the UII in binary, trimmed to the length in the PC
+	ID []byte
+	// NSI is the numbering system identifier toggle, true for ISO
+	NSI bool
+	// AFI is the application family identifier, 0 for the EPCs
+	AFI byte
+	// UMI is the user memory indicator
+	UMI bool
+	// XPCW1 is the XPC_W1 word, nil unless XI is set and given
+	XPCW1 []byte
+}
+
+// Bits returns the ID in the binary string
+func (i *Identity) Bits() string {
+	var sb strings.Builder
+	for _, b := range i.ID {
+		fmt.Fprintf(&sb, "%08b", b)
+	}
+	return sb.String()
+}
+
+// Decode takes ID in binary ([]byte) and returns the Identity with the fields,
+// the Identity comes along with an error wrapping ErrCheckDigit if the check digit is wrong
+func (c *Core) Decode(pc []byte, id []byte) (*Identity, error) {
+	p, err := ParsePC(pc)
+	if err != nil {
+		return nil, err
+	}
+	if id, err = c.trimID(p, id); err != nil {
+		return nil, err
+	}
+	pureIdentity, err := c.Translate(pc, id)
+	if err != nil {
+		return nil, err
+	}
+	// the raw URI has no pure identity type, urn:epc:raw:<fields>
+	seq := strings.SplitN(strings.Replace(pureIdentity, "urn:epc:raw:", "urn:epc:id:raw:", 1), ":", 5)
+	if len(seq) != 5 {
+		return nil, fmt.Errorf("invalid pure identity: %v", pureIdentity)
+	}
+	i := &Identity{
+		Type:         seq[3],
+		Header:       id[0],
+		Filter:       -1,
+		Partition:    -1,
+		PureIdentity: pureIdentity,
+		PC:           pc,
+		ID:           id,
+		NSI:          p.NSI,
+		UMI:          p.UMI,
+		XPCW1:        p.XPCW1,
+	}
+
+	// the IDs not translated in any scheme
+	if i.Type == "raw" {
+		i.Scheme = i.Type
+		if i.NSI {
+			i.AFI = p.AFI
+		}
+		i.Fields = strings.Split(seq[4], ".")
+		return i, nil
+	}
+
+	// ISO UII
+	if i.NSI {
+		i.Scheme = i.Type
+		i.AFI = p.AFI
+		i.Fields = []string{seq[4]}
+		i.DataIdentifier, _, _ = parseDataIdentifier(seq[4])
+		return i, ValidateCheckDigit(pureIdentity)
+	}
+
+	// GS1 TDT definitions
+	for _, s := range c.schemes() {
+		if !s.matchHeader(id) {
+			continue
+		}
+		opt, values, err := s.decode(id)
+		if err != nil {
+			return nil, err
+		}
+		i.Scheme = strings.ToLower(s.Name)
+		if s.tagEncoding != nil {
+			i.Scheme = strings.TrimPrefix(strings.TrimSuffix(s.tagEncoding.PrefixMatch, ":"), "urn:epc:tag:")
+		}
+		if filter, err := strconv.Atoi(values["filter"]); err == nil {
+			i.Filter = filter
+		}
+		// the fields of the pure identity keep the dots in the last one
+		pi := s.pureIdentity.option(opt.OptionKey)
+		i.Fields = strings.SplitN(seq[4], ".", pi.segments())
+		if _, ok := values["gs1companyprefix"]; ok {
+			i.Partition = opt.partition()
+			i.CompanyPrefix = i.Fields[0]
+			switch len(i.Fields) {
+			case 2:
+				i.Serial = i.Fields[1]
+			case 3:
+				i.Reference, i.Serial = i.Fields[1], i.Fields[2]
+			}
+		}
+		return i, nil
+	}
+	return nil, fmt.Errorf("no scheme for the EPC header: %#02x", id[0])
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package tdt
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCore_Decode(t *testing.T) {
+	tests := []struct {
+		name    string
+		pc      []byte
+		id      []byte
+		want    *Identity
+		wantErr bool
+	}{
+		{
+			"SGTIN-96_3_1234567_000001_1",
+			[]byte{48, 0},
+			[]byte{48, 116, 75, 90, 28, 0, 0, 64, 0, 0, 0, 1},
+			&Identity{
+				Scheme:        "sgtin-96",
+				Type:          "sgtin",
+				Header:        48,
+				Filter:        3,
+				Partition:     5,
+				CompanyPrefix: "1234567",
+				Reference:     "000001",
+				Serial:        "1",
+				Fields:        []string{"1234567", "000001", "1"},
+				PureIdentity:  "urn:epc:id:sgtin:1234567.000001.1",
+			},
+			false,
+		},
+		{
+			"SSCC-96_3_123456789012_00001",
+			[]byte{48, 0},
+			[]byte{49, 96, 114, 250, 100, 104, 80, 0, 1, 0, 0, 0},
+			&Identity{
+				Scheme:        "sscc-96",
+				Type:          "sscc",
+				Header:        49,
+				Filter:        3,
+				Partition:     0,
+				CompanyPrefix: "123456789012",
+				Serial:        "00001",
+				Fields:        []string{"123456789012", "00001"},
+				PureIdentity:  "urn:epc:id:sscc:123456789012.00001",
+			},
+			false,
+		},
+		{
+			"GIAI-202_0614141_32a/b",
+			[]byte{104, 0},
+			[]byte{56, 116, 37, 123, 245, 155, 44, 43, 241, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			&Identity{
+				Scheme:        "giai-202",
+				Type:          "giai",
+				Header:        56,
+				Filter:        3,
+				Partition:     5,
+				CompanyPrefix: "0614141",
+				Serial:        "32a%2Fb",
+				Fields:        []string{"0614141", "32a%2Fb"},
+				PureIdentity:  "urn:epc:id:giai:0614141.32a%2Fb",
+			},
+			false,
+		},
+		{
+			"GID-96_95100000_12345_400",
+			[]byte{48, 0},
+			[]byte{53, 90, 177, 198, 0, 3, 3, 144, 0, 0, 1, 144},
+			&Identity{
+				Scheme:       "gid-96",
+				Type:         "gid",
+				Header:       53,
+				Filter:       -1,
+				Partition:    -1,
+				Fields:       []string{"95100000", "12345", "400"},
+				PureIdentity: "urn:epc:id:gid:95100000.12345.400",
+			},
+			false,
+		},
+		{
+			"ISO17363_7B_ABC_U_1234560",
+			[]byte{41, 169},
+			[]byte{220, 32, 66, 13, 92, 114, 207, 77, 118, 194},
+			&Identity{
+				Scheme:         "iso17363",
+				Type:           "iso17363",
+				Header:         220,
+				Filter:         -1,
+				Partition:      -1,
+				DataIdentifier: "7B",
+				Fields:         []string{"7BABCU1234560"},
+				PureIdentity:   "urn:epc:id:iso17363:7BABCU1234560",
+				NSI:            true,
+				AFI:            169,
+			},
+			false,
+		},
+		{
+			"SGTIN-96_short",
+			[]byte{48, 0},
+			[]byte{48, 116, 75, 90, 28, 0, 0, 64, 0, 0, 0},
+			nil,
+			true,
+		},
+	}
+	c := NewCore()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Decode(tt.pc, tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Core.Decode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.want != nil {
+				tt.want.PC, tt.want.ID = tt.pc, tt.id
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Core.Decode() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCore_Decode_checkDigit(t *testing.T) {
+	c := NewCore()
+	// the serial 123456 read as 123457 with the check digit 0
+	identity, err := c.Decode([]byte{41, 169}, []byte{220, 32, 66, 13, 92, 114, 207, 77, 119, 194})
+	if !errors.Is(err, ErrCheckDigit) {
+		t.Fatalf("Core.Decode() error = %v, want %v", err, ErrCheckDigit)
+	}
+	if identity == nil || identity.PureIdentity != "urn:epc:id:iso17363:7BABCU1234570" {
+		t.Errorf("Core.Decode() = %+v, want the Identity of urn:epc:id:iso17363:7BABCU1234570", identity)
+	}
+}
+
+func TestIdentity_Bits(t *testing.T) {
+	i := &Identity{ID: []byte{48, 116}}
+	if got, want := i.Bits(), "0011000001110100"; got != want {
+		t.Errorf("Identity.Bits() = %v, want %v", got, want)
+	}
+}
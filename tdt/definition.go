@@ -0,0 +1,813 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package tdt
+
+import (
+	"embed"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// bundledSchemes is the GS1 TDT definition files built in the package
+//
+//go:embed schemes/*.xml
+var bundledSchemes embed.FS
+
+// Level types in the GS1 TDT definitions
+const (
+	levelBinary       = "BINARY"
+	levelTagEncoding  = "TAG_ENCODING"
+	levelPureIdentity = "PURE_IDENTITY"
+)
+
+// Rule types in the GS1 TDT definitions
+const (
+	ruleExtract = "EXTRACT"
+	ruleFormat  = "FORMAT"
+)
+
+// definitions is the only registry of the schemes for all the Cores and the patterns,
+// the bundled ones and the ones added by RegisterEPCTagDataTranslation
+var definitions struct {
+	sync.RWMutex
+	schemes []*scheme
+}
+
+func init() {
+	schemes, err := loadEPCTagDataTranslationFS(bundledSchemes, "schemes")
+	if err != nil {
+		panic(err)
+	}
+	definitions.schemes = schemes
+}
+
+// epcTagDataTranslation is the root element of a GS1 TDT definition file
+type epcTagDataTranslation struct {
+	XMLName xml.Name  `xml:"epcTagDataTranslation"`
+	Schemes []*scheme `xml:"scheme"`
+}
+
+// scheme is a coding scheme in the GS1 TDT definition
+type scheme struct {
+	Name      string   `xml:"name,attr"`
+	OptionKey string   `xml:"optionKey,attr"`
+	TagLength string   `xml:"tagLength,attr"`
+	Levels    []*level `xml:"level"`
+
+	binary       *level
+	tagEncoding  *level
+	pureIdentity *level
+	tagLength    int
+}
+
+// level is a representation of the scheme, e.g., BINARY or PURE_IDENTITY
+type level struct {
+	Type        string    `xml:"type,attr"`
+	PrefixMatch string    `xml:"prefixMatch,attr"`
+	Options     []*option `xml:"option"`
+	Rules       []*rule   `xml:"rule"`
+}
+
+// option is the format of the level for an optionKey, e.g., the length of the company prefix
+type option struct {
+	OptionKey string   `xml:"optionKey,attr"`
+	Pattern   string   `xml:"pattern,attr"`
+	Grammar   string   `xml:"grammar,attr"`
+	Fields    []*field `xml:"field"`
+
+	pattern *regexp.Regexp
+	grammar []grammarToken
+	fields  map[string]*field
+}
+
+// field is a component of the option matched by the seq-th group in the pattern
+type field struct {
+	Seq          int    `xml:"seq,attr"`
+	Name         string `xml:"name,attr"`
+	BitLength    int    `xml:"bitLength,attr"`
+	Length       int    `xml:"length,attr"`
+	CharacterSet string `xml:"characterSet,attr"`
+	Compaction   string `xml:"compaction,attr"`
+	PadChar      string `xml:"padChar,attr"`
+	PadDir       string `xml:"padDir,attr"`
+	BitPadDir    string `xml:"bitPadDir,attr"`
+
+	characterSet *regexp.Regexp
+	charBits     int
+}
+
+// rule derives a field from the others, EXTRACT after parsing the level and FORMAT before formatting it
+type rule struct {
+	Type         string `xml:"type,attr"`
+	Seq          int    `xml:"seq,attr"`
+	NewFieldName string `xml:"newFieldName,attr"`
+	CharacterSet string `xml:"characterSet,attr"`
+	Function     string `xml:"function,attr"`
+
+	characterSet *regexp.Regexp
+	function     string
+	args         []string
+}
+
+// grammarToken is either a quoted literal or a field name in the grammar
+type grammarToken struct {
+	literal string
+	field   string
+}
+
+// RegisterEPCTagDataTranslation loads the GS1 TDT definition files (*.xml) in dir
+// for all the Cores and the patterns, the schemes defined take precedence over the bundled ones
+func RegisterEPCTagDataTranslation(dir string) error {
+	schemes, err := loadEPCTagDataTranslationDir(dir)
+	if err != nil {
+		return err
+	}
+	definitions.Lock()
+	definitions.schemes = mergeSchemes(definitions.schemes, schemes)
+	definitions.Unlock()
+	return nil
+}
+
+// MakePureIdentityPrefix takes a pattern type and a slice of fields defined in the GS1 TDT definitions
+// and returns the prefix of the pure identities matching them
+func MakePureIdentityPrefix(patternType string, fields []string) (string, error) {
+	s := lookupScheme(patternType)
+	if s == nil {
+		return "", fmt.Errorf("unknown patternType: %v", patternType)
+	}
+	return s.pureIdentityPrefix(fields)
+}
+
+// lookupScheme returns the scheme of the pattern type in the definitions, or nil
+func lookupScheme(patternType string) *scheme {
+	return findScheme(registeredSchemes(), patternType)
+}
+
+// registeredSchemes returns the schemes in the definitions,
+// the slice is replaced but never modified on a registration
+func registeredSchemes() []*scheme {
+	definitions.RLock()
+	defer definitions.RUnlock()
+	return definitions.schemes
+}
+
+// findScheme returns the scheme of the pattern type in the schemes, or nil
+func findScheme(schemes []*scheme, patternType string) *scheme {
+	for _, s := range schemes {
+		if s.tagEncoding != nil && strings.TrimSuffix(s.tagEncoding.PrefixMatch, ":") == "urn:epc:tag:"+patternType {
+			return s
+		}
+	}
+	return nil
+}
+
+// loadEPCTagDataTranslationDir parses the GS1 TDT definition files (*.xml) in dir
+func loadEPCTagDataTranslationDir(dir string) ([]*scheme, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+	return loadEPCTagDataTranslationFS(os.DirFS(dir), ".")
+}
+
+// loadEPCTagDataTranslationFS parses the GS1 TDT definition files (*.xml) in dir of fsys
+func loadEPCTagDataTranslationFS(fsys fs.FS, dir string) ([]*scheme, error) {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.xml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	var schemes []*scheme
+	for _, f := range files {
+		data, err := fs.ReadFile(fsys, f)
+		if err != nil {
+			return nil, err
+		}
+		s, err := parseEPCTagDataTranslation(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+		schemes = mergeSchemes(schemes, s)
+	}
+	return schemes, nil
+}
+
+// mergeSchemes returns a new slice of the schemes in added followed by the ones in base without the same name,
+// the schemes added take precedence in matching the EPC headers
+func mergeSchemes(base []*scheme, added []*scheme) []*scheme {
+	merged := append([]*scheme{}, added...)
+	for _, b := range base {
+		replaced := false
+		for _, s := range added {
+			if s.Name == b.Name {
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, b)
+		}
+	}
+	return merged
+}
+
+// parseEPCTagDataTranslation parses a GS1 TDT definition file and prepares its schemes
+func parseEPCTagDataTranslation(data []byte) ([]*scheme, error) {
+	tdt := epcTagDataTranslation{}
+	if err := xml.Unmarshal(data, &tdt); err != nil {
+		return nil, err
+	}
+	if len(tdt.Schemes) == 0 {
+		return nil, errors.New("no scheme found")
+	}
+	for _, s := range tdt.Schemes {
+		if err := s.prepare(); err != nil {
+			return nil, fmt.Errorf("scheme %s: %w", s.Name, err)
+		}
+	}
+	return tdt.Schemes, nil
+}
+
+// prepare compiles the patterns and the grammars in the scheme
+func (s *scheme) prepare() error {
+	if n, err := strconv.Atoi(s.TagLength); err == nil {
+		s.tagLength = n
+	}
+	for _, l := range s.Levels {
+		switch l.Type {
+		case levelBinary:
+			s.binary = l
+		case levelTagEncoding:
+			s.tagEncoding = l
+		case levelPureIdentity:
+			s.pureIdentity = l
+		default:
+			continue
+		}
+		for _, opt := range l.Options {
+			if err := opt.prepare(l.Type); err != nil {
+				return fmt.Errorf("%s option %s: %w", l.Type, opt.OptionKey, err)
+			}
+		}
+		for _, r := range l.Rules {
+			if err := r.prepare(); err != nil {
+				return fmt.Errorf("%s rule %s: %w", l.Type, r.NewFieldName, err)
+			}
+		}
+		sort.SliceStable(l.Rules, func(i, j int) bool { return l.Rules[i].Seq < l.Rules[j].Seq })
+	}
+	if s.binary == nil || s.pureIdentity == nil {
+		return errors.New("both BINARY and PURE_IDENTITY levels are required")
+	}
+	if s.binary.PrefixMatch == "" || strings.Trim(s.binary.PrefixMatch, "01") != "" {
+		return fmt.Errorf("invalid BINARY prefixMatch: %q", s.binary.PrefixMatch)
+	}
+	for _, opt := range s.binary.Options {
+		if s.pureIdentity.option(opt.OptionKey) == nil {
+			return fmt.Errorf("no PURE_IDENTITY option %s", opt.OptionKey)
+		}
+	}
+	return nil
+}
+
+// prepare compiles the pattern, the grammar and the character sets in the option
+func (opt *option) prepare(levelType string) (err error) {
+	if opt.pattern, err = regexp.Compile("^(?:" + opt.Pattern + ")$"); err != nil {
+		return err
+	}
+	if opt.grammar, err = parseGrammar(opt.Grammar); err != nil {
+		return err
+	}
+	opt.fields = map[string]*field{}
+	for _, f := range opt.Fields {
+		if f.Seq < 1 || f.Seq > opt.pattern.NumSubexp() {
+			return fmt.Errorf("field %s: seq %d out of the pattern", f.Name, f.Seq)
+		}
+		if f.CharacterSet != "" {
+			if f.characterSet, err = regexp.Compile("^(?:" + f.CharacterSet + ")$"); err != nil {
+				return fmt.Errorf("field %s: %w", f.Name, err)
+			}
+		}
+		if f.Compaction != "" {
+			switch f.Compaction {
+			case "5bit", "6bit", "7bit", "8bit":
+				f.charBits = int(f.Compaction[0] - '0')
+			default:
+				return fmt.Errorf("field %s: unsupported compaction %s", f.Name, f.Compaction)
+			}
+		}
+		// the compacted characters may take a variable number of bits, up to the length
+		if levelType == levelBinary && f.BitLength <= 0 && f.charBits == 0 {
+			return fmt.Errorf("field %s: no bitLength", f.Name)
+		}
+		opt.fields[f.Name] = f
+	}
+	for _, t := range opt.grammar {
+		if t.field != "" && opt.fields[t.field] == nil {
+			return fmt.Errorf("unknown field in grammar: %s", t.field)
+		}
+	}
+	return nil
+}
+
+// prepare parses the function of the rule, only SUBSTR, CONCAT and LENGTH are supported
+func (r *rule) prepare() (err error) {
+	if r.Type != ruleExtract && r.Type != ruleFormat {
+		return fmt.Errorf("unsupported rule type %s", r.Type)
+	}
+	if r.NewFieldName == "" {
+		return errors.New("no newFieldName")
+	}
+	if r.CharacterSet != "" {
+		if r.characterSet, err = regexp.Compile("^(?:" + r.CharacterSet + ")$"); err != nil {
+			return err
+		}
+	}
+	open := strings.IndexByte(r.Function, '(')
+	if open < 0 || !strings.HasSuffix(r.Function, ")") {
+		return fmt.Errorf("invalid function %s", r.Function)
+	}
+	r.function = strings.TrimSpace(r.Function[:open])
+	for _, a := range strings.Split(r.Function[open+1:len(r.Function)-1], ",") {
+		r.args = append(r.args, strings.TrimSpace(a))
+	}
+	switch {
+	case r.function == "SUBSTR" && (len(r.args) == 2 || len(r.args) == 3):
+		for _, a := range r.args[1:] {
+			if _, err := strconv.Atoi(a); err != nil {
+				return fmt.Errorf("invalid function %s", r.Function)
+			}
+		}
+	case r.function == "CONCAT":
+	case r.function == "LENGTH" && len(r.args) == 1:
+	default:
+		return fmt.Errorf("unsupported function %s", r.Function)
+	}
+	return nil
+}
+
+// parseGrammar splits the grammar into the quoted literals and the field names
+func parseGrammar(grammar string) ([]grammarToken, error) {
+	var tokens []grammarToken
+	for i := 0; i < len(grammar); {
+		switch grammar[i] {
+		case ' ', '\t', '\n':
+			i++
+		case '\'':
+			end := strings.IndexByte(grammar[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated literal in grammar: %s", grammar)
+			}
+			tokens = append(tokens, grammarToken{literal: grammar[i+1 : i+1+end]})
+			i += end + 2
+		default:
+			end := strings.IndexAny(grammar[i:], " \t\n'")
+			if end < 0 {
+				end = len(grammar) - i
+			}
+			tokens = append(tokens, grammarToken{field: grammar[i : i+end]})
+			i += end
+		}
+	}
+	return tokens, nil
+}
+
+// option returns the option of the optionKey in the level, or nil
+func (l *level) option(key string) *option {
+	for _, opt := range l.Options {
+		if opt.OptionKey == key {
+			return opt
+		}
+	}
+	return nil
+}
+
+// applyRules derives the fields by the rules of the type in the level,
+// a rule is skipped when any field it takes is missing, e.g., in a partial pattern
+func (l *level) applyRules(ruleType string, values map[string]string) error {
+	for _, r := range l.Rules {
+		if r.Type != ruleType {
+			continue
+		}
+		v, ok, err := r.apply(values)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if r.characterSet != nil && !r.characterSet.MatchString(v) {
+			return fmt.Errorf("invalid %s: %q", r.NewFieldName, v)
+		}
+		values[r.NewFieldName] = v
+	}
+	return nil
+}
+
+// apply returns the value of the function with the values of the fields,
+// false if any of the fields is missing
+func (r *rule) apply(values map[string]string) (string, bool, error) {
+	args := make([]string, len(r.args))
+	for i, a := range r.args {
+		switch {
+		case len(a) > 1 && a[0] == '\'' && a[len(a)-1] == '\'':
+			args[i] = a[1 : len(a)-1]
+		case strings.Trim(a, "0123456789") == "":
+			args[i] = a
+		default:
+			v, ok := values[a]
+			if !ok {
+				return "", false, nil
+			}
+			args[i] = v
+		}
+	}
+	switch r.function {
+	case "SUBSTR":
+		start, _ := strconv.Atoi(args[1])
+		end := len(args[0])
+		if len(args) == 3 {
+			n, _ := strconv.Atoi(args[2])
+			end = start + n
+		}
+		if start > end || end > len(args[0]) {
+			return "", false, fmt.Errorf("invalid %s for %s: %q", r.NewFieldName, r.Function, args[0])
+		}
+		return args[0][start:end], true, nil
+	case "CONCAT":
+		return strings.Join(args, ""), true, nil
+	default: // LENGTH
+		return strconv.Itoa(len(args[0])), true, nil
+	}
+}
+
+// matchHeader checks if the id starts with the BINARY prefixMatch of the scheme
+func (s *scheme) matchHeader(id []byte) bool {
+	pm := s.binary.PrefixMatch
+	for i := 0; i < len(pm); i++ {
+		if i/8 >= len(id) {
+			return false
+		}
+		if (id[i/8]>>uint(7-i%8))&1 != pm[i]-'0' {
+			return false
+		}
+	}
+	return true
+}
+
+// translate returns the URI in the level, e.g., PURE_IDENTITY, of the id matched with matchHeader
+func (s *scheme) translate(id []byte, l *level) (string, error) {
+	if l == nil {
+		return "", fmt.Errorf("%s: no level to translate into", s.Name)
+	}
+	opt, values, err := s.decode(id)
+	if err != nil {
+		return "", err
+	}
+	to := l.option(opt.OptionKey)
+	if to == nil {
+		return "", fmt.Errorf("%s: no %s option %s", s.Name, l.Type, opt.OptionKey)
+	}
+	if err := l.applyRules(ruleFormat, values); err != nil {
+		return "", fmt.Errorf("%s: %w", s.Name, err)
+	}
+	uri, err := to.format(opt, values, false)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", s.Name, err)
+	}
+	return uri, nil
+}
+
+// decode returns the BINARY option matching the id and the values of the fields in it,
+// along with the ones extracted by the rules
+func (s *scheme) decode(id []byte) (*option, map[string]string, error) {
+	var sb strings.Builder
+	for _, b := range id {
+		fmt.Fprintf(&sb, "%08b", b)
+	}
+	bs := sb.String()
+	if s.tagLength != 0 && len(bs) > s.tagLength {
+		bs = bs[:s.tagLength]
+	}
+	for _, opt := range s.binary.Options {
+		m := opt.pattern.FindStringSubmatch(bs)
+		if m == nil {
+			continue
+		}
+		values := map[string]string{}
+		for _, f := range opt.Fields {
+			v, err := f.decode(m[f.Seq])
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", s.Name, err)
+			}
+			values[f.Name] = v
+		}
+		if err := s.binary.applyRules(ruleExtract, values); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", s.Name, err)
+		}
+		return opt, values, nil
+	}
+	return nil, nil, fmt.Errorf("%s: Invalid ID", s.Name)
+}
+
+// prefixFilter returns the binary prefix filter for the fields in the tag URI pattern
+func (s *scheme) prefixFilter(fields []string) (string, error) {
+	te, values, err := s.parseTagEncodingFields(fields)
+	if err != nil {
+		return "", err
+	}
+	bin := s.binary.option(te.OptionKey)
+	if bin == nil {
+		return "", fmt.Errorf("%s: no BINARY option %s", s.Name, te.OptionKey)
+	}
+	if err := s.binary.applyRules(ruleFormat, values); err != nil {
+		return "", fmt.Errorf("%s: %w", s.Name, err)
+	}
+	var bs strings.Builder
+	pending := ""
+	for i, t := range bin.grammar {
+		if t.field == "" {
+			// the literal terminates the variable number of bits in the field before it
+			if i > 0 && pending == "" && bin.grammar[i-1].field != "" && bin.fields[bin.grammar[i-1].field].BitLength == 0 {
+				bs.WriteString(t.literal)
+				continue
+			}
+			pending += t.literal
+			continue
+		}
+		v, ok := values[t.field]
+		if !ok {
+			return bs.String(), nil
+		}
+		bits, err := bin.fields[t.field].encode(te.fields[t.field], v)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", s.Name, err)
+		}
+		bs.WriteString(pending + bits)
+		pending = ""
+	}
+	return bs.String() + pending, nil
+}
+
+// pureIdentityPrefix returns the prefix of the pure identities for the fields in the tag URI pattern
+func (s *scheme) pureIdentityPrefix(fields []string) (string, error) {
+	te, values, err := s.parseTagEncodingFields(fields)
+	if err != nil {
+		return "", err
+	}
+	pi := s.pureIdentity.option(te.OptionKey)
+	if pi == nil {
+		return "", fmt.Errorf("%s: no PURE_IDENTITY option %s", s.Name, te.OptionKey)
+	}
+	if err := s.pureIdentity.applyRules(ruleFormat, values); err != nil {
+		return "", fmt.Errorf("%s: %w", s.Name, err)
+	}
+	return pi.format(te, values, true)
+}
+
+// parseTagEncodingFields returns the TAG_ENCODING option the fields conform to
+// and the values of the fields given, along with the ones extracted by the rules,
+// the fields are joined with . and split by the literals in the grammar
+func (s *scheme) parseTagEncodingFields(fields []string) (*option, map[string]string, error) {
+	if s.tagEncoding == nil {
+		return nil, nil, fmt.Errorf("%s: no TAG_ENCODING level", s.Name)
+	}
+	for _, opt := range s.tagEncoding.Options {
+		values, ok := opt.parse(strings.Join(fields, "."))
+		if !ok {
+			continue
+		}
+		if err := s.tagEncoding.applyRules(ruleExtract, values); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", s.Name, err)
+		}
+		// the optionKey of the scheme selects the option if given or extracted,
+		// e.g., gs1companyprefixlength from the company prefix
+		if key, ok := values[s.OptionKey]; ok && key != opt.OptionKey {
+			continue
+		}
+		return opt, values, nil
+	}
+	return nil, nil, fmt.Errorf("%s: no option for the fields %q", s.Name, fields)
+}
+
+// parse returns the values of the fields in v, the grammar of the option after the leading literals,
+// up to the last field given, false if v does not conform to the option
+func (opt *option) parse(v string) (map[string]string, bool) {
+	grammar := opt.grammar
+	for len(grammar) > 0 && grammar[0].field == "" {
+		grammar = grammar[1:]
+	}
+	values := map[string]string{}
+	for i, t := range grammar {
+		if v == "" {
+			break
+		}
+		if t.field == "" {
+			if !strings.HasPrefix(v, t.literal) {
+				return nil, false
+			}
+			v = v[len(t.literal):]
+			continue
+		}
+		// the field takes the rest up to the next literal
+		end := len(v)
+		if i+1 < len(grammar) && grammar[i+1].field == "" {
+			if n := strings.Index(v, grammar[i+1].literal); n >= 0 {
+				end = n
+			}
+		}
+		if !opt.fields[t.field].conform(v[:end]) {
+			return nil, false
+		}
+		values[t.field] = v[:end]
+		v = v[end:]
+	}
+	return values, v == ""
+}
+
+// partition returns the partition value, i.e., the literal bits before the company prefix
+// in the BINARY option, or -1 if none
+func (opt *option) partition() int {
+	for i, t := range opt.grammar {
+		if t.field != "gs1companyprefix" || i == 0 || opt.grammar[i-1].field != "" {
+			continue
+		}
+		if p, err := strconv.ParseUint(opt.grammar[i-1].literal, 2, 8); err == nil {
+			return int(p)
+		}
+	}
+	return -1
+}
+
+// segments returns the number of the . separated segments in the URI of the option after the leading literals
+func (opt *option) segments() int {
+	n := 1
+	for i, t := range opt.grammar {
+		if i > 0 && t.field == "" && opt.grammar[i-1].field != "" {
+			n += strings.Count(t.literal, ".")
+		}
+	}
+	return n
+}
+
+// format writes the grammar of the option with the values from the fields in src,
+// up to the last value given if partial
+func (opt *option) format(src *option, values map[string]string, partial bool) (string, error) {
+	var sb strings.Builder
+	pending := ""
+	for _, t := range opt.grammar {
+		if t.field == "" {
+			pending += t.literal
+			continue
+		}
+		v, ok := values[t.field]
+		if !ok {
+			if partial {
+				return sb.String(), nil
+			}
+			return "", fmt.Errorf("no value for %s", t.field)
+		}
+		sf := src.fields[t.field]
+		if sf != nil && sf.charBits != 0 {
+			v = unescapeURIString(v)
+		}
+		f := opt.fields[t.field]
+		v = f.pad(sf.unpad(v))
+		if f.Length > 0 && len(v) > f.Length {
+			return "", fmt.Errorf("too long %s: %v", t.field, v)
+		}
+		if sf != nil && sf.charBits != 0 {
+			v = escapeURIString(v)
+		}
+		sb.WriteString(pending + v)
+		pending = ""
+	}
+	return sb.String() + pending, nil
+}
+
+// conform checks if the value fits the character set and the length of the field,
+// the value can be shorter than the length to be padded
+func (f *field) conform(v string) bool {
+	if f == nil {
+		return false
+	}
+	if f.characterSet != nil && !f.characterSet.MatchString(v) {
+		return false
+	}
+	if f.Length > 0 && (len(v) > f.Length || f.PadChar == "" && len(v) < f.Length) {
+		return false
+	}
+	return true
+}
+
+// unpad removes the padChar of the field from the value
+func (f *field) unpad(v string) string {
+	if f == nil || f.PadChar == "" {
+		return v
+	}
+	if f.PadDir == "RIGHT" {
+		return strings.TrimRight(v, f.PadChar)
+	}
+	return strings.TrimLeft(v, f.PadChar)
+}
+
+// pad fills the value with the padChar of the field up to its length
+func (f *field) pad(v string) string {
+	if f == nil || f.PadChar == "" || len(v) >= f.Length {
+		return v
+	}
+	padding := strings.Repeat(f.PadChar, f.Length-len(v))
+	if f.PadDir == "RIGHT" {
+		return v + padding
+	}
+	return padding + v
+}
+
+// decode returns the value of the bits in the BINARY field
+func (f *field) decode(bits string) (string, error) {
+	if f.charBits == 0 {
+		z, _ := new(big.Int).SetString("0"+bits, 2)
+		v := f.pad(z.String())
+		if f.characterSet != nil && !f.characterSet.MatchString(v) {
+			return "", fmt.Errorf("invalid %s: %q", f.Name, v)
+		}
+		return v, nil
+	}
+	var buf []byte
+	for i := 0; i+f.charBits <= len(bits); i += f.charBits {
+		c, _ := strconv.ParseUint(bits[i:i+f.charBits], 2, 8)
+		if c == 0 {
+			if f.BitPadDir == "LEFT" {
+				continue
+			}
+			break
+		}
+		switch f.charBits {
+		case 5:
+			c |= 64
+		case 6:
+			if c&32 == 0 {
+				c |= 64
+			}
+		}
+		buf = append(buf, byte(c))
+	}
+	v := string(buf)
+	if f.characterSet != nil && !f.characterSet.MatchString(v) {
+		return "", fmt.Errorf("invalid %s: %q", f.Name, v)
+	}
+	if f.Length > 0 && len(v) > f.Length {
+		return "", fmt.Errorf("too long %s: %q", f.Name, v)
+	}
+	return v, nil
+}
+
+// encode returns the bits of the value given in the src field for the BINARY field
+func (f *field) encode(src *field, v string) (string, error) {
+	if f.charBits != 0 {
+		v = unescapeURIString(v)
+	}
+	v = f.pad(src.unpad(v))
+	if f.characterSet != nil && !f.characterSet.MatchString(v) {
+		return "", fmt.Errorf("invalid %s: %q", f.Name, v)
+	}
+	if f.charBits == 0 {
+		z, ok := new(big.Int).SetString("0"+v, 10)
+		if !ok || z.BitLen() > f.BitLength {
+			return "", fmt.Errorf("invalid %s: %v", f.Name, v)
+		}
+		bits := z.Text(2)
+		return strings.Repeat("0", f.BitLength-len(bits)) + bits, nil
+	}
+	if f.Length > 0 && len(v) > f.Length {
+		return "", fmt.Errorf("too long %s: %v", f.Name, v)
+	}
+	var bits strings.Builder
+	for i := 0; i < len(v); i++ {
+		fmt.Fprintf(&bits, "%0*b", f.charBits, v[i]&(1<<uint(f.charBits)-1))
+	}
+	// the characters take as many bits as they need without the bitLength
+	if f.BitLength == 0 {
+		return bits.String(), nil
+	}
+	if bits.Len() > f.BitLength {
+		return "", fmt.Errorf("too long %s: %v", f.Name, v)
+	}
+	padding := strings.Repeat("0", f.BitLength-bits.Len())
+	if f.BitPadDir == "LEFT" {
+		return padding + bits.String(), nil
+	}
+	return bits.String() + padding, nil
+}
@@ -0,0 +1,271 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package tdt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCore_Translate_bundledDefinitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		pc      []byte
+		id      []byte
+		want    string
+		wantErr bool
+	}{
+		{"GID-96_95100000_12345_400", []byte{48, 0}, []byte{53, 90, 177, 198, 0, 3, 3, 144, 0, 0, 1, 144}, "urn:epc:id:gid:95100000.12345.400", false},
+		{"GID-96_short", []byte{48, 0}, []byte{53, 90, 177, 198, 0, 3, 3, 144, 0, 0, 1}, "", true},
+	}
+	c := NewCore()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Translate(tt.pc, tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Core.Translate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Core.Translate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMakePrefixFilterString_definitions(t *testing.T) {
+	tests := []struct {
+		name        string
+		patternType string
+		fields      []string
+		want        string
+		wantErr     bool
+	}{
+		{"GID-96_95100000", "gid-96", []string{"95100000"}, "001101010101101010110001110001100000", false},
+		{"GID-96_95100000_12345", "gid-96", []string{"95100000", "12345"}, "001101010101101010110001110001100000000000000011000000111001", false},
+		{"GID-96_95100000_12345_400", "gid-96", []string{"95100000", "12345", "400"}, "001101010101101010110001110001100000000000000011000000111001" + "000000000000000000000000000110010000", false},
+		{"GID-96_too_large", "gid-96", []string{"268435456"}, "", true},
+		{"GID-96_not_a_number", "gid-96", []string{"951A"}, "", true},
+		{"GSRN-96_0_5_0614141", "gsrn-96", []string{"0", "0614141"}, "00101101000101000010010101111011111101", false},
+		{"GSRN-96_0_5_0614141_1234567890", "gsrn-96", []string{"0", "0614141", "1234567890"}, "001011010001010000100101011110111111010001001001100101100000001011010010" + strings.Repeat("0", 24), false},
+		{"GSRN-96_too_many_fields", "gsrn-96", []string{"0", "0614141", "1234567890", "1"}, "", true},
+		{"SGCN-96_3_5_4012345_67890_04711", "sgcn-96", []string{"3", "4012345", "67890", "04711"}, "001111110111010011110100111001001110011000010010011001000000000000000000000000011001100100000111", false},
+		{"SGCN-96_invalid_numeric_string", "sgcn-96", []string{"3", "4012345", "67890", "47A"}, "", true},
+		{"SGLN-96_3_5_0614141_12345", "sgln-96", []string{"3", "0614141", "12345"}, "0011001001110100001001010111101111110100011000000111001", false},
+		{"SGLN-96_3_5_0614141_12345_400", "sgln-96", []string{"3", "0614141", "12345", "400"}, "001100100111010000100101011110111111010001100000011100100000000000000000000000000000000110010000", false},
+		{"SGLN-96_1_0_012345678901__0", "sgln-96", []string{"1", "012345678901", "", "0"}, "001100100010000000001011011111110111000001110000110101000000000000000000000000000000000000000000", false},
+		{"SGLN-96_invalid_company_prefix", "sgln-96", []string{"3", "06141"}, "", true},
+		{"SGLN-195_3_5_0614141", "sgln-195", []string{"3", "0614141"}, "00111001011101000010010101111011111101", false},
+		{"SGLN-195_3_5_0614141_12345_32a%2Fb", "sgln-195", []string{"3", "0614141", "12345", "32a%2Fb"}, "001110010111010000100101011110111111010001100000011100101100110110010110000101011111100010" + strings.Repeat("0", 105), false},
+		{"SGLN-195_invalid_character", "sgln-195", []string{"3", "0614141", "12345", "a#b"}, "", true},
+		{"CPI-96_3_5_0614141_123457", "cpi-96", []string{"3", "0614141", "123457"}, "00111100011101000010010101111011111101000000000011110001001000001", false},
+		{"CPI-96_3_5_0614141_123457_12345", "cpi-96", []string{"3", "0614141", "123457", "12345"}, "00111100011101000010010101111011111101000000000011110001001000001" + "0000000000000000011000000111001", false},
+		{"CPI-96_invalid_company_prefix", "cpi-96", []string{"3", "06141"}, "", true},
+		{"CPI-var_3_5_0614141_5PQ7%2FZ43", "cpi-var", []string{"3", "0614141", "5PQ7%2FZ43"}, "00111101011101000010010101111011111101110101010000010001110111101111011010110100110011000000", false},
+		{"CPI-var_3_5_0614141_5PQ7%2FZ43_12345", "cpi-var", []string{"3", "0614141", "5PQ7%2FZ43", "12345"}, "00111101011101000010010101111011111101110101010000010001110111101111011010110100110011000000" + "0000000000000000000000000011000000111001", false},
+		{"CPI-var_invalid_character", "cpi-var", []string{"3", "0614141", "5pq7"}, "", true},
+		{"GDTI-174_3_5_0614141_12345_32a%2Fb", "gdti-174", []string{"3", "0614141", "12345", "32a%2Fb"}, "00111110011101000010010101111011111101000110000001110010110011011001011000010101111110001" + strings.Repeat("0", 85), false},
+		{"GDTI-174_too_long_serial", "gdti-174", []string{"3", "0614141", "12345", "ABCDEFGHIJKLMNOPQR"}, "", true},
+		{"GDTI-96_3_5_0614141_12345", "gdti-96", []string{"3", "0614141", "12345"}, "0010110001110100001001010111101111110100011000000111001", false},
+		{"GDTI-96_3_5_0614141_12345_400", "gdti-96", []string{"3", "0614141", "12345", "400"}, "001011000111010000100101011110111111010001100000011100100000000000000000000000000000000110010000", false},
+		{"GIAI-202_3_5_0614141", "giai-202", []string{"3", "0614141"}, "00111000011101000010010101111011111101", false},
+		{"GIAI-202_3_5_0614141_32a%2Fb", "giai-202", []string{"3", "0614141", "32a%2Fb"}, "001110000111010000100101011110111111010110011011001011000010101111110001" + strings.Repeat("0", 130), false},
+		{"GIAI-202_invalid_company_prefix", "giai-202", []string{"3", "06141"}, "", true},
+		{"GRAI-170_3_5_0614141_12345", "grai-170", []string{"3", "0614141", "12345"}, "0011011101110100001001010111101111110100000011000000111001", false},
+		{"GRAI-170_3_5_0614141_12345_32a%2Fb", "grai-170", []string{"3", "0614141", "12345", "32a%2Fb"}, "0011011101110100001001010111101111110100000011000000111001011001101100101100001010111111000100" + strings.Repeat("0", 76), false},
+		{"GRAI-170_too_long_serial", "grai-170", []string{"3", "0614141", "12345", "ABCDEFGHIJKLMNOPQ"}, "", true},
+		{"SGTIN-198_3_5_0614141", "sgtin-198", []string{"3", "0614141"}, "00110110011101000010010101111011111101", false},
+		{"SGTIN-198_3_5_0614141_812345_32a%2Fb", "sgtin-198", []string{"3", "0614141", "812345", "32a%2Fb"}, "001101100111010000100101011110111111011100011001010011100101100110110010110000101011111100010" + strings.Repeat("0", 105), false},
+		{"SGTIN-198_1_4_12345678_00001_A.1", "sgtin-198", []string{"1", "12345678", "00001", "A", "1"}, "0011011000110000010111100011000010100111000000000000000001100000101011100110001" + strings.Repeat("0", 119), false},
+		{"SGTIN-198_too_long_serial", "sgtin-198", []string{"3", "0614141", "812345", "123456789012345678901"}, "", true},
+		{"SGTIN-198_invalid_character", "sgtin-198", []string{"3", "0614141", "812345", "a#b"}, "", true},
+		{"SGTIN-198_invalid_company_prefix", "sgtin-198", []string{"3", "06141"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MakePrefixFilterString(tt.patternType, tt.fields)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MakePrefixFilterString() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("MakePrefixFilterString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMakePureIdentityPrefix(t *testing.T) {
+	tests := []struct {
+		name        string
+		patternType string
+		fields      []string
+		want        string
+		wantErr     bool
+	}{
+		{"GID-96_95100000", "gid-96", []string{"95100000"}, "urn:epc:id:gid:95100000", false},
+		{"GID-96_95100000_12345_400", "gid-96", []string{"95100000", "12345", "400"}, "urn:epc:id:gid:95100000.12345.400", false},
+		{"SGTIN-96_3_0614141_812345", "sgtin-96", []string{"3", "0614141", "812345"}, "urn:epc:id:sgtin:0614141.812345", false},
+		{"SGCN-96_3_4012345_67890_04711", "sgcn-96", []string{"3", "4012345", "67890", "04711"}, "urn:epc:id:sgcn:4012345.67890.04711", false},
+		{"unknown", "unknown-96", []string{"3"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MakePureIdentityPrefix(tt.patternType, tt.fields)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MakePureIdentityPrefix() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("MakePureIdentityPrefix() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterEPCTagDataTranslation(t *testing.T) {
+	// the Cores created before share the registered definitions with the patterns
+	c := NewCore()
+	if err := RegisterEPCTagDataTranslation("testdata"); err != nil {
+		t.Fatalf("RegisterEPCTagDataTranslation() error = %v", err)
+	}
+	if err := RegisterEPCTagDataTranslation("no-such-dir"); err == nil {
+		t.Error("RegisterEPCTagDataTranslation() loaded no-such-dir without error")
+	}
+	got, err := MakePrefixFilterString("usdod-96", []string{"0", "2S194"})
+	if err != nil {
+		t.Fatalf("MakePrefixFilterString() error = %v", err)
+	}
+	if want := "001011110000001000000011001001010011001100010011100100110100"; got != want {
+		t.Errorf("MakePrefixFilterString() = %v, want %v", got, want)
+	}
+	// the filter value placed by the definition takes * and ranges too
+	if !HasFilter("usdod-96") {
+		t.Errorf("HasFilter() = false, want true")
+	}
+	filters, err := MakeFilterStrings("usdod-96", []string{"[1-2]", "2S194"})
+	if err != nil {
+		t.Fatalf("MakeFilterStrings() error = %v", err)
+	}
+	if want := []string{"00101111" + "0001" + got[12:], "00101111" + "0010" + got[12:]}; !reflect.DeepEqual(filters, want) {
+		t.Errorf("MakeFilterStrings() = %v, want %v", filters, want)
+	}
+	// the filter value right after the header takes the x bits for *
+	if filters, err = MakeFilterStrings("usdod-96", []string{"*", "2S194"}); err != nil || !reflect.DeepEqual(filters, []string{"00101111" + "xxxx" + got[12:]}) {
+		t.Errorf("MakeFilterStrings() = %v, %v, want %v", filters, err, "00101111xxxx"+got[12:])
+	}
+	pc, id := []byte{48, 0}, []byte{47, 2, 3, 37, 51, 19, 147, 66, 223, 220, 28, 53}
+	for _, core := range []*Core{c, NewCore()} {
+		pi, err := core.Translate(pc, id)
+		if err != nil {
+			t.Fatalf("Core.Translate() error = %v", err)
+		}
+		if want := "urn:epc:id:usdod:2S194.12345678901"; pi != want {
+			t.Errorf("Core.Translate() = %v, want %v", pi, want)
+		}
+		tagURI, err := core.TranslateURI(pc, id, TagURI)
+		if err != nil {
+			t.Fatalf("Core.TranslateURI() error = %v", err)
+		}
+		if want := "urn:epc:tag:usdod-96:0.2S194.12345678901"; tagURI != want {
+			t.Errorf("Core.TranslateURI() = %v, want %v", tagURI, want)
+		}
+	}
+}
+
+func TestCore_LoadEPCTagDataTranslation(t *testing.T) {
+	c := NewCore()
+	if err := c.LoadEPCTagDataTranslation("testdata"); err != nil {
+		t.Fatalf("Core.LoadEPCTagDataTranslation() error = %v", err)
+	}
+	if err := c.LoadEPCTagDataTranslation("no-such-dir"); err == nil {
+		t.Error("Core.LoadEPCTagDataTranslation() loaded no-such-dir without error")
+	}
+	// the definitions loaded are registered for the patterns too
+	if _, err := MakePrefixFilterString("usdod-96", []string{"0", "2S194"}); err != nil {
+		t.Errorf("MakePrefixFilterString() error = %v", err)
+	}
+}
+
+func TestParseEPCTagDataTranslation(t *testing.T) {
+	scheme := func(binary string, pureIdentity string) string {
+		return `<epcTagDataTranslation><scheme name="TEST-8" tagLength="8">` + binary + pureIdentity + `</scheme></epcTagDataTranslation>`
+	}
+	binary := `<level type="BINARY" prefixMatch="1111"><option optionKey="1" pattern="1111([01]{4})" grammar="'1111' serial"><field seq="1" bitLength="4" name="serial"/></option></level>`
+	pureIdentity := `<level type="PURE_IDENTITY" prefixMatch="urn:epc:id:test"><option optionKey="1" pattern="urn:epc:id:test:([0-9]+)" grammar="'urn:epc:id:test:' serial"><field seq="1" name="serial"/></option></level>`
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"valid", scheme(binary, pureIdentity), ""},
+		{"not xml", "<epcTagDataTranslation>", "XML syntax error"},
+		{"no scheme", "<epcTagDataTranslation/>", "no scheme found"},
+		{"no PURE_IDENTITY", scheme(binary, ""), "both BINARY and PURE_IDENTITY levels are required"},
+		{"invalid prefixMatch", scheme(strings.Replace(binary, `prefixMatch="1111"`, `prefixMatch="11x1"`, 1), pureIdentity), "invalid BINARY prefixMatch"},
+		{"unknown field in grammar", scheme(strings.Replace(binary, `' serial"`, `' serial extra"`, 1), pureIdentity), "unknown field in grammar: extra"},
+		{"no bitLength", scheme(strings.Replace(binary, ` bitLength="4"`, "", 1), pureIdentity), "no bitLength"},
+		{"unsupported compaction", scheme(strings.Replace(binary, ` bitLength="4"`, ` bitLength="4" compaction="4bit"`, 1), pureIdentity), "unsupported compaction 4bit"},
+		{"no PURE_IDENTITY option", scheme(binary, strings.Replace(pureIdentity, `optionKey="1"`, `optionKey="2"`, 1)), "no PURE_IDENTITY option 1"},
+		{"rule", scheme(strings.Replace(binary, `</level>`, `<rule type="EXTRACT" seq="1" newFieldName="last" function="SUBSTR(serial,1)"/></level>`, 1), pureIdentity), ""},
+		{"unsupported rule type", scheme(strings.Replace(binary, `</level>`, `<rule type="VALIDATE" seq="1" newFieldName="last" function="SUBSTR(serial,1)"/></level>`, 1), pureIdentity), "unsupported rule type VALIDATE"},
+		{"unsupported rule function", scheme(strings.Replace(binary, `</level>`, `<rule type="EXTRACT" seq="1" newFieldName="checkdigit" function="GS1CHECKSUM(serial)"/></level>`, 1), pureIdentity), "unsupported function GS1CHECKSUM(serial)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseEPCTagDataTranslation([]byte(tt.data))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("parseEPCTagDataTranslation() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseEPCTagDataTranslation() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLevel_applyRules(t *testing.T) {
+	rules := []*rule{
+		{Type: ruleExtract, Seq: 1, NewFieldName: "serial", CharacterSet: "[0-9]*", Function: "SUBSTR(serialwithone,1)"},
+		{Type: ruleFormat, Seq: 1, NewFieldName: "serialwithone", Function: "CONCAT('1',serial)"},
+		{Type: ruleExtract, Seq: 2, NewFieldName: "length", Function: "LENGTH(serial)"},
+	}
+	l := &level{Type: levelBinary, Rules: rules}
+	for _, r := range rules {
+		if err := r.prepare(); err != nil {
+			t.Fatalf("rule.prepare() error = %v", err)
+		}
+	}
+	tests := []struct {
+		name     string
+		ruleType string
+		values   map[string]string
+		want     map[string]string
+		wantErr  bool
+	}{
+		{"extract", ruleExtract, map[string]string{"serialwithone": "104711"}, map[string]string{"serialwithone": "104711", "serial": "04711", "length": "5"}, false},
+		{"format", ruleFormat, map[string]string{"serial": "04711"}, map[string]string{"serial": "04711", "serialwithone": "104711"}, false},
+		{"missing field", ruleFormat, map[string]string{}, map[string]string{}, false},
+		{"invalid character", ruleExtract, map[string]string{"serialwithone": "1A"}, nil, true},
+		{"out of range", ruleExtract, map[string]string{"serialwithone": ""}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := l.applyRules(tt.ruleType, tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("level.applyRules() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.want != nil && !reflect.DeepEqual(tt.values, tt.want) {
+				t.Errorf("level.applyRules() = %v, want %v", tt.values, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package tdt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/iomz/go-llrp/binutil"
+)
+
+// Encode takes a urn:epc:tag: or urn:epc:id: URI and returns the PC bits and the EPC in binary,
+// the filter value and the patternType, e.g., sgtin-96, are required for a pure identity
+// and must agree with the ones in a tag URI if given
+func (c *Core) Encode(uri string, filter string, patternType string) ([]byte, []byte, error) {
+	seq := strings.SplitN(uri, ":", 5)
+	if len(seq) != 5 || seq[0] != "urn" || seq[1] != "epc" {
+		return nil, nil, fmt.Errorf("invalid EPC URI: %v", uri)
+	}
+	var fields []string
+	var s *scheme
+	switch seq[2] {
+	case "tag":
+		fields = strings.Split(seq[4], ".")
+		if patternType != "" && patternType != seq[3] {
+			return nil, nil, fmt.Errorf("patternType %v does not match %v", patternType, uri)
+		}
+		patternType = seq[3]
+		s = findScheme(c.schemes(), patternType)
+		if hasFilter(s) && filter != "" && filter != fields[0] {
+			return nil, nil, fmt.Errorf("filter %v does not match %v", filter, uri)
+		}
+	case "id":
+		if patternType == "" {
+			return nil, nil, fmt.Errorf("no patternType given to encode %v", uri)
+		}
+		if i := strings.LastIndex(patternType, "-"); i < 0 || patternType[:i] != seq[3] {
+			return nil, nil, fmt.Errorf("patternType %v does not match %v", patternType, uri)
+		}
+		s = findScheme(c.schemes(), patternType)
+		fields = strings.Split(seq[4], ".")
+		if hasFilter(s) {
+			fields = append([]string{filter}, fields...)
+		}
+	default:
+		return nil, nil, fmt.Errorf("invalid EPC URI: %v", uri)
+	}
+	if s == nil {
+		return nil, nil, fmt.Errorf("unsupported patternType to encode: %v", patternType)
+	}
+	if hasFilter(s) {
+		if n, err := strconv.Atoi(fields[0]); err != nil || n < 0 || n > 7 {
+			return nil, nil, fmt.Errorf("invalid filter value: %q", fields[0])
+		}
+	}
+	if fields[len(fields)-1] == "" {
+		return nil, nil, fmt.Errorf("incomplete EPC URI: %v", uri)
+	}
+
+	bs, err := s.prefixFilter(fields)
+	if err != nil {
+		return nil, nil, err
+	}
+	length := s.tagLength
+	if length < len(bs) {
+		if length != 0 {
+			return nil, nil, fmt.Errorf("%v exceeds %v bits in %v", uri, length, patternType)
+		}
+		length = len(bs)
+	}
+	// pad to the tag length and then to the word boundary
+	length += (16 - length%16) % 16
+	bs += strings.Repeat("0", length-len(bs))
+
+	id, err := binutil.ParseBinRuneSliceToUint8Slice([]rune(bs))
+	if err != nil {
+		return nil, nil, err
+	}
+	pc := []byte{uint8(length / 16 << 3), 0} // L4-0=words, UMI=0, XI=0, T=0, RFU=0
+
+	// the EPC must translate back to the identity, e.g., without any field missing
+	if hasFilter(s) {
+		fields = fields[1:]
+	}
+	want := strings.TrimSuffix(s.pureIdentity.PrefixMatch, ":") + ":" + escapeURIString(unescapeURIString(strings.Join(fields, ".")))
+	if got, err := c.Translate(pc, id); err != nil || got != want {
+		return nil, nil, fmt.Errorf("%v cannot be encoded in %v", uri, patternType)
+	}
+	return pc, id, nil
+}
+
+// hasFilter checks if the tag URIs of the scheme start with the filter value
+func hasFilter(s *scheme) bool {
+	if s == nil || s.tagEncoding == nil {
+		return false
+	}
+	for _, opt := range s.tagEncoding.Options {
+		if _, ok := opt.fields["filter"]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package tdt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCore_Encode(t *testing.T) {
+	type args struct {
+		uri         string
+		filter      string
+		patternType string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantPC  []byte
+		wantID  []byte
+		wantErr bool
+	}{
+		{
+			"SGTIN-96_id",
+			args{"urn:epc:id:sgtin:12345678.00001.1", "3", "sgtin-96"},
+			[]byte{48, 0},
+			[]byte{48, 112, 94, 48, 167, 0, 0, 64, 0, 0, 0, 1},
+			false,
+		},
+		{
+			"SGTIN-96_tag",
+			args{"urn:epc:tag:sgtin-96:3.12345678.00001.1", "", ""},
+			[]byte{48, 0},
+			[]byte{48, 112, 94, 48, 167, 0, 0, 64, 0, 0, 0, 1},
+			false,
+		},
+		{
+			"SGTIN-198_id",
+			args{"urn:epc:id:sgtin:0614141.812345.32a%2Fb", "3", "sgtin-198"},
+			[]byte{104, 0},
+			[]byte{54, 116, 37, 123, 247, 25, 78, 89, 178, 194, 191, 16, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			false,
+		},
+		{
+			"SGLN-96_tag",
+			args{"urn:epc:tag:sgln-96:3.0614141.12345.400", "3", "sgln-96"},
+			[]byte{48, 0},
+			[]byte{50, 116, 37, 123, 244, 96, 114, 0, 0, 0, 1, 144},
+			false,
+		},
+		{
+			"GSRN-96_id",
+			args{"urn:epc:id:gsrn:0614141.1234567890", "0", "gsrn-96"},
+			[]byte{48, 0},
+			[]byte{45, 20, 37, 123, 244, 73, 150, 2, 210, 0, 0, 0},
+			false,
+		},
+		{
+			"SGCN-96_id",
+			args{"urn:epc:id:sgcn:4012345.67890.04711", "3", "sgcn-96"},
+			[]byte{48, 0},
+			[]byte{63, 116, 244, 228, 230, 18, 100, 0, 0, 1, 153, 7},
+			false,
+		},
+		{
+			"CPI-var_id",
+			args{"urn:epc:id:cpi:0614141.5PQ7%2FZ43.12345", "3", "cpi-var"},
+			[]byte{72, 0},
+			[]byte{61, 116, 37, 123, 247, 84, 17, 222, 246, 180, 204, 0, 0, 0, 3, 3, 144, 0},
+			false,
+		},
+		{
+			"GID-96_id",
+			args{"urn:epc:id:gid:95100000.12345.400", "", "gid-96"},
+			[]byte{48, 0},
+			[]byte{53, 90, 177, 198, 0, 3, 3, 144, 0, 0, 1, 144},
+			false,
+		},
+		{"no patternType", args{"urn:epc:id:sgtin:12345678.00001.1", "1", ""}, nil, nil, true},
+		{"patternType mismatch", args{"urn:epc:id:sgtin:12345678.00001.1", "1", "sscc-96"}, nil, nil, true},
+		{"tag patternType mismatch", args{"urn:epc:tag:sgtin-96:1.12345678.00001.1", "", "sgtin-198"}, nil, nil, true},
+		{"filter mismatch", args{"urn:epc:tag:sgtin-96:1.12345678.00001.1", "3", ""}, nil, nil, true},
+		{"invalid filter", args{"urn:epc:id:sgtin:12345678.00001.1", "8", "sgtin-96"}, nil, nil, true},
+		{"missing serial", args{"urn:epc:id:sgtin:12345678.00001", "1", "sgtin-96"}, nil, nil, true},
+		{"empty serial", args{"urn:epc:id:sgtin:12345678.00001.", "1", "sgtin-96"}, nil, nil, true},
+		{"leading zeros in serial", args{"urn:epc:id:sgtin:12345678.00001.01", "1", "sgtin-96"}, nil, nil, true},
+		{"too long serial", args{"urn:epc:id:sgtin:12345678.00001.274877906944", "1", "sgtin-96"}, nil, nil, true},
+		{"not EPC", args{"urn:epc:pat:sgtin-96:1.12345678.00001.1", "", ""}, nil, nil, true},
+		{"unknown", args{"urn:epc:id:foo:1", "1", "foo-96"}, nil, nil, true},
+	}
+	c := NewCore()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPC, gotID, err := c.Encode(tt.args.uri, tt.args.filter, tt.args.patternType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Core.Encode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotPC, tt.wantPC) {
+				t.Errorf("Core.Encode() gotPC = %v, want %v", gotPC, tt.wantPC)
+			}
+			if !reflect.DeepEqual(gotID, tt.wantID) {
+				t.Errorf("Core.Encode() gotID = %v, want %v", gotID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestCore_Encode_roundTrip(t *testing.T) {
+	tests := []struct {
+		pureIdentity string
+		filter       string
+		patternType  string
+	}{
+		{"urn:epc:id:cpi:0614141.123457.12345", "3", "cpi-96"},
+		{"urn:epc:id:cpi:0614141.5PQ7%2FZ43.12345", "0", "cpi-var"},
+		{"urn:epc:id:gdti:0614141.12345.400", "3", "gdti-96"},
+		{"urn:epc:id:gdti:0614141.12345.32a%2Fb", "3", "gdti-174"},
+		{"urn:epc:id:gid:95100000.12345.400", "", "gid-96"},
+		{"urn:epc:id:giai:0614141.32a%2Fb", "3", "giai-202"},
+		{"urn:epc:id:grai:0614141.12345.32a%2Fb", "3", "grai-170"},
+		{"urn:epc:id:gsrn:0614141.1234567890", "0", "gsrn-96"},
+		{"urn:epc:id:sgcn:4012345.67890.04711", "3", "sgcn-96"},
+		{"urn:epc:id:sgln:0614141.12345.400", "3", "sgln-96"},
+		{"urn:epc:id:sgln:012345678901..0", "1", "sgln-96"},
+		{"urn:epc:id:sgln:0614141.12345.32a%2Fb", "3", "sgln-195"},
+		{"urn:epc:id:sgtin:0614141.812345.6789", "3", "sgtin-96"},
+		{"urn:epc:id:sgtin:0614141.812345.32a%2Fb", "3", "sgtin-198"},
+		{"urn:epc:id:sscc:0614141.1234567890", "3", "sscc-96"},
+	}
+	c := NewCore()
+	for _, tt := range tests {
+		t.Run(tt.patternType+"_"+tt.pureIdentity, func(t *testing.T) {
+			pc, id, err := c.Encode(tt.pureIdentity, tt.filter, tt.patternType)
+			if err != nil {
+				t.Fatalf("Core.Encode() error = %v", err)
+			}
+			got, err := c.Translate(pc, id)
+			if err != nil {
+				t.Fatalf("Core.Translate() error = %v", err)
+			}
+			if got != tt.pureIdentity {
+				t.Errorf("Core.Translate() = %v, want %v", got, tt.pureIdentity)
+			}
+		})
+	}
+}
@@ -7,11 +7,7 @@
 package tdt
 
 import (
-	"fmt"
-	"math"
-	"strconv"
-
-	"github.com/iomz/go-llrp/binutil"
+	"strings"
 )
 
 // PartitionTableKey is used for PartitionTables
@@ -78,224 +74,51 @@ var SSCC96PartitionTable = PartitionTable{
 	6:  {PValue: 6, CPBits: 20, EBits: 38, EDigits: 11},
 }
 
-// getAssetType returns Asset Type as rune slice
-func getAssetType(at string, pr map[PartitionTableKey]int) (assetType []rune) {
-	if at != "" {
-		assetType = binutil.ParseDecimalStringToBinRuneSlice(at)
-		if pr[ATBits] > len(assetType) {
-			leftPadding := binutil.GenerateNLengthZeroPaddingRuneSlice(pr[ATBits] - len(assetType))
-			assetType = append(leftPadding, assetType...)
-		}
-	} else {
-		assetType, _ = binutil.GenerateNLengthRandomBinRuneSlice(pr[ATBits], uint(math.Pow(float64(10), float64(pr[ATDigits]))))
-	}
-	return
-}
-
-// getCompanyPrefix returns Company Prefix as rune slice
-func getCompanyPrefix(cp string, pt PartitionTable) (companyPrefix []rune) {
-	if cp != "" {
-		companyPrefix = binutil.ParseDecimalStringToBinRuneSlice(cp)
-		if pt[len(cp)][CPBits] > len(companyPrefix) {
-			leftPadding := binutil.GenerateNLengthZeroPaddingRuneSlice(pt[len(cp)][CPBits] - len(companyPrefix))
-			companyPrefix = append(leftPadding, companyPrefix...)
-		}
-	}
-	return
-}
-
-// getExtension returns Extension digit and Serial Reference as rune slice
-func getExtension(e string, pr map[PartitionTableKey]int) (extension []rune) {
-	if e != "" {
-		extension = binutil.ParseDecimalStringToBinRuneSlice(e)
-		if pr[EBits] > len(extension) {
-			leftPadding := binutil.GenerateNLengthZeroPaddingRuneSlice(pr[EBits] - len(extension))
-			extension = append(leftPadding, extension...)
-		}
-	} else {
-		extension, _ = binutil.GenerateNLengthRandomBinRuneSlice(pr[EBits], uint(math.Pow(float64(10), float64(pr[EDigits]))))
-	}
-	return
-}
-
-// getFilter returns filter value as rune slice
-func getFilter(fv string) (filter []rune) {
-	if fv != "" {
-		n, _ := strconv.ParseInt(fv, 10, 32)
-		filter = []rune(fmt.Sprintf("%.3b", n))
-	} else {
-		filter, _ = binutil.GenerateNLengthRandomBinRuneSlice(3, 7)
-	}
-	return
-}
+// uriEscapes is the characters to escape in the EPC URIs
+var uriEscapes = strings.NewReplacer(
+	"%", "%25",
+	"\"", "%22",
+	"#", "%23",
+	"&", "%26",
+	"/", "%2F",
+	"<", "%3C",
+	">", "%3E",
+	"?", "%3F",
+)
 
-// getIndivisualAssetReference returns iar as rune slice
-func getIndivisualAssetReference(iar string, pr map[PartitionTableKey]int) (indivisualAssetReference []rune) {
-	if iar != "" {
-		indivisualAssetReference = binutil.ParseDecimalStringToBinRuneSlice(iar)
-		if pr[IARBits] > len(indivisualAssetReference) {
-			leftPadding := binutil.GenerateNLengthZeroPaddingRuneSlice(pr[IARBits] - len(indivisualAssetReference))
-			indivisualAssetReference = append(leftPadding, indivisualAssetReference...)
-		}
-	} else {
-		indivisualAssetReference, _ = binutil.GenerateNLengthRandomBinRuneSlice(pr[IARBits], uint(math.Pow(float64(10), float64(pr[IARDigits]))))
-	}
-	return
-}
+// uriUnescapes is the reverse of uriEscapes, the hex digits in either case
+var uriUnescapes = strings.NewReplacer(
+	"%22", "\"", "%23", "#", "%26", "&", "%2F", "/", "%2f", "/",
+	"%3C", "<", "%3c", "<", "%3E", ">", "%3e", ">",
+	"%3F", "?", "%3f", "?", "%25", "%",
+)
 
-// getItemReference converts ItemReference value to rune slice
-func getItemReference(ir string, pr map[PartitionTableKey]int) (itemReference []rune) {
-	if ir != "" {
-		itemReference = binutil.ParseDecimalStringToBinRuneSlice(ir)
-		// If the itemReference is short, pad zeroes to the left
-		if pr[IRBits] > len(itemReference) {
-			leftPadding := binutil.GenerateNLengthZeroPaddingRuneSlice(pr[IRBits] - len(itemReference))
-			itemReference = append(leftPadding, itemReference...)
-		}
-	} else {
-		itemReference, _ = binutil.GenerateNLengthRandomBinRuneSlice(pr[IRBits], uint(math.Pow(float64(10), float64(pr[IRDigits]))))
-	}
-	return
+// escapeURIString escapes the characters not allowed in the EPC URIs
+func escapeURIString(s string) string {
+	return uriEscapes.Replace(s)
 }
 
-// getSerial converts serial to rune slice
-func getSerial(s string, serialLength int) (serial []rune) {
-	if s != "" {
-		serial = binutil.ParseDecimalStringToBinRuneSlice(s)
-		if serialLength > len(serial) {
-			leftPadding := binutil.GenerateNLengthZeroPaddingRuneSlice(serialLength - len(serial))
-			serial = append(leftPadding, serial...)
-		}
-	} else {
-		serial, _ = binutil.GenerateNLengthRandomBinRuneSlice(serialLength, uint(math.Pow(float64(2), float64(serialLength))))
-	}
-	return serial
+// unescapeURIString restores the characters escaped in the EPC URIs
+func unescapeURIString(s string) string {
+	return uriUnescapes.Replace(s)
 }
 
 // NewPrefixFilterGIAI96 takes field values in a slice and return a prefix filter string
 func NewPrefixFilterGIAI96(fields []string) (string, error) {
-	nFields := len(fields) // filter, companyPrefix, indivisualAssetReference
-	if nFields == 0 {
-		return "", fmt.Errorf("wrong fields: %q", fields)
-	}
-
-	// filter
-	filter := getFilter(fields[0])
-	if nFields == 1 {
-		return "00110100" + string(filter), nil
-	}
-
-	// companyPrefix
-	companyPrefix := getCompanyPrefix(fields[1], GIAI96PartitionTable)
-	partition := []rune(fmt.Sprintf("%.3b", GIAI96PartitionTable[len(fields[1])][PValue]))
-	if nFields == 2 {
-		return "00110100" + string(filter) + string(partition) + string(companyPrefix), nil
-	}
-
-	// indivisualAssetReference
-	indivisualAssetReference := getIndivisualAssetReference(fields[2], GIAI96PartitionTable[len(fields[1])])
-	if nFields == 3 {
-		return "00110100" + string(filter) + string(partition) + string(companyPrefix) + string(indivisualAssetReference), nil
-	}
-
-	return "", fmt.Errorf("unknown fields provided %q", fields)
+	return MakePrefixFilterString("giai-96", fields)
 }
 
 // NewPrefixFilterGRAI96 takes field values in a slice and return a prefix filter string
 func NewPrefixFilterGRAI96(fields []string) (string, error) {
-	nFields := len(fields) // filter, companyPrefix, assetType, serial
-	if nFields == 0 {
-		return "", fmt.Errorf("wrong fields: %q", fields)
-	}
-
-	// filter
-	filter := getFilter(fields[0])
-	if nFields == 1 {
-		return "00110011" + string(filter), nil
-	}
-
-	// companyPrefix
-	companyPrefix := getCompanyPrefix(fields[1], GRAI96PartitionTable)
-	partition := []rune(fmt.Sprintf("%.3b", GRAI96PartitionTable[len(fields[1])][PValue]))
-	if nFields == 2 {
-		return "00110011" + string(filter) + string(partition) + string(companyPrefix), nil
-	}
-
-	// assetType
-	assetType := getAssetType(fields[2], GRAI96PartitionTable[len(fields[1])])
-	if nFields == 3 {
-		return "00110011" + string(filter) + string(partition) + string(companyPrefix) + string(assetType), nil
-	}
-
-	// serial
-	serial := getSerial(fields[3], 38)
-	if nFields == 4 {
-		return "00110011" + string(filter) + string(partition) + string(companyPrefix) + string(assetType) + string(serial), nil
-	}
-
-	return "", fmt.Errorf("unknown fields provided %q", fields)
+	return MakePrefixFilterString("grai-96", fields)
 }
 
 // NewPrefixFilterSGTIN96 takes field values in a slice and return a prefix filter string
 func NewPrefixFilterSGTIN96(fields []string) (string, error) {
-	nFields := len(fields) // filter, companyPrefix, itemReference, serial
-	if nFields == 0 {
-		return "", fmt.Errorf("wrong fields: %q", fields)
-	}
-
-	// filter
-	filter := getFilter(fields[0])
-	if nFields == 1 {
-		return "00110000" + string(filter), nil
-	}
-
-	// companyPrefix
-	companyPrefix := getCompanyPrefix(fields[1], SGTIN96PartitionTable)
-	partition := []rune(fmt.Sprintf("%.3b", SGTIN96PartitionTable[len(fields[1])][PValue]))
-	if nFields == 2 {
-		return "00110000" + string(filter) + string(partition) + string(companyPrefix), nil
-	}
-
-	// itemReference
-	itemReference := getItemReference(fields[2], SGTIN96PartitionTable[len(fields[1])])
-	if nFields == 3 {
-		return "00110000" + string(filter) + string(partition) + string(companyPrefix) + string(itemReference), nil
-	}
-
-	// serial
-	serial := getSerial(fields[3], 38)
-	if nFields == 4 {
-		return "00110000" + string(filter) + string(partition) + string(companyPrefix) + string(itemReference) + string(serial), nil
-	}
-
-	return "", fmt.Errorf("unknown fields provided %q", fields)
+	return MakePrefixFilterString("sgtin-96", fields)
 }
 
 // NewPrefixFilterSSCC96 takes field values in a slice and return a prefix filter string
 func NewPrefixFilterSSCC96(fields []string) (string, error) {
-	nFields := len(fields) // filter, companyPrefix, extension
-	if nFields == 0 {
-		return "", fmt.Errorf("wrong fields: %q", fields)
-	}
-
-	// filter
-	filter := getFilter(fields[0])
-	if nFields == 1 {
-		return "00110001" + string(filter), nil
-	}
-
-	// companyPrefix
-	companyPrefix := getCompanyPrefix(fields[1], SSCC96PartitionTable)
-	partition := []rune(fmt.Sprintf("%.3b", SSCC96PartitionTable[len(fields[1])][PValue]))
-	if nFields == 2 {
-		return "00110001" + string(filter) + string(partition) + string(companyPrefix), nil
-	}
-
-	// extension
-	extension := getExtension(fields[2], SSCC96PartitionTable[len(fields[1])])
-	if nFields == 3 {
-		return "00110001" + string(filter) + string(partition) + string(companyPrefix) + string(extension), nil
-	}
-
-	return "", fmt.Errorf("unknown fields provided %q", fields)
+	return MakePrefixFilterString("sscc-96", fields)
 }
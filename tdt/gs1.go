@@ -0,0 +1,144 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package tdt
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DigitalLinkDomain is the resolver of the GS1 Digital Link URIs
+const DigitalLinkDomain = "https://id.gs1.org"
+
+// gs1Element is an element string of the AI and the value
+type gs1Element struct {
+	ai    string
+	value string
+}
+
+// formatGS1 returns the pure identity of an SGTIN, SSCC, SGLN, GRAI or GIAI in the GS1 syntax of the form
+func formatGS1(pureIdentity string, form URIForm) (string, error) {
+	elements, err := parseGS1Elements(pureIdentity)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	switch form {
+	case GS1Key:
+		return elements[0].value, nil
+	case ElementString:
+		for _, e := range elements {
+			sb.WriteString("(" + e.ai + ")" + e.value)
+		}
+	case DigitalLinkURI:
+		sb.WriteString(DigitalLinkDomain)
+		for _, e := range elements {
+			sb.WriteString("/" + e.ai + "/" + url.PathEscape(e.value))
+		}
+	default:
+		return "", fmt.Errorf("%v is not a GS1 syntax", form)
+	}
+	return sb.String(), nil
+}
+
+// parseGS1Elements returns the element strings of the GS1 key and the qualifier in the pure identity
+func parseGS1Elements(pureIdentity string) ([]gs1Element, error) {
+	seq := strings.SplitN(pureIdentity, ":", 5)
+	if len(seq) != 5 || seq[0] != "urn" || seq[1] != "epc" || seq[2] != "id" {
+		return nil, fmt.Errorf("invalid pure identity: %v", pureIdentity)
+	}
+	invalid := fmt.Errorf("no GS1 key in %v", pureIdentity)
+	switch seq[3] {
+	case "sgtin":
+		fields := strings.SplitN(seq[4], ".", 3)
+		if len(fields) != 3 || len(fields[1]) == 0 {
+			return nil, invalid
+		}
+		// the indicator digit goes in front of the company prefix
+		gtin, ok := gs1Key(fields[1][:1]+fields[0]+fields[1][1:], 13)
+		if !ok {
+			return nil, invalid
+		}
+		return []gs1Element{{"01", gtin}, {"21", unescapeURIString(fields[2])}}, nil
+	case "sscc":
+		fields := strings.SplitN(seq[4], ".", 2)
+		if len(fields) != 2 || len(fields[1]) == 0 {
+			return nil, invalid
+		}
+		// the extension digit goes in front of the company prefix
+		sscc, ok := gs1Key(fields[1][:1]+fields[0]+fields[1][1:], 17)
+		if !ok {
+			return nil, invalid
+		}
+		return []gs1Element{{"00", sscc}}, nil
+	case "sgln":
+		fields := strings.SplitN(seq[4], ".", 3)
+		if len(fields) != 3 {
+			return nil, invalid
+		}
+		gln, ok := gs1Key(fields[0]+fields[1], 12)
+		if !ok {
+			return nil, invalid
+		}
+		elements := []gs1Element{{"414", gln}}
+		// the extension 0 stands for no extension
+		if fields[2] != "0" {
+			elements = append(elements, gs1Element{"254", unescapeURIString(fields[2])})
+		}
+		return elements, nil
+	case "grai":
+		fields := strings.SplitN(seq[4], ".", 3)
+		if len(fields) != 3 {
+			return nil, invalid
+		}
+		grai, ok := gs1Key("0"+fields[0]+fields[1], 13)
+		if !ok {
+			return nil, invalid
+		}
+		return []gs1Element{{"8003", grai + unescapeURIString(fields[2])}}, nil
+	case "giai":
+		fields := strings.SplitN(seq[4], ".", 2)
+		if len(fields) != 2 || !isDigits(fields[0]) {
+			return nil, invalid
+		}
+		return []gs1Element{{"8004", fields[0] + unescapeURIString(fields[1])}}, nil
+	}
+	return nil, fmt.Errorf("no GS1 syntax for %v", pureIdentity)
+}
+
+// gs1Key appends the check digit to the digits of the key if it has the length
+func gs1Key(digits string, length int) (string, bool) {
+	if len(digits) != length || !isDigits(digits) {
+		return "", false
+	}
+	return digits + gs1CheckDigit(digits), true
+}
+
+// gs1CheckDigit returns the GS1 check digit for the digits of the key without it
+func gs1CheckDigit(digits string) string {
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		// the weights are 3 and 1 alternately from the right
+		if i%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return strconv.Itoa((10 - sum%10) % 10)
+}
+
+// isDigits checks if s consists of the decimal digits
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return len(s) != 0
+}
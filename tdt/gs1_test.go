@@ -0,0 +1,67 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package tdt
+
+import (
+	"testing"
+)
+
+func Test_gs1CheckDigit(t *testing.T) {
+	tests := []struct {
+		name   string
+		digits string
+		want   string
+	}{
+		{"GTIN-14", "8061414112345", "8"},
+		{"GTIN-14_0", "0950600013435", "2"},
+		{"SSCC", "10614141123456789", "7"},
+		{"GLN", "061414112345", "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gs1CheckDigit(tt.digits); got != tt.want {
+				t.Errorf("gs1CheckDigit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCore_TranslateURI_gs1(t *testing.T) {
+	sgtin := []byte{48, 116, 75, 90, 28, 0, 0, 64, 0, 0, 0, 1}
+	tests := []struct {
+		name    string
+		pc      []byte
+		id      []byte
+		form    URIForm
+		want    string
+		wantErr bool
+	}{
+		{"SGTIN-96_gs1-key", []byte{48, 0}, sgtin, GS1Key, "01234567000015", false},
+		{"SGTIN-96_element-string", []byte{48, 0}, sgtin, ElementString, "(01)01234567000015(21)1", false},
+		{"SGTIN-96_digital-link", []byte{48, 0}, sgtin, DigitalLinkURI, "https://id.gs1.org/01/01234567000015/21/1", false},
+		{"SSCC-96_element-string", []byte{48, 0}, []byte{49, 96, 114, 250, 100, 104, 80, 0, 1, 0, 0, 0}, ElementString, "(00)012345678901200015", false},
+		{"SGLN-96_element-string", []byte{48, 0}, []byte{50, 116, 37, 123, 244, 96, 114, 0, 0, 0, 1, 144}, ElementString, "(414)0614141123452(254)400", false},
+		{"SGLN-96_no_extension", []byte{48, 0}, []byte{50, 32, 11, 127, 112, 112, 212, 0, 0, 0, 0, 0}, DigitalLinkURI, "https://id.gs1.org/414/0123456789012", false},
+		{"GRAI-170_element-string", []byte{88, 0}, []byte{55, 116, 37, 123, 244, 12, 14, 89, 178, 194, 191, 16, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, ElementString, "(8003)0061414112345232a/b", false},
+		{"GRAI-170_digital-link", []byte{88, 0}, []byte{55, 116, 37, 123, 244, 12, 14, 89, 178, 194, 191, 16, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, DigitalLinkURI, "https://id.gs1.org/8003/0061414112345232a%2Fb", false},
+		{"GIAI-202_digital-link", []byte{104, 0}, []byte{56, 116, 37, 123, 245, 155, 44, 43, 241, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, DigitalLinkURI, "https://id.gs1.org/8004/061414132a%2Fb", false},
+		{"GSRN-96_no_syntax", []byte{48, 0}, []byte{45, 20, 37, 123, 244, 73, 150, 2, 210, 0, 0, 0}, ElementString, "", true},
+		{"ISO17363_as_is", []byte{41, 169}, []byte{220, 32, 66, 13, 92, 114, 207, 77, 118, 194}, DigitalLinkURI, "urn:epc:id:iso17363:7BABCU1234560", false},
+	}
+	c := NewCore()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.TranslateURI(tt.pc, tt.id, tt.form)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Core.TranslateURI() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Core.TranslateURI() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
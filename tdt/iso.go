@@ -7,12 +7,16 @@
 package tdt
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/iomz/go-llrp/binutil"
 )
 
+// ErrCheckDigit is returned when the check digit disagrees with the rest of the ID
+var ErrCheckDigit = errors.New("check digit mismatch")
+
 // getISO6346CD returns check digit for container serial number
 func getISO6346CD(cn string) (int, error) {
 	if len(cn) != 10 {
@@ -27,6 +31,45 @@ func getISO6346CD(cn string) (int, error) {
 	return (int(n) - int(n/11)*11) % 10, nil
 }
 
+// validateISO6346 checks the container code of the owner code, the equipment category identifier,
+// the serial number and the check digit
+func validateISO6346(cn string) error {
+	if len(cn) != 11 {
+		return fmt.Errorf("%w: invalid ISO6346 code: %v", ErrCheckDigit, cn)
+	}
+	for i := 0; i < len(cn); i++ {
+		if (i < 4 && (cn[i] < 'A' || cn[i] > 'Z')) || (i >= 4 && (cn[i] < '0' || cn[i] > '9')) {
+			return fmt.Errorf("%w: invalid ISO6346 code: %v", ErrCheckDigit, cn)
+		}
+	}
+	cd, err := getISO6346CD(cn[:10])
+	if err != nil {
+		return err
+	}
+	if int(cn[10]-'0') != cd {
+		return fmt.Errorf("%w: %v for %v in %v", ErrCheckDigit, cn[10:], cd, cn)
+	}
+	return nil
+}
+
+// ValidateCheckDigit checks the check digit in the pure identity if the scheme has any,
+// i.e., the ISO 6346 container code in ISO 17363
+func ValidateCheckDigit(pureIdentity string) error {
+	seq := strings.SplitN(pureIdentity, ":", 5)
+	if len(seq) != 5 || seq[0] != "urn" || seq[1] != "epc" || seq[2] != "id" {
+		return nil
+	}
+	switch seq[3] {
+	case "iso17363", "iso17363h":
+		_, cn, err := parseDataIdentifier(seq[4])
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrCheckDigit, err)
+		}
+		return validateISO6346(cn)
+	}
+	return nil
+}
+
 // pad6BitEncodingRuneSlice returns a new length
 // and 16-bit (word-length) padded binary string in rune slice
 // @ISO15962
@@ -79,7 +122,7 @@ func NewPrefixFilterISO17363(fields []string) (string, error) {
 	} else if 6 < len(csn) {
 		return "", fmt.Errorf("Invalid csn: %v", csn)
 	}
-	cd, err := getISO6346CD(fields[0] + fields[1] + csn)
+	cd, err := getISO6346CD(fields[1] + fields[2] + csn)
 	if err != nil {
 		return "", err
 	}
@@ -91,8 +134,29 @@ func NewPrefixFilterISO17363(fields []string) (string, error) {
 	return "", fmt.Errorf("unknown fields provided: %q", fields)
 }
 
+// NewPrefixFilterISO17364 takes fields and return the prefix filter in string
+func NewPrefixFilterISO17364(fields []string) (string, error) {
+	return newPrefixFilterISO15459(fields)
+}
+
 // NewPrefixFilterISO17365 takes fields and return the prefix filter in string
 func NewPrefixFilterISO17365(fields []string) (string, error) {
+	return newPrefixFilterISO15459(fields)
+}
+
+// NewPrefixFilterISO17366 takes fields and return the prefix filter in string
+func NewPrefixFilterISO17366(fields []string) (string, error) {
+	return newPrefixFilterISO15459(fields)
+}
+
+// NewPrefixFilterISO17367 takes fields and return the prefix filter in string
+func NewPrefixFilterISO17367(fields []string) (string, error) {
+	return newPrefixFilterISO15459(fields)
+}
+
+// newPrefixFilterISO15459 takes fields of the ISO/IEC 15459 unique identifier
+// used in ISO 17364, 17365, 17366 and 17367, and return the prefix filter in string
+func newPrefixFilterISO15459(fields []string) (string, error) {
 	nFields := len(fields) // dataIdentifier, issuingAgencyCode, companyIdentification, serialNumber
 
 	if nFields == 0 {
@@ -125,3 +189,17 @@ func NewPrefixFilterISO17365(fields []string) (string, error) {
 
 	return "", fmt.Errorf("unknown fields provided: %q", fields)
 }
+
+// parseDataIdentifier splits the ASC MH10 data identifier off the UII,
+// up to 3 digits followed by an uppercase letter, e.g., 7B or 25S
+func parseDataIdentifier(uii string) (string, string, error) {
+	for i := 0; i < len(uii) && i <= 3; i++ {
+		if 'A' <= uii[i] && uii[i] <= 'Z' {
+			return uii[:i+1], uii[i+1:], nil
+		}
+		if uii[i] < '0' || '9' < uii[i] {
+			break
+		}
+	}
+	return "", uii, fmt.Errorf("no data identifier in %v", uii)
+}
@@ -6,6 +6,7 @@
 package tdt
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -77,6 +78,12 @@ func TestNewPrefixFilterISO17363(t *testing.T) {
 			"110111000010000001000001001010",
 			false,
 		},
+		{
+			"ISO17363_7B_ABC_U_123456",
+			args{[]string{"7B", "ABC", "U", "123456"}},
+			"110111000010000001000010000011010101110001110010110011110100110101110110110000",
+			false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -122,3 +129,108 @@ func TestNewPrefixFilterISO17365(t *testing.T) {
 		})
 	}
 }
+
+func TestMakePrefixFilterString_iso15459(t *testing.T) {
+	tests := []struct {
+		name        string
+		patternType string
+		fields      []string
+		want        string
+		wantErr     bool
+	}{
+		{"ISO17364_25B_UN", "iso17364", []string{"25B", "UN"}, "110010110101000010010101001110", false},
+		{"ISO17364h_25B_UN", "iso17364h", []string{"25B", "UN"}, "110010110101000010010101001110", false},
+		{"ISO17366_25S_OD", "iso17366", []string{"25S", "OD"}, "110010110101010011001111000100", false},
+		{"ISO17367_25S_LA", "iso17367", []string{"25S", "LA"}, "110010110101010011001100000001", false},
+		{"ISO17367_too_many_fields", "iso17367", []string{"25S", "LA", "ACME", "SN12345", "1"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MakePrefixFilterString(tt.patternType, tt.fields)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MakePrefixFilterString() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("MakePrefixFilterString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseDataIdentifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		uii      string
+		wantDI   string
+		wantRest string
+		wantErr  bool
+	}{
+		{"J", "JUN043325711", "J", "UN043325711", false},
+		{"7B", "7BABCU1234560", "7B", "ABCU1234560", false},
+		{"25S", "25SUNABC0THANK0YOU", "25S", "UNABC0THANK0YOU", false},
+		{"999T", "999TX", "999T", "X", false},
+		{"too_many_digits", "1234S", "", "1234S", true},
+		{"no_letter", "123", "", "123", true},
+		{"symbol", "2-S", "", "2-S", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotDI, gotRest, err := parseDataIdentifier(tt.uii)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseDataIdentifier() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotDI != tt.wantDI || gotRest != tt.wantRest {
+				t.Errorf("parseDataIdentifier() = %v, %v, want %v, %v", gotDI, gotRest, tt.wantDI, tt.wantRest)
+			}
+		})
+	}
+}
+
+func Test_validateISO6346(t *testing.T) {
+	tests := []struct {
+		name    string
+		cn      string
+		wantErr bool
+	}{
+		{"ABCU1234560", "ABCU1234560", false},
+		{"CSQU3054383", "CSQU3054383", false},
+		{"wrong_check_digit", "ABCU1234570", true},
+		{"lowercase", "abcU1234560", true},
+		{"too_short", "ABCU123456", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateISO6346(tt.cn)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateISO6346() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrCheckDigit) {
+				t.Errorf("validateISO6346() error = %v, want %v", err, ErrCheckDigit)
+			}
+		})
+	}
+}
+
+func TestValidateCheckDigit(t *testing.T) {
+	tests := []struct {
+		name         string
+		pureIdentity string
+		wantErr      bool
+	}{
+		{"ISO17363", "urn:epc:id:iso17363:7BABCU1234560", false},
+		{"ISO17363_wrong_check_digit", "urn:epc:id:iso17363:7BABCU1234570", true},
+		{"ISO17363h_wrong_check_digit", "urn:epc:id:iso17363h:7BABCU1234570", true},
+		{"ISO17365_no_check_digit", "urn:epc:id:iso17365:25SUNABC0THANK0YOU", false},
+		{"SGTIN_no_check_digit", "urn:epc:id:sgtin:0614141.812345.6789", false},
+		{"raw", "urn:epc:raw:96.x3074257BF7194E4000001A85", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateCheckDigit(tt.pureIdentity); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCheckDigit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package tdt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Wildcard is the field of a pattern matching any value
+const Wildcard = "*"
+
+// MakeFilterStrings takes a pattern type and a slice of fields, each of which can be * for any value
+// or [lo-hi] for the integers from lo to hi, and returns the binary representations of the filters in string,
+// the x bits match any bit and a range takes the minimal set of the bit prefixes covering it
+func MakeFilterStrings(patternType string, fields []string) ([]string, error) {
+	// the trailing * leave the filter a prefix
+	n := len(fields)
+	for n > 1 && fields[n-1] == Wildcard {
+		n--
+	}
+	fields = fields[:n]
+	masked := false
+	for _, f := range fields {
+		if f == Wildcard || isRange(f) {
+			masked = true
+		}
+	}
+	if !masked {
+		pfs, err := MakePrefixFilterString(patternType, fields)
+		if err != nil {
+			return nil, err
+		}
+		return []string{pfs}, nil
+	}
+	s := lookupScheme(patternType)
+	if s == nil || s.tagLength == 0 {
+		return nil, fmt.Errorf("%v takes * only in the trailing fields", patternType)
+	}
+	if hasFilter(s) && firstBinaryField(s) != "filter" && (fields[0] == Wildcard || isRange(fields[0])) {
+		return expandFilter(s, patternType, fields)
+	}
+	// the company prefix decides the length of the reference after it
+	cp := tagEncodingFieldIndex(s, "gs1companyprefix")
+	if cp >= 0 && len(fields) > cp+1 && fields[cp] == Wildcard && fields[cp+1] != Wildcard {
+		return nil, fmt.Errorf("%v needs the company prefix for the field %v", patternType, fields[cp+1])
+	}
+
+	// the values to find the bits of each field with
+	values := make([]string, len(fields))
+	for i, f := range fields {
+		switch {
+		case f == Wildcard && i == cp:
+			// any length of the company prefix takes the same bits with the reference
+			values[i] = "0000000"
+		case f == Wildcard:
+			values[i] = "0"
+		case isRange(f):
+			values[i] = f[1:strings.Index(f, "-")]
+		default:
+			values[i] = f
+		}
+	}
+
+	filters := []string{""}
+	offset := len(s.binary.PrefixMatch)
+	for i, f := range fields {
+		pfs, err := MakePrefixFilterString(patternType, values[:i+1])
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			// the header is common to all the filters
+			filters[0] = pfs[:offset]
+		}
+		width := len(pfs) - offset
+		var alternatives []string
+		switch {
+		case f == Wildcard:
+			alternatives = []string{""}
+		case isRange(f):
+			if alternatives, err = fieldRange(patternType, values[:i], f, offset, pfs[offset:]); err != nil {
+				return nil, err
+			}
+		default:
+			alternatives = []string{pfs[offset:]}
+		}
+		var next []string
+		for _, prefix := range filters {
+			for _, a := range alternatives {
+				next = append(next, prefix+a+strings.Repeat("x", width-len(a)))
+			}
+		}
+		filters = next
+		offset = len(pfs)
+	}
+	// the wildcard bits at the end match anything left in the IDs
+	for i := range filters {
+		filters[i] = strings.TrimRight(filters[i], "x")
+	}
+	return filters, nil
+}
+
+// HasFilter checks if the patterns of the type start with the filter value,
+// i.e., the GS1 TDT definitions with the filter field
+func HasFilter(patternType string) bool {
+	return hasFilter(lookupScheme(patternType))
+}
+
+// firstBinaryField returns the name of the first field in the binary of the scheme
+func firstBinaryField(s *scheme) string {
+	for _, opt := range s.binary.Options {
+		for _, t := range opt.grammar {
+			if t.field != "" {
+				return t.field
+			}
+		}
+	}
+	return ""
+}
+
+// tagEncodingFieldIndex returns the index of the field in the tag URI patterns of the scheme, or -1
+func tagEncodingFieldIndex(s *scheme, name string) int {
+	if s.tagEncoding == nil || len(s.tagEncoding.Options) == 0 {
+		return -1
+	}
+	i := 0
+	for _, t := range s.tagEncoding.Options[0].grammar {
+		if t.field == name {
+			return i
+		}
+		if t.field != "" {
+			i++
+		}
+	}
+	return -1
+}
+
+// ParseRange returns the integers lo and hi in the field of a pattern [lo-hi]
+func ParseRange(f string) (uint64, uint64, error) {
+	if !isRange(f) {
+		return 0, 0, fmt.Errorf("invalid range: %v", f)
+	}
+	bounds := strings.SplitN(f[1:len(f)-1], "-", 2)
+	lo, err := strconv.ParseUint(bounds[0], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid range: %v", f)
+	}
+	hi, err := strconv.ParseUint(bounds[1], 10, 64)
+	if err != nil || hi < lo {
+		return 0, 0, fmt.Errorf("invalid range: %v", f)
+	}
+	return lo, hi, nil
+}
+
+// isRange checks if the field of a pattern is [lo-hi]
+func isRange(f string) bool {
+	return strings.HasPrefix(f, "[") && strings.HasSuffix(f, "]") && strings.Contains(f, "-")
+}
+
+// expandFilter returns the filters for each filter value in * or [lo-hi] of the GS1 TDT definitions
+// placing the filter value after the other fields in the binary
+func expandFilter(s *scheme, patternType string, fields []string) ([]string, error) {
+	bits := 0
+	for _, opt := range s.binary.Options {
+		if f, ok := opt.fields["filter"]; ok {
+			bits = f.BitLength
+		}
+	}
+	if bits > 8 {
+		return nil, fmt.Errorf("%v takes no * or range in the filter value of %v bits", patternType, bits)
+	}
+	lo, hi := uint64(0), uint64(1)<<uint(bits)-1
+	if isRange(fields[0]) {
+		l, h, err := ParseRange(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		if l > lo {
+			lo = l
+		}
+		if h < hi {
+			hi = h
+		}
+	}
+	var filters []string
+	for v := lo; v <= hi; v++ {
+		fs, err := MakeFilterStrings(patternType, append([]string{strconv.FormatUint(v, 10)}, fields[1:]...))
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, fs...)
+	}
+	return filters, nil
+}
+
+// fieldRange returns the bit prefixes of the field covering the range in [lo-hi]
+// after the fields given in values, the lo of which takes the bits in loBits from the offset
+func fieldRange(patternType string, values []string, f string, offset int, loBits string) ([]string, error) {
+	lo, hi, err := ParseRange(f)
+	if err != nil {
+		return nil, err
+	}
+	pfs, err := MakePrefixFilterString(patternType, append(values[:len(values):len(values)], strconv.FormatUint(hi, 10)))
+	if err != nil {
+		return nil, err
+	}
+	// the field must be the integer in binary for the prefixes to cover the range
+	width := len(loBits)
+	if width >= 64 || len(pfs) != offset+width || pfs[offset:] != fmt.Sprintf("%0*b", width, hi) || loBits != fmt.Sprintf("%0*b", width, lo) {
+		return nil, fmt.Errorf("%v does not take the range %v in the field", patternType, f)
+	}
+	return rangePrefixes(lo, hi, width), nil
+}
+
+// rangePrefixes returns the minimal set of the bit prefixes covering the integers from lo to hi in width bits
+func rangePrefixes(lo uint64, hi uint64, width int) []string {
+	var prefixes []string
+	for {
+		// the largest block aligned at lo within hi
+		size := 0
+		for size < width {
+			block := uint64(1) << uint(size+1)
+			if lo%block != 0 || lo+block-1 > hi {
+				break
+			}
+			size++
+		}
+		prefixes = append(prefixes, fmt.Sprintf("%0*b", width, lo)[:width-size])
+		last := lo + (uint64(1) << uint(size)) - 1
+		if last >= hi {
+			return prefixes
+		}
+		lo = last + 1
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package tdt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMakeFilterStrings(t *testing.T) {
+	tests := []struct {
+		name        string
+		patternType string
+		fields      []string
+		want        []string
+		wantErr     bool
+	}{
+		{
+			"SGTIN-96_prefix",
+			"sgtin-96",
+			[]string{"3", "0614141"},
+			[]string{"00110000011101000010010101111011111101"},
+			false,
+		},
+		{
+			"SGTIN-96_trailing_wildcards",
+			"sgtin-96",
+			[]string{"3", "0614141", "*", "*"},
+			[]string{"00110000011101000010010101111011111101"},
+			false,
+		},
+		{
+			"SGTIN-96_any_filter",
+			"sgtin-96",
+			[]string{"*", "0614141"},
+			[]string{"00110000xxx101000010010101111011111101"},
+			false,
+		},
+		{
+			"SGTIN-96_filter_range",
+			"sgtin-96",
+			[]string{"[1-3]", "0614141"},
+			[]string{"00110000001101000010010101111011111101", "0011000001x101000010010101111011111101"},
+			false,
+		},
+		{
+			"SGTIN-96_any_item_serial_range",
+			"sgtin-96",
+			[]string{"3", "0614141", "*", "[8-23]"},
+			[]string{
+				"00110000011101000010010101111011111101xxxxxxxxxxxxxxxxxxxx00000000000000000000000000000000001",
+				"00110000011101000010010101111011111101xxxxxxxxxxxxxxxxxxxx00000000000000000000000000000000010",
+			},
+			false,
+		},
+		{
+			"SGTIN-96_any_company_serial",
+			"sgtin-96",
+			[]string{"3", "*", "*", "5"},
+			[]string{"00110000011xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx00000000000000000000000000000000000101"},
+			false,
+		},
+		{"SGTIN-96_any_company_item", "sgtin-96", []string{"3", "*", "812345"}, nil, true},
+		{"SGTIN-96_inverted_range", "sgtin-96", []string{"3", "0614141", "812345", "[5-3]"}, nil, true},
+		{"SGTIN-96_range_too_large", "sgtin-96", []string{"3", "0614141", "812345", "[0-274877906944]"}, nil, true},
+		{"SGTIN-198_alphanumeric_range", "sgtin-198", []string{"3", "0614141", "812345", "[1-5]"}, nil, true},
+		{"ISO17363_wildcard_inside", "iso17363", []string{"7B", "*", "U"}, nil, true},
+		{"ISO17363_trailing_wildcard", "iso17363", []string{"7B", "*"}, []string{"110111000010"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MakeFilterStrings(tt.patternType, tt.fields)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MakeFilterStrings() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MakeFilterStrings() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasFilter(t *testing.T) {
+	tests := []struct {
+		patternType string
+		want        bool
+	}{
+		{"sgtin-96", true},
+		{"sscc-96", true},
+		{"cpi-var", true},
+		{"gid-96", false},
+		{"iso17363", false},
+		{"raw", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.patternType, func(t *testing.T) {
+			if got := HasFilter(tt.patternType); got != tt.want {
+				t.Errorf("HasFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_rangePrefixes(t *testing.T) {
+	tests := []struct {
+		name  string
+		lo    uint64
+		hi    uint64
+		width int
+		want  []string
+	}{
+		{"single", 5, 5, 4, []string{"0101"}},
+		{"whole", 0, 15, 4, []string{""}},
+		{"aligned", 8, 15, 4, []string{"1"}},
+		{"unaligned", 3, 12, 4, []string{"0011", "01", "10", "1100"}},
+		{"1000-1999", 1000, 1999, 11, []string{"01111101", "0111111", "10", "110", "1110", "11110", "1111100"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rangePrefixes(tt.lo, tt.hi, tt.width); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rangePrefixes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package tdt
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrPCLength is returned when the length in the PC bits disagrees with the ID
+var ErrPCLength = errors.New("PC length disagrees with the ID")
+
+// PC is the protocol control word of a tag, with the XPC_W1 if XI is set
+type PC struct {
+	// Length is the number of the words in the EPC/UII after the PC (and the XPC) words
+	Length int
+	// UMI is the user memory indicator
+	UMI bool
+	// XI is the XPC_W1 indicator
+	XI bool
+	// NSI is the numbering system identifier toggle, true for ISO
+	NSI bool
+	// AFI is the application family identifier for ISO, or the attribute bits for the EPCs
+	AFI byte
+	// XPCW1 is the XPC_W1 word, nil unless XI is set and given
+	XPCW1 []byte
+}
+
+// ParsePC takes the PC bits in []byte, followed by the XPC_W1 if XI is set, and returns the PC
+func ParsePC(pc []byte) (*PC, error) {
+	if len(pc) != 2 && len(pc) != 4 {
+		return nil, errors.New("Invalid PC bits")
+	}
+	p := &PC{
+		Length: int(pc[0] >> 3), // L4-0
+		UMI:    4&pc[0] != 0,
+		XI:     2&pc[0] != 0,
+		NSI:    1&pc[0] != 0,
+		AFI:    pc[1],
+	}
+	if len(pc) == 4 {
+		if !p.XI {
+			return nil, errors.New("Invalid PC bits: XPC_W1 given without XI")
+		}
+		p.XPCW1 = pc[2:4]
+	}
+	return p, nil
+}
+
+// trimID returns the ID trimmed to the length in the PC,
+// the readers may report the EPC memory padded beyond the EPC/UII
+func (c *Core) trimID(p *PC, id []byte) ([]byte, error) {
+	n := p.Length * 2
+	if n == 0 {
+		return nil, fmt.Errorf("%w: no word in PC", ErrPCLength)
+	}
+	if len(id) < n {
+		return nil, fmt.Errorf("%w: %d words in PC for %d bytes", ErrPCLength, p.Length, len(id))
+	}
+	id = id[:n]
+	if p.NSI {
+		return id, nil
+	}
+	// the length implied by the EPC header
+	for _, s := range c.schemes() {
+		if !s.matchHeader(id) {
+			continue
+		}
+		if s.tagLength != 0 && (s.tagLength+15)/16 != p.Length {
+			return nil, fmt.Errorf("%w: %d words in PC for %s", ErrPCLength, p.Length, s.Name)
+		}
+		break
+	}
+	return id, nil
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package tdt
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParsePC(t *testing.T) {
+	tests := []struct {
+		name    string
+		pc      []byte
+		want    *PC
+		wantErr bool
+	}{
+		{"EPC_6_words", []byte{48, 0}, &PC{Length: 6}, false},
+		{"EPC_UMI", []byte{52, 0}, &PC{Length: 6, UMI: true}, false},
+		{"EPC_XI_without_XPC_W1", []byte{50, 0}, &PC{Length: 6, XI: true}, false},
+		{"EPC_XI_with_XPC_W1", []byte{50, 0, 2, 0}, &PC{Length: 6, XI: true, XPCW1: []byte{2, 0}}, false},
+		{"ISO_AFI", []byte{41, 169}, &PC{Length: 5, NSI: true, AFI: 169}, false},
+		{"XPC_W1_without_XI", []byte{48, 0, 2, 0}, nil, true},
+		{"short", []byte{48}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePC(tt.pc)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParsePC() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePC() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCore_Translate_pcLength(t *testing.T) {
+	sgtin96 := []byte{48, 116, 75, 90, 28, 0, 0, 64, 0, 0, 0, 1}
+	padded := append(append([]byte{}, sgtin96...), 0, 0, 0, 0)
+	tests := []struct {
+		name            string
+		pc              []byte
+		id              []byte
+		want            string
+		wantPCLengthErr bool
+	}{
+		{"SGTIN-96", []byte{48, 0}, sgtin96, "urn:epc:id:sgtin:1234567.000001.1", false},
+		{"SGTIN-96_padded", []byte{48, 0}, padded, "urn:epc:id:sgtin:1234567.000001.1", false},
+		{"SGTIN-96_UMI_XI", []byte{54, 0, 2, 0}, padded, "urn:epc:id:sgtin:1234567.000001.1", false},
+		{"SGTIN-96_in_7_words", []byte{56, 0}, padded, "", true},
+		{"SGTIN-96_in_5_words", []byte{40, 0}, sgtin96, "", true},
+		{"SGTIN-96_shorter_than_PC", []byte{56, 0}, sgtin96, "", true},
+		{"no_word", []byte{0, 0}, sgtin96, "", true},
+		{"GID-96_in_7_words", []byte{56, 0}, []byte{53, 90, 177, 198, 0, 3, 3, 144, 0, 0, 1, 144, 0, 0}, "", true},
+		{"ISO17363_padded", []byte{41, 169}, []byte{220, 32, 66, 13, 92, 114, 207, 77, 118, 194, 0, 0}, "urn:epc:id:iso17363:7BABCU1234560", false},
+	}
+	c := NewCore()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Translate(tt.pc, tt.id)
+			if errors.Is(err, ErrPCLength) != tt.wantPCLengthErr {
+				t.Errorf("Core.Translate() error = %v, want ErrPCLength %v", err, tt.wantPCLengthErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Core.Translate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCore_Decode_pc(t *testing.T) {
+	got, err := NewCore().Decode([]byte{54, 0, 2, 0}, []byte{48, 116, 75, 90, 28, 0, 0, 64, 0, 0, 0, 1, 0, 0})
+	if err != nil {
+		t.Fatalf("Core.Decode() error = %v", err)
+	}
+	if !got.UMI || !reflect.DeepEqual(got.XPCW1, []byte{2, 0}) || len(got.ID) != 12 {
+		t.Errorf("Core.Decode() = %+v, want UMI, XPC_W1 and the ID in 12 bytes", got)
+	}
+}
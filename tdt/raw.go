@@ -0,0 +1,46 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package tdt
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// buildRaw returns the raw URI of the ID not translated in any scheme,
+// urn:epc:raw:<length>.x<hex> for the EPCs and urn:epc:raw:<length>.x<AFI>.x<hex> for ISO
+func buildRaw(id []byte, nsi bool, afi byte) string {
+	urn := "urn:epc:raw:" + strconv.Itoa(len(id)*8) + "."
+	if nsi {
+		urn += fmt.Sprintf("x%02X.", afi)
+	}
+	return urn + "x" + strings.ToUpper(hex.EncodeToString(id))
+}
+
+// NewPrefixFilterRaw takes the fields of a raw pattern, x<hex> for the leading hex digits of the IDs,
+// and returns the binary representation of the prefix filter in string,
+// the raw pattern urn:epc:raw:x<hex> is a gosstrak extension and not in the TDS,
+// it matches any ID starting with the hex digits, including the ones translated in a scheme
+func NewPrefixFilterRaw(fields []string) (string, error) {
+	// the TDS raw URIs, urn:epc:raw:<length>.x<hex>, are the IDs not translated and not the patterns
+	if f := strings.Join(fields, "."); strings.Contains(f, ".x") {
+		return "", fmt.Errorf("not a raw pattern: %q is a raw URI, use x<hex> for the leading hex digits", f)
+	}
+	if len(fields) != 1 || !strings.HasPrefix(fields[0], "x") || len(fields[0]) == 1 {
+		return "", fmt.Errorf("invalid raw pattern: %q", strings.Join(fields, "."))
+	}
+	var sb strings.Builder
+	for _, r := range fields[0][1:] {
+		d, err := strconv.ParseUint(string(r), 16, 4)
+		if err != nil {
+			return "", fmt.Errorf("invalid hex digit in raw pattern: %q", r)
+		}
+		fmt.Fprintf(&sb, "%04b", d)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package tdt
+
+import (
+	"testing"
+)
+
+func TestNewPrefixFilterRaw(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  []string
+		want    string
+		wantErr bool
+	}{
+		{"x30", []string{"x30"}, "00110000", false},
+		{"xE2_lower", []string{"xe2"}, "11100010", false},
+		{"x3", []string{"x3"}, "0011", false},
+		{"no_hex", []string{"x"}, "", true},
+		{"no_x", []string{"30"}, "", true},
+		{"not_hex", []string{"x3G"}, "", true},
+		{"length", []string{"96", "x30"}, "", true},
+		{"raw_uri", []string{"96.x30"}, "", true},
+		{"raw_uri_iso", []string{"64.xA2.x30"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewPrefixFilterRaw(tt.fields)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewPrefixFilterRaw() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("NewPrefixFilterRaw() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -96,15 +96,15 @@ func generateUIISet(wg *sync.WaitGroup, q chan UIIParam, fq chan string) {
 			}
 
 			if !param.NoFilter {
-				bs, opt := scheme.MakeEPC(true, param.Scheme, "3", param.CompanyPrefix, "", "", "", "", "")
+				bs, opt := scheme.MakeEPC(true, param.Scheme, "3", param.CompanyPrefix, "", "", "", "", "", "")
 				fq <- scheme.PrintID(bs, opt)
-				bs, opt = scheme.MakeEPC(true, param.Scheme, "3", param.CompanyPrefix, param.ItemReference, "", "", "", "")
+				bs, opt = scheme.MakeEPC(true, param.Scheme, "3", param.CompanyPrefix, param.ItemReference, "", "", "", "", "")
 				fq <- scheme.PrintID(bs, opt)
 			}
 
 			w := bufio.NewWriter(f)
 			for ser := 0; ser < NumSerial; ser++ {
-				bs, opt := scheme.MakeEPC(false, param.Scheme, "3", param.CompanyPrefix, param.ItemReference, "", strconv.Itoa(ser), "", "")
+				bs, opt := scheme.MakeEPC(false, param.Scheme, "3", param.CompanyPrefix, param.ItemReference, "", strconv.Itoa(ser), "", "", "")
 				w.WriteString(scheme.PrintID(bs, opt) + "\n")
 			}
 			w.Flush()
@@ -122,13 +122,13 @@ func generateUIISet(wg *sync.WaitGroup, q chan UIIParam, fq chan string) {
 			}
 
 			if !param.NoFilter {
-				bs, opt := scheme.MakeEPC(true, param.Scheme, "3", param.CompanyPrefix, "", "", "", "", "")
+				bs, opt := scheme.MakeEPC(true, param.Scheme, "3", param.CompanyPrefix, "", "", "", "", "", "")
 				fq <- scheme.PrintID(bs, opt)
 			}
 
 			w := bufio.NewWriter(f)
 			for ext := 0; ext < NumSerial; ext++ {
-				bs, opt := scheme.MakeEPC(false, param.Scheme, "3", param.CompanyPrefix, "", generateNonZeroDigits(param.ExtDigits), "", "", "")
+				bs, opt := scheme.MakeEPC(false, param.Scheme, "3", param.CompanyPrefix, "", generateNonZeroDigits(param.ExtDigits), "", "", "", "")
 				w.WriteString(scheme.PrintID(bs, opt) + "\n")
 			}
 			w.Flush()
@@ -146,14 +146,14 @@ func generateUIISet(wg *sync.WaitGroup, q chan UIIParam, fq chan string) {
 			}
 
 			if !param.NoFilter {
-				bs, opt := scheme.MakeEPC(true, param.Scheme, "3", param.CompanyPrefix, "", "", "", "", "")
+				bs, opt := scheme.MakeEPC(true, param.Scheme, "3", param.CompanyPrefix, "", "", "", "", "", "")
 				fq <- scheme.PrintID(bs, opt)
 			}
 
 			w := bufio.NewWriter(f)
 			for iar := 0; iar < NumSerial; iar++ {
 				iarLen := binutil.GenerateRandomInt(1, param.IARMaxDigits)
-				bs, opt := scheme.MakeEPC(false, param.Scheme, "3", param.CompanyPrefix, "", "", "", generateNonZeroDigits(iarLen), "")
+				bs, opt := scheme.MakeEPC(false, param.Scheme, "3", param.CompanyPrefix, "", "", "", generateNonZeroDigits(iarLen), "", "")
 				w.WriteString(scheme.PrintID(bs, opt) + "\n")
 			}
 			w.Flush()
@@ -171,15 +171,15 @@ func generateUIISet(wg *sync.WaitGroup, q chan UIIParam, fq chan string) {
 			}
 
 			if !param.NoFilter {
-				bs, opt := scheme.MakeEPC(true, param.Scheme, "3", param.CompanyPrefix, "", "", "", "", "")
+				bs, opt := scheme.MakeEPC(true, param.Scheme, "3", param.CompanyPrefix, "", "", "", "", "", "")
 				fq <- scheme.PrintID(bs, opt)
-				bs, opt = scheme.MakeEPC(true, param.Scheme, "3", param.CompanyPrefix, "", "", "", "", param.AssetType)
+				bs, opt = scheme.MakeEPC(true, param.Scheme, "3", param.CompanyPrefix, "", "", "", "", param.AssetType, "")
 				fq <- scheme.PrintID(bs, opt)
 			}
 
 			w := bufio.NewWriter(f)
 			for ser := 0; ser < NumSerial; ser++ {
-				bs, opt := scheme.MakeEPC(false, param.Scheme, "3", param.CompanyPrefix, "", "", strconv.Itoa(ser), "", param.AssetType)
+				bs, opt := scheme.MakeEPC(false, param.Scheme, "3", param.CompanyPrefix, "", "", strconv.Itoa(ser), "", param.AssetType, "")
 				w.WriteString(scheme.PrintID(bs, opt) + "\n")
 			}
 			w.Flush()